@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+type counters struct {
+	hits  atomic.Int64
+	flag  atomic.Bool
+	count atomic.Uint32
+	last  atomic.Pointer[string]
+	val   atomic.Value
+}
+
+func main() {
+	var c counters
+	c.hits.Store(42)
+	c.flag.Store(true)
+	c.count.Store(7)
+	s := "hello"
+	c.last.Store(&s)
+	c.val.Store(3)
+	var raw int64
+	atomic.AddInt64(&raw, 5)
+	runtime.Breakpoint()
+	fmt.Println(&c, raw)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func a(c1 chan int) {
+	c2 := make(chan int)
+	go b(c2)
+	c1 <- <-c2
+}
+
+func b(c2 chan int) {
+	time.Sleep(1 * time.Second)
+	runtime.Breakpoint()
+	c2 <- 1
+}
+
+func main() {
+	c1 := make(chan int)
+	go a(c1)
+	fmt.Println(<-c1)
+}
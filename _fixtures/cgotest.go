@@ -3,6 +3,7 @@ package main
 /*
 #include <stdio.h>
 char* foo(void) { return "hello, world!"; }
+int cglobal = 42;
 */
 import "C"
 
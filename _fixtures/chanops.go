@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func main() {
+	a := make(chan int, 10)
+	b := make(chan int, 10)
+	runtime.Breakpoint()
+	b <- 1
+	go func() {
+		a <- 42
+	}()
+	<-a
+	fmt.Println(len(b))
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func cleanup(name string, n int) {
+	fmt.Println(name, n)
+}
+
+func inner() {
+	// deferred calls inside loops are never open-coded
+	for i := 0; i < 1; i++ {
+		defer cleanup("inner", i)
+	}
+	runtime.Breakpoint()
+}
+
+func outer() {
+	for i := 0; i < 2; i++ {
+		defer cleanup("outer", i)
+	}
+	inner()
+}
+
+func main() {
+	outer()
+}
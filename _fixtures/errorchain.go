@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (e *wrapError) Error() string {
+	return e.msg + ": " + e.err.Error()
+}
+
+// hiddenError does not store the error it wraps in an interface field,
+// it can only be found calling Unwrap.
+type hiddenError struct {
+	wrapped *wrapError
+}
+
+func (e *hiddenError) Error() string {
+	return "hidden: " + e.wrapped.Error()
+}
+
+func (e *hiddenError) Unwrap() error {
+	return e.wrapped
+}
+
+func main() {
+	base := errors.New("base")
+	var err error = &wrapError{"top", &wrapError{"middle", base}}
+	var errnil error
+	hidden := &hiddenError{&wrapError{"inner", base}}
+	var errhidden error = hidden
+	runtime.Breakpoint()
+	fmt.Println(err, errnil, errhidden, hidden.Unwrap())
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+type astruct struct {
+	X int
+}
+
+func (a astruct) Double() int {
+	return a.X * 2
+}
+
+func (a *astruct) Inc(n int) int {
+	a.X += n
+	return a.X
+}
+
+func fib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return fib(n-1) + fib(n-2)
+}
+
+func square(f float64) float64 {
+	return f * f
+}
+
+func deref(p *int) int {
+	return *p
+}
+
+func greet(s string) string {
+	return "hello " + s
+}
+
+func mustBePositive(n int) int {
+	if n < 0 {
+		panic("negative")
+	}
+	return n
+}
+
+func nap(d time.Duration) int {
+	time.Sleep(d)
+	return 1
+}
+
+var spinCount int
+
+func spin() int {
+	for {
+		spinCount++
+	}
+}
+
+// gcFuncs are called by the debugger, they must be linked in
+var gcFuncs = []interface{}{runtime.GC, debug.SetGCPercent}
+
+func main() {
+	a := astruct{3}
+	pa := &a
+	n := 7
+	pn := &n
+	napTime := 500 * time.Millisecond
+	runtime.Breakpoint()
+	fmt.Println(a, pa, n, pn, fib(n), square(1.5), deref(pn), greet("world"), mustBePositive(n), a.Double(), pa.Inc(1), nap(0), napTime, len(gcFuncs))
+}
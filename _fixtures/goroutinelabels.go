@@ -0,0 +1,16 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"runtime/pprof"
+)
+
+func main() {
+	ctx := context.Background()
+	labels := pprof.Labels("k1", "v1", "k2", "v2")
+	runtime.Breakpoint()
+	pprof.Do(ctx, labels, func(context.Context) {
+		runtime.Breakpoint()
+	})
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+)
+
+func main() {
+	long := strings.Repeat("0123456789abcdef", 1<<16)
+	ints := make([]int, 1000)
+	for i := range ints {
+		ints[i] = i
+	}
+	m := make(map[int]int)
+	for i := 0; i < 100; i++ {
+		m[i] = i
+	}
+	runtime.Breakpoint()
+	println(len(long), len(ints), len(m))
+}
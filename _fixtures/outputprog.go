@@ -0,0 +1,14 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func main() {
+	fmt.Println("first stdout")
+	fmt.Fprintln(os.Stderr, "first stderr")
+	runtime.Breakpoint()
+	fmt.Println("second stdout")
+}
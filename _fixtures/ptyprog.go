@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+func isatty(f *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
+
+func main() {
+	stdoutTTY := isatty(os.Stdout)
+	runtime.Breakpoint()
+	fmt.Println(stdoutTTY)
+}
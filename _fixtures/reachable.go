@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type T struct {
+	a, b int
+}
+
+var global *T
+
+func main() {
+	global = &T{1, 2}
+	local := &T{3, 4}
+	runtime.Breakpoint()
+	fmt.Println(global, local)
+}
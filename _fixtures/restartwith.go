@@ -0,0 +1,14 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func main() {
+	args := os.Args[1:]
+	env := os.Getenv("DLV_RESTART_ENV")
+	runtime.Breakpoint()
+	fmt.Println(args, env)
+}
@@ -0,0 +1,14 @@
+package main
+
+import "fmt"
+
+func main() {
+	x := 1
+	y := 2
+	if x > 0 {
+		x := 3
+		z := x + y
+		fmt.Println(x, z)
+	}
+	fmt.Println(x, y)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+func receive(ch chan os.Signal) bool {
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	select {
+	case <-ch:
+		return true
+	case <-time.After(500 * time.Millisecond):
+		return false
+	}
+}
+
+func main() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1)
+	for i := 0; i < 2; i++ {
+		received := receive(ch)
+		runtime.Breakpoint()
+		fmt.Println(i, received)
+	}
+}
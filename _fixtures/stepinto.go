@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+func first(x int) int {
+	return x + 1
+}
+
+func second(x int) int {
+	return x * 2
+}
+
+func main() {
+	x := second(first(1))
+	fmt.Println(x)
+}
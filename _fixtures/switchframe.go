@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+func callee(b int) int {
+	return b * 2
+}
+
+func caller(a int) int {
+	return callee(a + 1)
+}
+
+func main() {
+	fmt.Println(caller(1))
+}
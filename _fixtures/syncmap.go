@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+func main() {
+	var sm sync.Map
+	sm.Store("a", 1)
+	sm.Store("b", 2)
+	// promote the dirty map to the read-only map
+	for i := 0; i < 3; i++ {
+		sm.Load("x")
+	}
+	sm.Store("c", 3)
+	sm.Delete("a")
+	var empty sync.Map
+	runtime.Breakpoint()
+	fmt.Println(&sm, &empty)
+}
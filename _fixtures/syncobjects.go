@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+func main() {
+	var wg sync.WaitGroup
+	var once, once2 sync.Once
+	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+	wg.Add(3)
+	once.Do(func() {})
+	runtime.Breakpoint()
+	wg.Add(-3)
+	wg.Wait()
+	once2.Do(func() {})
+	cond.Broadcast()
+	fmt.Println(&wg, &once, &once2, cond)
+}
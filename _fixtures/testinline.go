@@ -0,0 +1,15 @@
+package main
+
+import "fmt"
+
+func inlineThis(a int) int {
+	z := a * a
+	return z + a
+}
+
+func main() {
+	var a = 3
+	z := inlineThis(a)
+	z += inlineThis(z)
+	fmt.Printf("%d\n", z)
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func tick(i int) {
+	fmt.Println("tick", i)
+}
+
+func main() {
+	for i := 0; i < 20; i++ {
+		tick(i)
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var global int
+
+func local() int {
+	x := 0
+	runtime.Breakpoint()
+	for i := 0; i < 3; i++ {
+		x += i
+	}
+	return x
+}
+
+func main() {
+	runtime.Breakpoint()
+	global = 1
+	fmt.Println(global)
+	n := local()
+	runtime.Breakpoint()
+	global = n
+	fmt.Println(global, n)
+}
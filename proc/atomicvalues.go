@@ -0,0 +1,78 @@
+package proc
+
+import (
+	"go/constant"
+	"reflect"
+	"strings"
+
+	"golang.org/x/debug/dwarf"
+)
+
+// loadAtomicWrapper loads the typed wrappers of the sync/atomic package
+// (atomic.Int64, atomic.Bool, atomic.Pointer[T], atomic.Value...) as the
+// value they contain instead of as a struct with a single field.
+// The type of v is left unchanged.
+// Returns false, leaving v unchanged, if v is not one of these types or
+// its layout is not recognized.
+func (v *Variable) loadAtomicWrapper(recurseLevel int, cfg LoadConfig) bool {
+	name := v.RealType.Common().Name
+	if !strings.HasPrefix(name, "sync/atomic.") {
+		return false
+	}
+	inner, err := v.structMember("v")
+	if err != nil {
+		return false
+	}
+
+	isBool := false
+	switch {
+	case name == "sync/atomic.Int32", name == "sync/atomic.Int64", name == "sync/atomic.Uint32", name == "sync/atomic.Uint64", name == "sync/atomic.Uintptr", name == "sync/atomic.Value":
+		// nothing to do
+	case name == "sync/atomic.Bool":
+		isBool = true
+	case strings.HasPrefix(name, "sync/atomic.Pointer["):
+		// The field v of atomic.Pointer[T] is an unsafe.Pointer, the type T
+		// is recorded in the type of the zero sized field _ [0]*T.
+		ptrtyp := atomicPointerType(v.RealType.(*dwarf.StructType))
+		if ptrtyp == nil {
+			return false
+		}
+		inner = newVariable(v.Name, inner.Addr, ptrtyp, v.dbp, v.mem)
+	default:
+		return false
+	}
+
+	inner.loadValueInternal(recurseLevel, cfg)
+	if isBool && inner.Unreadable == nil {
+		n, err := inner.asUint()
+		if err != nil {
+			return false
+		}
+		inner.Kind = reflect.Bool
+		inner.Value = constant.MakeBool(n != 0)
+	}
+
+	v.Kind = inner.Kind
+	v.Value = inner.Value
+	v.Len = inner.Len
+	v.Cap = inner.Cap
+	v.Base = inner.Base
+	v.Children = inner.Children
+	v.Unreadable = inner.Unreadable
+	return true
+}
+
+// atomicPointerType returns the type *T of an atomic.Pointer[T] struct.
+func atomicPointerType(t *dwarf.StructType) *dwarf.PtrType {
+	for _, field := range t.Field {
+		if field.Name != "_" {
+			continue
+		}
+		if arr, ok := resolveTypedef(field.Type).(*dwarf.ArrayType); ok {
+			if ptrtyp, ok := resolveTypedef(arr.Type).(*dwarf.PtrType); ok {
+				return ptrtyp
+			}
+		}
+	}
+	return nil
+}
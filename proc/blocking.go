@@ -0,0 +1,180 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/debug/dwarf"
+)
+
+// maxSelectCases is the maximum number of cases of a select statement.
+const maxSelectCases = 1 << 16
+
+// BlockingChain returns the chain of goroutines that the goroutine gid is
+// waiting for, starting with the goroutine itself.
+// A goroutine blocked on a channel operation, or on a select statement, is
+// considered to be waiting for the first other goroutine whose stack
+// contains a reference to one of the channels and that is not itself
+// blocked on them. The chain ends with a goroutine that is not blocked on
+// a channel, with a goroutine for which no such reference can be found or,
+// if the goroutines are deadlocked, with the first goroutine repeated.
+func (dbp *Process) BlockingChain(gid int) ([]*G, error) {
+	if dbp.exited {
+		return nil, &ProcessExitedError{}
+	}
+	g, err := dbp.FindGoroutine(gid)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, errors.New("no selected goroutine")
+	}
+	gs, err := dbp.GoroutinesInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []*G{g}
+	visited := map[int]bool{g.ID: true}
+	for {
+		chans, err := g.waitingChannels()
+		if err != nil || len(chans) == 0 {
+			break
+		}
+		next, err := dbp.channelCounterpart(gs, g, chans)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			break
+		}
+		chain = append(chain, next)
+		if visited[next.ID] {
+			break
+		}
+		visited[next.ID] = true
+		g = next
+	}
+	return chain, nil
+}
+
+// channelCounterpart returns the first goroutine in gs, other than g, that
+// holds a reference to one of chans in its stack and is not blocked on
+// any of them.
+func (dbp *Process) channelCounterpart(gs []*G, g *G, chans []uint64) (*G, error) {
+	for _, other := range gs {
+		if other.ID == g.ID || other.Status == Gdead {
+			continue
+		}
+		if otherChans, err := other.waitingChannels(); err == nil && sharesChannel(otherChans, chans) {
+			continue
+		}
+		_, hi, used, err := other.StackUsage()
+		if err != nil {
+			continue
+		}
+		for _, ch := range chans {
+			found := false
+			err := dbp.scanForPointer(hi-used, hi, ch, func(uint64) { found = true })
+			if err != nil {
+				return nil, fmt.Errorf("could not read stack of goroutine %d: %v", other.ID, err)
+			}
+			if found {
+				return other, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func sharesChannel(a, b []uint64) bool {
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// waitingChannels returns the addresses of the channels g is blocked on,
+// if g is blocked on a channel operation or select statement.
+func (g *G) waitingChannels() ([]uint64, error) {
+	// the scan bit can be set in addition to the goroutine status
+	const gscan = 0x1000
+	if g.Status&^gscan != Gwaiting || g.thread != nil {
+		return nil, nil
+	}
+	gvar, err := g.dbp.CurrentThread.newGVariable(uintptr(g.Addr), false)
+	if err != nil {
+		return nil, err
+	}
+	waiting, err := gvar.structMember("waiting")
+	if err != nil {
+		return nil, err
+	}
+	ptrSize := int64(g.dbp.arch.PtrSize())
+	sudog, err := readUintRaw(waiting.mem, waiting.Addr, ptrSize)
+	if err != nil {
+		return nil, err
+	}
+	if sudog == 0 {
+		return nil, nil
+	}
+	typ, err := g.dbp.findType("runtime.sudog")
+	if err != nil {
+		return nil, err
+	}
+
+	var chans []uint64
+	// the sudogs of a select statement are linked through waitlink
+	for i := 0; sudog != 0 && i < maxSelectCases; i++ {
+		sv := newVariable("", uintptr(sudog), typ, g.dbp, g.dbp.CurrentThread)
+		c, err := sv.structMember("c")
+		if err != nil {
+			return nil, err
+		}
+		ch, err := readUintRaw(c.mem, c.Addr, ptrSize)
+		if err != nil {
+			return nil, err
+		}
+		if ch != 0 {
+			chans = append(chans, ch)
+		}
+		link, err := sv.structMember("waitlink")
+		if err != nil {
+			return nil, err
+		}
+		if sudog, err = readUintRaw(link.mem, link.Addr, ptrSize); err != nil {
+			return nil, err
+		}
+	}
+	return chans, nil
+}
+
+// ChannelInfo evaluates expr, which must be a channel, and returns the
+// address of its runtime.hchan struct and the name of the type of its
+// elements.
+func (scope *EvalScope) ChannelInfo(expr string) (uint64, string, error) {
+	t, err := ParseExpr(expr)
+	if err != nil {
+		return 0, "", err
+	}
+	v, err := scope.evalAST(t)
+	if err != nil {
+		return 0, "", err
+	}
+	chtyp, ok := v.RealType.(*dwarf.ChanType)
+	if !ok {
+		return 0, "", fmt.Errorf("expression \"%s\" is not a channel", expr)
+	}
+	v.loadValue(loadSingleValue)
+	if v.Unreadable != nil {
+		return 0, "", v.Unreadable
+	}
+	if v.Base == 0 {
+		return 0, "", fmt.Errorf("channel \"%s\" is nil", expr)
+	}
+	return uint64(v.Base), chtyp.ElemType.String(), nil
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"go/ast"
 	"go/constant"
+	"go/parser"
+	"go/token"
 	"reflect"
+	"time"
 )
 
 // Breakpoint represents a breakpoint. Stores information on the break
@@ -20,18 +23,47 @@ type Breakpoint struct {
 	Addr         uint64         // Address breakpoint is set for.
 	OriginalData []byte         // If software breakpoint, the data we replace with breakpoint instruction.
 	Name         string         // User defined name of the breakpoint
+	Group        string         // Group of breakpoints created together, see CreateBreakpoints in package debugger
 	ID           int            // Monotonically increasing ID.
 	Kind         BreakpointKind // Whether this is an internal breakpoint (for next'ing or stepping).
 
 	// Breakpoint information
 	Tracepoint    bool     // Tracepoint flag
 	Goroutine     bool     // Retrieve goroutine information
+	GoroutineID   int      // If not zero the breakpoint only triggers on this goroutine
+	ThreadID      int      // If not zero the breakpoint only triggers on this thread
 	Stacktrace    int      // Number of stack frames to retrieve
 	Variables     []string // Variables to evaluate
 	LoadArgs      *LoadConfig
 	LoadLocals    *LoadConfig
 	HitCount      map[int]uint64 // Number of times a breakpoint has been reached in a certain goroutine
 	TotalHitCount uint64         // Number of times a breakpoint has been reached
+	Ignore        int            // Number of times the breakpoint will be reached without stopping
+	HitCond       string         // Condition on TotalHitCount, see SetHitCond
+	Aggregate     string         // Numeric expression accumulated by a tracepoint instead of stopping
+	LogMessage    string         // Message rendered when a tracepoint is reached, expressions between braces are replaced by their value
+	LogToFile     string         // File the LogMessage of a tracepoint is appended to instead of stopping
+	CondEvalTime  time.Duration  // Total time spent evaluating Cond
+	CaptureTime   time.Duration  // Total time spent retrieving the breakpoint information
+	ActiveAfter   time.Duration  // The breakpoint is ignored until the process has been running for this much time, time spent stopped is not counted
+	ActiveUntil   time.Duration  // If not zero the breakpoint is ignored once the process has been running for this much time
+
+	// AnchorFunction and AnchorStatement, if AnchorFunction is not empty,
+	// identify the location of the breakpoint as a statement of a function,
+	// see StatementIndex.
+	AnchorFunction  string
+	AnchorStatement int
+
+	// WatchExpr and WatchType, if WatchType is not zero, describe a hardware
+	// watchpoint on the memory at Addr, see SetWatchpoint.
+	WatchExpr      string
+	WatchType      WatchType
+	watchSize      int
+	hwidx          int    // debug register used by the watchpoint
+	watchStack     bool   // the watched memory is a local variable of goroutine watchGoroutine
+	watchGoroutine int    // goroutine of the watched local variable
+	watchCFA       int64  // CFA of the frame of the watched local variable
+	watchFnEntry   uint64 // entry point of the function of the watched local variable
 
 	// DeferReturns: when kind == NextDeferBreakpoint this breakpoint
 	// will also check if the caller is runtime.gopanic or if the return
@@ -45,6 +77,8 @@ type Breakpoint struct {
 	DeferReturns []uint64
 	// Cond: if not nil the breakpoint will be triggered only if evaluating Cond returns true
 	Cond ast.Expr
+	// hitCond is the parsed form of HitCond
+	hitCond ast.Expr
 }
 
 // Breakpoint Kind determines the behavior of delve when the
@@ -75,6 +109,14 @@ func (bp *Breakpoint) String() string {
 // Clear this breakpoint appropriately depending on whether it is a
 // hardware or software breakpoint.
 func (bp *Breakpoint) Clear(thread *Thread) (*Breakpoint, error) {
+	if bp.IsWatchpoint() {
+		for _, th := range thread.dbp.Threads {
+			if err := th.clearWatchpoint(bp); err != nil {
+				return nil, err
+			}
+		}
+		return bp, nil
+	}
 	if _, err := thread.writeMemory(uintptr(bp.Addr), bp.OriginalData); err != nil {
 		return nil, fmt.Errorf("could not clear breakpoint %s", err)
 	}
@@ -109,6 +151,22 @@ func (dbp *Process) writeSoftwareBreakpoint(thread *Thread, addr uint64) error {
 }
 
 func (bp *Breakpoint) checkCondition(thread *Thread) (bool, error) {
+	if bp.ActiveAfter > 0 || bp.ActiveUntil > 0 {
+		elapsed := thread.dbp.runTime
+		if elapsed < bp.ActiveAfter || (bp.ActiveUntil > 0 && elapsed >= bp.ActiveUntil) {
+			return false, nil
+		}
+	}
+	if bp.ThreadID > 0 && thread.ID != bp.ThreadID {
+		return false, nil
+	}
+	if bp.GoroutineID > 0 {
+		// threads not running a goroutine never match
+		g, err := thread.GetG()
+		if err != nil || g == nil || g.ID != bp.GoroutineID {
+			return false, nil
+		}
+	}
 	if bp.Cond == nil {
 		return true, nil
 	}
@@ -130,6 +188,11 @@ func (bp *Breakpoint) checkCondition(thread *Thread) (bool, error) {
 			}
 		}
 	}
+	// the other threads are stopped in the middle of Continue, a function
+	// call would resume them
+	oldForbidden := thread.dbp.fncallForbidden
+	thread.dbp.fncallForbidden = true
+	defer func() { thread.dbp.fncallForbidden = oldForbidden }()
 	scope, err := thread.Scope()
 	if err != nil {
 		return true, err
@@ -147,6 +210,88 @@ func (bp *Breakpoint) checkCondition(thread *Thread) (bool, error) {
 	return constant.BoolVal(v.Value), nil
 }
 
+// hitCountIdent is the implicit left operand of hit conditions.
+const hitCountIdent = "hitcount"
+
+// SetHitCond sets the hit condition of the breakpoint: an operator
+// followed by an integer expression, such as ">= 5" or "% 2 == 0", which
+// is applied to the total hit count of the breakpoint. Once Cond is
+// satisfied the breakpoint is triggered only if the hit condition is
+// true, the hit counts are updated either way.
+// An empty string removes the hit condition.
+func (bp *Breakpoint) SetHitCond(hitCond string) error {
+	if hitCond == "" {
+		bp.HitCond, bp.hitCond = "", nil
+		return nil
+	}
+	expr, err := parser.ParseExpr(hitCountIdent + " " + hitCond)
+	if err != nil {
+		return fmt.Errorf("invalid hit condition %q: %v", hitCond, err)
+	}
+	v, err := evalHitCond(expr, 1)
+	if err != nil {
+		return fmt.Errorf("invalid hit condition %q: %v", hitCond, err)
+	}
+	if v.Kind() != constant.Bool {
+		return fmt.Errorf("invalid hit condition %q: not a boolean expression", hitCond)
+	}
+	bp.HitCond, bp.hitCond = hitCond, expr
+	return nil
+}
+
+// evalHitCond evaluates a hit condition for hit count n.
+func evalHitCond(expr ast.Expr, n uint64) (constant.Value, error) {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return evalHitCond(expr.X, n)
+	case *ast.Ident:
+		if expr.Name != hitCountIdent {
+			return nil, fmt.Errorf("unknown identifier %s", expr.Name)
+		}
+		return constant.MakeUint64(n), nil
+	case *ast.BasicLit:
+		if expr.Kind != token.INT {
+			return nil, fmt.Errorf("%s is not an integer", expr.Value)
+		}
+		return constant.MakeFromLiteral(expr.Value, expr.Kind, 0), nil
+	case *ast.BinaryExpr:
+		x, err := evalHitCond(expr.X, n)
+		if err != nil {
+			return nil, err
+		}
+		y, err := evalHitCond(expr.Y, n)
+		if err != nil {
+			return nil, err
+		}
+		switch expr.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			if x.Kind() != constant.Int || y.Kind() != constant.Int {
+				return nil, errors.New("comparison between non-integer values")
+			}
+			return constant.MakeBool(constant.Compare(x, expr.Op, y)), nil
+		case token.LAND, token.LOR:
+			if x.Kind() != constant.Bool || y.Kind() != constant.Bool {
+				return nil, fmt.Errorf("operator %s applied to non-boolean values", expr.Op)
+			}
+			return constant.BinaryOp(x, expr.Op, y), nil
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+			if x.Kind() != constant.Int || y.Kind() != constant.Int {
+				return nil, fmt.Errorf("operator %s applied to non-integer values", expr.Op)
+			}
+			if (expr.Op == token.QUO || expr.Op == token.REM) && constant.Sign(y) == 0 {
+				return nil, errors.New("division by zero")
+			}
+			op := expr.Op
+			if op == token.QUO {
+				op = token.QUO_ASSIGN // integer division
+			}
+			return constant.BinaryOp(x, op, y), nil
+		}
+		return nil, fmt.Errorf("operator %s not supported in hit conditions", expr.Op)
+	}
+	return nil, errors.New("unsupported expression in hit condition")
+}
+
 // Internal returns true for breakpoints not set directly by the user.
 func (bp *Breakpoint) Internal() bool {
 	return bp.Kind != UserBreakpoint
@@ -161,3 +306,68 @@ type NoBreakpointError struct {
 func (nbp NoBreakpointError) Error() string {
 	return fmt.Sprintf("no breakpoint at %#v", nbp.addr)
 }
+
+const (
+	// maxAheadFrames is the number of stack frames examined by
+	// BreakpointsAhead.
+	maxAheadFrames = 50
+	// maxAheadCallDepth is how deep BreakpointsAhead follows calls.
+	maxAheadCallDepth = 3
+)
+
+// BreakpointsAhead returns a best effort guess of the user breakpoints
+// goroutine g will reach next. For each frame of the goroutine's stack
+// the instructions from the current PC to the end of the function are
+// examined, along with the functions they call directly.
+// Branches, calls through function pointers and interface method calls
+// are not followed, so the result can both miss breakpoints and include
+// ones that will never be reached.
+func (dbp *Process) BreakpointsAhead(g *G) ([]*Breakpoint, error) {
+	if dbp.exited {
+		return nil, &ProcessExitedError{}
+	}
+	frames, err := g.Stacktrace(maxAheadFrames)
+	if err != nil {
+		return nil, err
+	}
+	var r []*Breakpoint
+	seen := make(map[uint64]bool)
+	visited := make(map[uint64]bool)
+	var scan func(pc uint64, depth int) error
+	scan = func(pc uint64, depth int) error {
+		_, _, fn := dbp.PCToLine(pc)
+		if fn == nil {
+			return nil
+		}
+		text, err := dbp.CurrentThread.Disassemble(pc, fn.End, false)
+		if err != nil {
+			return err
+		}
+		for _, instr := range text {
+			if bp, ok := dbp.Breakpoints[instr.Loc.PC]; ok && !bp.Internal() && !seen[bp.Addr] {
+				seen[bp.Addr] = true
+				r = append(r, bp)
+			}
+			if instr.Inst == nil || !instr.IsCall() || instr.DestLoc == nil || instr.DestLoc.Fn == nil || depth >= maxAheadCallDepth {
+				continue
+			}
+			if entry := instr.DestLoc.Fn.Entry; !visited[entry] {
+				visited[entry] = true
+				if err := scan(entry, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if len(frames) > 0 {
+		// the breakpoint the goroutine is stopped at, if any, is behind it
+		seen[frames[0].Current.PC] = true
+	}
+	for i := range frames {
+		if err := scan(frames[i].Current.PC, 0); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,206 @@
+package proc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BuildInfo is the module information embedded in the executable by the
+// Go toolchain, the same data returned by runtime/debug.ReadBuildInfo.
+type BuildInfo struct {
+	GoVersion string   // Version of Go that produced the executable
+	Path      string   // Package path of the main package
+	Main      Module   // Main module
+	Deps      []Module // Module dependencies
+}
+
+// Module describes a module listed in the build information.
+type Module struct {
+	Path    string
+	Version string
+	Sum     string
+	Replace *Module // Replacement of this module, if any
+}
+
+var (
+	buildInfoMagic = []byte("\xff Go buildinf:")
+	buildIDPrefix  = []byte("\xff Go build ID: \"")
+	buildIDSuffix  = []byte("\"\n \xff")
+
+	NoBuildInfoErr = errors.New("executable does not contain module information")
+)
+
+const (
+	buildInfoHeaderSize = 32
+	// maximum distance from the start of the text segment where the build
+	// ID can be found.
+	buildIDSearchLen = 32 * 1024
+)
+
+// findBuildInfo returns the offset of the build information header in data
+// or -1 if data does not contain it. The header is always 16 byte aligned.
+func findBuildInfo(data []byte) int {
+	for off := 0; off+buildInfoHeaderSize <= len(data); off += 16 {
+		if bytes.HasPrefix(data[off:], buildInfoMagic) {
+			return off
+		}
+	}
+	return -1
+}
+
+// findBuildID returns the Go build ID written by the linker at the start
+// of the text segment.
+func findBuildID(text []byte) string {
+	if len(text) > buildIDSearchLen {
+		text = text[:buildIDSearchLen]
+	}
+	i := bytes.Index(text, buildIDPrefix)
+	if i < 0 {
+		return ""
+	}
+	text = text[i+len(buildIDPrefix):]
+	j := bytes.Index(text, buildIDSuffix)
+	if j < 0 {
+		return ""
+	}
+	return string(text[:j])
+}
+
+// parseBuildIDNote returns the Go build ID stored in the contents of a
+// .note.go.buildid ELF section.
+func parseBuildIDNote(note []byte) string {
+	if len(note) < 16 {
+		return ""
+	}
+	namesz := binary.LittleEndian.Uint32(note[0:])
+	descsz := binary.LittleEndian.Uint32(note[4:])
+	off := 12 + int((namesz+3)&^3)
+	if namesz != 4 || !bytes.Equal(note[12:15], []byte("Go\x00")) || off+int(descsz) > len(note) {
+		return ""
+	}
+	return string(note[off : off+int(descsz)])
+}
+
+// BuildID returns the Go build ID of the executable.
+func (dbp *Process) BuildID() string {
+	return dbp.buildID
+}
+
+// BuildInfo returns the module information embedded in the executable.
+func (dbp *Process) BuildInfo() (*BuildInfo, error) {
+	if dbp.exited {
+		return nil, &ProcessExitedError{}
+	}
+	data := dbp.buildInfoData
+	if len(data) < buildInfoHeaderSize {
+		return nil, NoBuildInfoErr
+	}
+
+	ptrSize := int(data[14])
+	flags := data[15]
+	var order binary.ByteOrder = binary.LittleEndian
+	if flags&1 != 0 {
+		order = binary.BigEndian
+	}
+
+	var vers, mod string
+	if flags&2 != 0 {
+		// Since go1.18 both strings are stored inline after the header,
+		// prefixed by their length.
+		var rest []byte
+		vers, rest = decodeBuildInfoString(data[buildInfoHeaderSize:])
+		mod, _ = decodeBuildInfoString(rest)
+	} else {
+		// Before go1.18 the header contains two pointers to string headers
+		// that must be read from the target's memory.
+		if ptrSize != 4 && ptrSize != 8 {
+			return nil, fmt.Errorf("invalid pointer size in module information: %d", ptrSize)
+		}
+		readPtr := func(b []byte) uint64 {
+			if ptrSize == 4 {
+				return uint64(order.Uint32(b))
+			}
+			return order.Uint64(b)
+		}
+		readString := func(addr uint64) (string, error) {
+			hdr, err := dbp.CurrentThread.readMemory(uintptr(addr), 2*ptrSize)
+			if err != nil {
+				return "", err
+			}
+			strlen := int(readPtr(hdr[ptrSize:]))
+			if strlen == 0 {
+				return "", nil
+			}
+			str, err := dbp.CurrentThread.readMemory(uintptr(readPtr(hdr)), strlen)
+			return string(str), err
+		}
+		var err error
+		if vers, err = readString(readPtr(data[16:])); err != nil {
+			return nil, fmt.Errorf("could not read Go version: %v", err)
+		}
+		if mod, err = readString(readPtr(data[16+ptrSize:])); err != nil {
+			return nil, fmt.Errorf("could not read module information: %v", err)
+		}
+	}
+
+	// The module information is surrounded by 16 byte sentinels.
+	if len(mod) >= 33 && mod[len(mod)-17] == '\n' {
+		mod = mod[16 : len(mod)-16]
+	} else {
+		mod = ""
+	}
+
+	bi := parseModInfo(mod)
+	bi.GoVersion = vers
+	return bi, nil
+}
+
+func decodeBuildInfoString(data []byte) (string, []byte) {
+	n, sz := binary.Uvarint(data)
+	if sz <= 0 || uint64(len(data)-sz) < n {
+		return "", nil
+	}
+	return string(data[sz : sz+int(n)]), data[sz+int(n):]
+}
+
+// parseModInfo parses the module information string, see
+// runtime/debug.ParseBuildInfo for a description of the format.
+func parseModInfo(mod string) *BuildInfo {
+	bi := &BuildInfo{}
+	var last *Module
+	for _, line := range strings.Split(mod, "\n") {
+		fields := strings.Split(line, "\t")
+		switch fields[0] {
+		case "path":
+			if len(fields) >= 2 {
+				bi.Path = fields[1]
+			}
+		case "mod":
+			bi.Main = newModule(fields[1:])
+			last = &bi.Main
+		case "dep":
+			bi.Deps = append(bi.Deps, newModule(fields[1:]))
+			last = &bi.Deps[len(bi.Deps)-1]
+		case "=>":
+			if last != nil && last.Replace == nil {
+				m := newModule(fields[1:])
+				last.Replace = &m
+			}
+			last = nil
+		}
+	}
+	return bi
+}
+
+func newModule(fields []string) Module {
+	var m Module
+	for i, dst := range []*string{&m.Path, &m.Version, &m.Sum} {
+		if i < len(fields) {
+			*dst = fields[i]
+		}
+	}
+	return m
+}
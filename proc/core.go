@@ -0,0 +1,78 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CoreReadOnlyErr is returned by the operations that would need to modify
+// or resume the process when examining a core file.
+var CoreReadOnlyErr = errors.New("can not modify or resume the process of a core file")
+
+// coreFile is the memory and the registers of the threads of a process
+// saved in a core file.
+type coreFile struct {
+	// mappings are the memory ranges contained in the core file followed by
+	// the ones of the executable, the first mapping containing an address
+	// is used to read it.
+	mappings []coreMapping
+	// threads are the registers of each thread, by thread ID.
+	threads map[int]Registers
+	// comm is the command name of the process.
+	comm    string
+	closers []io.Closer
+}
+
+// coreMapping is a range of memory [start, end) read from r at offset
+// start-addr.
+type coreMapping struct {
+	start, end uint64
+	r          io.ReaderAt
+}
+
+// IsCore returns true if the process is a core file rather than a live
+// process.
+func (dbp *Process) IsCore() bool {
+	return dbp.core != nil
+}
+
+func (c *coreFile) readMemory(addr uintptr, size int) ([]byte, error) {
+	data := make([]byte, size)
+	for n := 0; n < size; {
+		cur := uint64(addr) + uint64(n)
+		m := c.findMapping(cur)
+		if m == nil {
+			return nil, fmt.Errorf("could not read memory at %#x: not contained in the core file", cur)
+		}
+		end := uint64(addr) + uint64(size)
+		if end > m.end {
+			end = m.end
+		}
+		read, err := m.r.ReadAt(data[n:n+int(end-cur)], int64(cur-m.start))
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if read == 0 {
+			return nil, fmt.Errorf("could not read memory at %#x", cur)
+		}
+		n += read
+	}
+	return data, nil
+}
+
+func (c *coreFile) findMapping(addr uint64) *coreMapping {
+	for i := range c.mappings {
+		if addr >= c.mappings[i].start && addr < c.mappings[i].end {
+			return &c.mappings[i]
+		}
+	}
+	return nil
+}
+
+func (c *coreFile) close() {
+	for _, closer := range c.closers {
+		closer.Close()
+	}
+	c.closers = nil
+}
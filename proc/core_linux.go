@@ -0,0 +1,158 @@
+package proc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unsafe"
+
+	sys "golang.org/x/sys/unix"
+
+	"golang.org/x/debug/elf"
+)
+
+// Types of the notes of a linux core file.
+const (
+	_NT_PRSTATUS = 1
+	_NT_PRPSINFO = 3
+)
+
+// Offsets of the fields of struct elf_prstatus and struct elf_prpsinfo
+// on linux/amd64.
+const (
+	prstatusPidOffset   = 32
+	prstatusRegOffset   = 112
+	prpsinfoFnameOffset = 40
+	prpsinfoFnameSize   = 16
+)
+
+// OpenCore opens the core file at corePath, produced by the executable at
+// exePath, for examination.
+// The returned process can not be resumed or modified: Continue, the
+// step functions and SetBreakpoint return CoreReadOnlyErr.
+func OpenCore(corePath, exePath string) (*Process, error) {
+	core, err := elf.Open(corePath)
+	if err != nil {
+		return nil, err
+	}
+	if core.Type != elf.ET_CORE {
+		core.Close()
+		return nil, fmt.Errorf("%s is not a core file", corePath)
+	}
+	if core.Machine != elf.EM_X86_64 {
+		core.Close()
+		return nil, UnsupportedArchErr
+	}
+	exe, err := elf.Open(exePath)
+	if err != nil {
+		core.Close()
+		return nil, err
+	}
+
+	c := &coreFile{threads: make(map[int]Registers), closers: []io.Closer{core, exe}}
+	dbp := New(0)
+	dbp.core = c
+	fail := func(err error) (*Process, error) {
+		c.close()
+		dbp.postExit()
+		return nil, err
+	}
+
+	for _, prog := range core.Progs {
+		switch prog.Type {
+		case elf.PT_LOAD:
+			if prog.Filesz > 0 {
+				c.mappings = append(c.mappings, coreMapping{start: prog.Vaddr, end: prog.Vaddr + prog.Filesz, r: prog})
+			}
+		case elf.PT_NOTE:
+			if err := dbp.readCoreNotes(prog); err != nil {
+				return fail(fmt.Errorf("could not read notes of core file: %v", err))
+			}
+		}
+	}
+	// the kernel does not save the memory mapped from the executable
+	// unless it was modified, it is read from the executable itself
+	for _, prog := range exe.Progs {
+		if prog.Type == elf.PT_LOAD && prog.Filesz > 0 {
+			c.mappings = append(c.mappings, coreMapping{start: prog.Vaddr, end: prog.Vaddr + prog.Filesz, r: prog})
+		}
+	}
+	if dbp.CurrentThread == nil {
+		return fail(errors.New("core file does not contain any thread"))
+	}
+
+	if err := dbp.LoadInformation(exePath); err != nil {
+		return fail(err)
+	}
+	ver, isextld, err := dbp.getGoInformation()
+	if err != nil {
+		return fail(err)
+	}
+	dbp.arch.SetGStructOffset(ver, isextld)
+	dbp.SelectedGoroutine, _ = dbp.CurrentThread.GetG()
+	return dbp, nil
+}
+
+// readCoreNotes reads the threads and the command name of the process
+// from the PT_NOTE segment prog of a core file.
+// The first thread is the one that received the signal that caused the
+// core dump, it becomes the current thread.
+func (dbp *Process) readCoreNotes(prog *elf.Prog) error {
+	notes, err := ioutil.ReadAll(prog.Open())
+	if err != nil {
+		return err
+	}
+	align4 := func(n uint32) int { return int((n + 3) &^ 3) }
+	for len(notes) >= 12 {
+		namesz := binary.LittleEndian.Uint32(notes[0:])
+		descsz := binary.LittleEndian.Uint32(notes[4:])
+		typ := binary.LittleEndian.Uint32(notes[8:])
+		notes = notes[12:]
+		if len(notes) < align4(namesz)+int(descsz) {
+			return errors.New("truncated note")
+		}
+		notes = notes[align4(namesz):]
+		desc := notes[:descsz]
+		if len(notes) > align4(descsz) {
+			notes = notes[align4(descsz):]
+		} else {
+			notes = nil
+		}
+
+		switch typ {
+		case _NT_PRSTATUS:
+			var regs sys.PtraceRegs
+			if len(desc) < prstatusRegOffset+int(unsafe.Sizeof(regs)) {
+				return errors.New("truncated NT_PRSTATUS note")
+			}
+			tid := int(int32(binary.LittleEndian.Uint32(desc[prstatusPidOffset:])))
+			if err := binary.Read(bytes.NewReader(desc[prstatusRegOffset:]), binary.LittleEndian, &regs); err != nil {
+				return err
+			}
+			dbp.core.threads[tid] = &Regs{&regs}
+			dbp.Threads[tid] = &Thread{
+				ID:  tid,
+				dbp: dbp,
+				os:  new(OSSpecificDetails),
+			}
+			if dbp.CurrentThread == nil {
+				dbp.Pid = tid
+				dbp.CurrentThread = dbp.Threads[tid]
+			}
+		case _NT_PRPSINFO:
+			if len(desc) < prpsinfoFnameOffset+prpsinfoFnameSize {
+				return errors.New("truncated NT_PRPSINFO note")
+			}
+			fname := desc[prpsinfoFnameOffset : prpsinfoFnameOffset+prpsinfoFnameSize]
+			if i := bytes.IndexByte(fname, 0); i >= 0 {
+				fname = fname[:i]
+			}
+			dbp.core.comm = strings.Replace(string(fname), "%", "%%", -1)
+		}
+	}
+	return nil
+}
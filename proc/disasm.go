@@ -7,6 +7,15 @@ type AsmInstruction struct {
 	Breakpoint bool
 	AtPC       bool
 	Inst       *ArchInst
+	// ResolvedTarget is the absolute address of the PC relative operand of
+	// the instruction, a relative jump or call target or a RIP relative
+	// memory operand, or 0 if the instruction has none.
+	ResolvedTarget uint64
+}
+
+// IsBranch returns true if inst is a call, jump or return instruction.
+func (inst *AsmInstruction) IsBranch() bool {
+	return inst.Inst != nil && (inst.IsCall() || inst.IsJump() || inst.IsRet())
 }
 
 type AssemblyFlavour int
@@ -16,6 +25,18 @@ const (
 	IntelFlavour
 )
 
+// ATTFlavour is the AT&T syntax, as printed by the GNU assembler.
+const ATTFlavour = GNUFlavour
+
+// Valid returns true if flavour is one of the known assembly flavours.
+func (flavour AssemblyFlavour) Valid() bool {
+	switch flavour {
+	case GNUFlavour, IntelFlavour:
+		return true
+	}
+	return false
+}
+
 // Disassemble disassembles target memory between startPC and endPC
 // If currentGoroutine is set and thread is stopped at a CALL instruction Disassemble will evaluate the argument of the CALL instruction using the thread's registers
 // Be aware that the Bytes field of each returned instruction is a slice of a larger array of size endPC - startPC
@@ -49,11 +70,16 @@ func (thread *Thread) Disassemble(startPC, endPC uint64, currentGoroutine bool)
 		}
 		file, line, fn := thread.dbp.PCToLine(pc)
 		loc := Location{PC: pc, File: file, Line: line, Fn: fn}
-		inst, err := asmDecode(mem, pc)
+		inst, target, err := asmDecode(mem, pc)
 		if err == nil {
 			atpc := currentGoroutine && (curpc == pc)
 			destloc := thread.resolveCallArg(inst, atpc, regs)
-			r = append(r, AsmInstruction{Loc: loc, DestLoc: destloc, Bytes: mem[:inst.Len], Breakpoint: atbp, AtPC: atpc, Inst: inst})
+			asminst := AsmInstruction{Loc: loc, DestLoc: destloc, Bytes: mem[:inst.Len], Breakpoint: atbp, AtPC: atpc, Inst: inst, ResolvedTarget: target}
+			if destloc == nil && target != 0 && asminst.IsJump() {
+				file, line, fn := thread.dbp.PCToLine(target)
+				asminst.DestLoc = &Location{PC: target, File: file, Line: line, Fn: fn}
+			}
+			r = append(r, asminst)
 
 			pc += uint64(inst.Size())
 			mem = mem[inst.Size():]
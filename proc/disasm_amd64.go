@@ -10,29 +10,38 @@ var maxInstructionLength uint64 = 15
 
 type ArchInst x86asm.Inst
 
-func asmDecode(mem []byte, pc uint64) (*ArchInst, error) {
+func asmDecode(mem []byte, pc uint64) (*ArchInst, uint64, error) {
 	inst, err := x86asm.Decode(mem, 64)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	patchPCRel(pc, &inst)
+	target := patchPCRel(pc, &inst)
 	r := ArchInst(inst)
-	return &r, nil
+	return &r, target, nil
 }
 
 func (inst *ArchInst) Size() int {
 	return inst.Len
 }
 
-// converts PC relative arguments to absolute addresses
-func patchPCRel(pc uint64, inst *x86asm.Inst) {
+// converts PC relative arguments to absolute addresses and returns the
+// absolute address of the last PC relative argument, or 0 if inst has
+// none. RIP relative memory operands are not rewritten but their
+// effective address is returned.
+func patchPCRel(pc uint64, inst *x86asm.Inst) uint64 {
+	var target uint64
 	for i := range inst.Args {
-		rel, isrel := inst.Args[i].(x86asm.Rel)
-		if isrel {
-			inst.Args[i] = x86asm.Imm(int64(pc) + int64(rel) + int64(inst.Len))
+		switch arg := inst.Args[i].(type) {
+		case x86asm.Rel:
+			target = uint64(int64(pc) + int64(arg) + int64(inst.Len))
+			inst.Args[i] = x86asm.Imm(target)
+		case x86asm.Mem:
+			if arg.Base == x86asm.RIP {
+				target = uint64(int64(pc) + arg.Disp + int64(inst.Len))
+			}
 		}
 	}
-	return
+	return target
 }
 
 func (inst *AsmInstruction) Text(flavour AssemblyFlavour) string {
@@ -62,6 +71,20 @@ func (inst *AsmInstruction) IsCall() bool {
 	return inst.Inst.Op == x86asm.CALL || inst.Inst.Op == x86asm.LCALL
 }
 
+// IsJump returns true if inst is an unconditional or conditional jump.
+func (inst *AsmInstruction) IsJump() bool {
+	switch inst.Inst.Op {
+	case x86asm.JMP, x86asm.LJMP, x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ, x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS, x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		return true
+	}
+	return false
+}
+
+// IsRet returns true if inst is a return instruction.
+func (inst *AsmInstruction) IsRet() bool {
+	return inst.Inst.Op == x86asm.RET || inst.Inst.Op == x86asm.LRET
+}
+
 func (thread *Thread) resolveCallArg(inst *ArchInst, currentGoroutine bool, regs Registers) *Location {
 	if inst.Op != x86asm.CALL && inst.Op != x86asm.LCALL {
 		return nil
@@ -8,8 +8,12 @@ import (
 	"go/constant"
 	"go/parser"
 	"go/printer"
+	"go/scanner"
 	"go/token"
+	"math"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/derekparker/delve/dwarf/reader"
 	"golang.org/x/debug/dwarf"
@@ -17,36 +21,93 @@ import (
 
 // EvalExpression returns the value of the given expression.
 func (scope *EvalScope) EvalExpression(expr string, cfg LoadConfig) (*Variable, error) {
-	t, err := parser.ParseExpr(expr)
+	t, err := ParseExpr(expr)
 	if err != nil {
 		return nil, err
 	}
 
+	if !scope.Deadline.IsZero() {
+		dbp := scope.Thread.dbp
+		dbp.evalDeadline = scope.Deadline
+		defer func() { dbp.evalDeadline = time.Time{} }()
+	}
+
 	ev, err := scope.evalAST(t)
 	if err != nil {
 		return nil, err
 	}
 	ev.loadValue(cfg)
+	if scope.Thread.dbp.evalTimedOut() {
+		return nil, EvalTimeoutErr
+	}
 	if ev.Name == "" {
 		ev.Name = expr
 	}
 	return ev, nil
 }
 
+// regIdentPrefix replaces the '$' of register pseudo-variables, like $rax
+// or $sp, so that expressions using them can be parsed as Go expressions.
+const regIdentPrefix = "__delve_reg_"
+
+// ParseExpr parses expr as a Go expression extended with register
+// pseudo-variables. Expressions evaluated by EvalScope, including
+// breakpoint conditions, must be parsed with it.
+func ParseExpr(expr string) (ast.Expr, error) {
+	if !strings.Contains(expr, "$") {
+		return parser.ParseExpr(expr)
+	}
+	src := []byte(expr)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, 0)
+
+	var buf bytes.Buffer
+	last, dollar := 0, -1
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		off := file.Offset(pos)
+		if tok == token.ILLEGAL && lit == "$" && (off == 0 || !isIdentByte(expr[off-1])) {
+			dollar = off
+			continue
+		}
+		if tok == token.IDENT && dollar >= 0 && dollar+1 == off {
+			buf.WriteString(expr[last:dollar])
+			buf.WriteString(regIdentPrefix)
+			last = off
+		}
+		dollar = -1
+	}
+	buf.WriteString(expr[last:])
+	return parser.ParseExpr(buf.String())
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 0x80 || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
 func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 	switch node := t.(type) {
 	case *ast.CallExpr:
+		if fn, ok := decodeFuncs[exprToString(node.Fun)]; ok {
+			return scope.evalDecodeCall(node, fn)
+		}
+		if fn, recv := scope.callTarget(node.Fun); fn != nil {
+			return scope.evalFunctionCall(node, fn, recv)
+		}
 		if len(node.Args) == 1 {
 			v, err := scope.evalTypeCast(node)
 			if err == nil {
 				return v, nil
 			}
 			_, isident := node.Fun.(*ast.Ident)
-			// we don't support function calls at the moment except for a few
-			// builtin functions so just return the type error here if the function
-			// isn't an identifier.
-			// More sophisticated logic will be required when function calls
-			// are implemented.
+			// calls to functions of the target were handled above, so just
+			// return the type error here if the function isn't an identifier
+			// that could be a builtin function.
 			if err != reader.TypeNotFoundErr || !isident {
 				return v, err
 			}
@@ -67,6 +128,10 @@ func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 				return scope.Thread.getGVariable()
 			} else if v, err := scope.packageVarAddr(maybePkg.Name + "." + node.Sel.Name); err == nil {
 				return v, nil
+			} else if maybePkg.Name == "C" {
+				if _, err := scope.extractVarInfo("C"); err != nil {
+					return scope.cGlobal(node.Sel.Name)
+				}
 			}
 		}
 		// if it's not a package variable then it must be a struct member access
@@ -110,12 +175,96 @@ func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 	}
 }
 
+// maxErrorChainLen is the maximum number of errors returned by UnwrapError.
+const maxErrorChainLen = 100
+
+// UnwrapError evaluates expr, which must be an interface value, and
+// returns the chain of errors wrapped by it, starting with expr itself.
+// The chain is followed structurally: the wrapped error of each concrete
+// error is the first interface field named err or Err (as used by
+// fmt.wrapError, os.PathError, net.OpError, etc). If there is no such field
+// and the concrete error has an Unwrap method it is called in the target,
+// the chain ends if the call can not be made.
+func (scope *EvalScope) UnwrapError(expr string, cfg LoadConfig) ([]*Variable, error) {
+	t, err := ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := scope.evalAST(t)
+	if err != nil {
+		return nil, err
+	}
+	if ev.Kind != reflect.Interface {
+		return nil, fmt.Errorf("expression \"%s\" not an interface", expr)
+	}
+	if ev.Name == "" {
+		ev.Name = expr
+	}
+
+	chain := []*Variable{}
+	for ev != nil && len(chain) < maxErrorChainLen {
+		ev.loadInterface(0, false, loadFullValue)
+		if ev.Unreadable != nil {
+			return nil, ev.Unreadable
+		}
+		data := &ev.Children[0]
+		if data.Unreadable != nil || data.Addr == 0 {
+			break
+		}
+		next := wrappedError(data)
+		if next == nil {
+			next = scope.callUnwrap(data)
+		}
+		ev.loadValue(cfg)
+		chain = append(chain, ev)
+		ev = next
+	}
+	return chain, nil
+}
+
+// wrappedError returns the error wrapped by the concrete error data or
+// nil if it doesn't wrap any error.
+func wrappedError(data *Variable) *Variable {
+	for _, name := range []string{"err", "Err"} {
+		v, err := data.structMember(name)
+		if err != nil || v.Unreadable != nil || v.Kind != reflect.Interface {
+			continue
+		}
+		return v
+	}
+	return nil
+}
+
+// callUnwrap calls the Unwrap method of the concrete error data and
+// returns its result, or nil if data has no Unwrap method or it can not be
+// called.
+func (scope *EvalScope) callUnwrap(data *Variable) *Variable {
+	if data.DwarfType == nil {
+		return nil
+	}
+	fn, recv := scope.methodTarget(data, "Unwrap")
+	if fn == nil {
+		return nil
+	}
+	v, err := scope.callFunction(fn, []*Variable{recv})
+	if err != nil || v == nil || v.Kind != reflect.Interface {
+		return nil
+	}
+	return v
+}
+
 func exprToString(t ast.Expr) string {
 	var buf bytes.Buffer
 	printer.Fprint(&buf, token.NewFileSet(), t)
 	return buf.String()
 }
 
+// ExprString returns the source form of t, an expression returned by
+// ParseExpr.
+func ExprString(t ast.Expr) string {
+	return strings.Replace(exprToString(t), regIdentPrefix, "$", -1)
+}
+
 // Eval type cast expressions
 func (scope *EvalScope) evalTypeCast(node *ast.CallExpr) (*Variable, error) {
 	argv, err := scope.evalAST(node.Args[0])
@@ -151,20 +300,54 @@ func (scope *EvalScope) evalTypeCast(node *ast.CallExpr) (*Variable, error) {
 
 	switch ttyp := typ.(type) {
 	case *dwarf.PtrType:
+		// integer variables must be able to hold an address, constants are
+		// always accepted
+		if argv.RealType != nil && argv.RealType.Size() < int64(scope.Thread.dbp.arch.PtrSize()) {
+			return nil, fmt.Errorf("%s: %s is smaller than a pointer", converr, argv.TypeString())
+		}
+
+		var n uint64
 		switch argv.Kind {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			// ok
+			x, _ := constant.Int64Val(argv.Value)
+			if x < 0 {
+				return nil, fmt.Errorf("%s: negative address", converr)
+			}
+			n = uint64(x)
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			// ok
+			n, _ = constant.Uint64Val(argv.Value)
+		case reflect.Ptr, reflect.UnsafePointer:
+			n = uint64(argv.Children[0].Addr)
 		default:
 			return nil, converr
 		}
 
-		n, _ := constant.Int64Val(argv.Value)
-
 		v.Children = []Variable{*(scope.newVariable("", uintptr(n), ttyp.Type))}
 		return v, nil
 
+	case *dwarf.SliceType:
+		// reinterprets the bytes of a string, or the array pointed to by a
+		// pointer, as a slice sharing their memory
+		var base uintptr
+		var n int64
+		switch {
+		case argv.Kind == reflect.String && argv.Base != 0 && ttyp.ElemType.Size() == 1:
+			base, n = argv.Base, argv.Len
+		case argv.Kind == reflect.Ptr && len(argv.Children) == 1:
+			atyp, isarr := resolveTypedef(argv.Children[0].RealType).(*dwarf.ArrayType)
+			if !isarr || atyp.Type.String() != ttyp.ElemType.String() {
+				return nil, converr
+			}
+			base, n = argv.Children[0].Addr, atyp.Count
+		default:
+			return nil, converr
+		}
+		v.loaded = false
+		v.Base, v.Len, v.Cap = base, n, n
+		v.fieldType = ttyp.ElemType
+		v.stride = ttyp.ElemType.Size()
+		return v, nil
+
 	case *dwarf.UintType:
 		switch argv.Kind {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -428,6 +611,103 @@ func realBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 	return newConstant(constant.Real(arg.Value), arg.mem), nil
 }
 
+// decodeFunc describes a function of encoding/binary or math that can be
+// used in expressions to reinterpret raw bytes.
+type decodeFunc struct {
+	order binary.ByteOrder // byte order of the argument, nil for math functions
+	size  int64            // size of the result
+	float bool             // the result is a floating point number
+}
+
+var decodeFuncs = map[string]decodeFunc{
+	"binary.BigEndian.Uint16":    {binary.BigEndian, 2, false},
+	"binary.BigEndian.Uint32":    {binary.BigEndian, 4, false},
+	"binary.BigEndian.Uint64":    {binary.BigEndian, 8, false},
+	"binary.LittleEndian.Uint16": {binary.LittleEndian, 2, false},
+	"binary.LittleEndian.Uint32": {binary.LittleEndian, 4, false},
+	"binary.LittleEndian.Uint64": {binary.LittleEndian, 8, false},
+	"math.Float32frombits":       {nil, 4, true},
+	"math.Float64frombits":       {nil, 8, true},
+}
+
+// evalDecodeCall evaluates a call to one of decodeFuncs. The functions of
+// encoding/binary read the first bytes of a byte array or slice, the
+// functions of math reinterpret an integer as a floating point number.
+func (scope *EvalScope) evalDecodeCall(node *ast.CallExpr, fn decodeFunc) (*Variable, error) {
+	fnname := exprToString(node.Fun)
+	if len(node.Args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to %s: %d", fnname, len(node.Args))
+	}
+	arg, err := scope.evalAST(node.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	invalidArgErr := fmt.Errorf("invalid argument %s (type %s) to %s", exprToString(node.Args[0]), arg.TypeString(), fnname)
+
+	var n uint64
+	if fn.order != nil {
+		var base uintptr
+		switch arg.Kind {
+		case reflect.Array:
+			base = arg.Addr
+		case reflect.Slice:
+			base = arg.Base
+		default:
+			return nil, invalidArgErr
+		}
+		if elem, ok := resolveTypedef(arg.fieldType).(*dwarf.UintType); !ok || elem.Size() != 1 {
+			return nil, invalidArgErr
+		}
+		if arg.Len < fn.size {
+			return nil, fmt.Errorf("%s is too short for %s: %d bytes", exprToString(node.Args[0]), fnname, arg.Len)
+		}
+		mem, err := arg.mem.readMemory(base, int(fn.size))
+		if err != nil {
+			return nil, err
+		}
+		switch fn.size {
+		case 2:
+			n = uint64(fn.order.Uint16(mem))
+		case 4:
+			n = uint64(fn.order.Uint32(mem))
+		case 8:
+			n = fn.order.Uint64(mem)
+		}
+	} else {
+		arg.loadValue(loadSingleValue)
+		if arg.Unreadable != nil {
+			return nil, arg.Unreadable
+		}
+		if arg.Value == nil || arg.Value.Kind() != constant.Int {
+			return nil, invalidArgErr
+		}
+		if x, exact := constant.Uint64Val(arg.Value); exact {
+			n = x
+		} else {
+			x, _ := constant.Int64Val(arg.Value)
+			n = uint64(x)
+		}
+	}
+
+	var typ dwarf.Type
+	var val constant.Value
+	if fn.float {
+		typ = &dwarf.FloatType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: fn.size, Name: fmt.Sprintf("float%d", fn.size*8)}, BitSize: fn.size * 8}}
+		if fn.size == 4 {
+			val = constant.MakeFloat64(float64(math.Float32frombits(uint32(n))))
+		} else {
+			val = constant.MakeFloat64(math.Float64frombits(n))
+		}
+	} else {
+		typ = &dwarf.UintType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: fn.size, Name: fmt.Sprintf("uint%d", fn.size*8)}, BitSize: fn.size * 8}}
+		val = constant.MakeUint64(n)
+	}
+	v := newVariable("", 0, typ, scope.Thread.dbp, scope.Thread)
+	v.Value = val
+	v.loaded = true
+	return v, nil
+}
+
 // Evaluates identifier expressions
 func (scope *EvalScope) evalIdent(node *ast.Ident) (*Variable, error) {
 	switch node.Name {
@@ -437,6 +717,15 @@ func (scope *EvalScope) evalIdent(node *ast.Ident) (*Variable, error) {
 		return nilVariable, nil
 	}
 
+	if strings.HasPrefix(node.Name, regIdentPrefix) {
+		name := node.Name[len(regIdentPrefix):]
+		switch name {
+		case "ndefers", "panicking":
+			return scope.goroutinePseudoVariable(name)
+		}
+		return scope.registerVariable(name)
+	}
+
 	// try to interpret this as a local variable
 	v, err := scope.extractVarInfo(node.Name)
 	if err == nil {
@@ -461,6 +750,142 @@ func (scope *EvalScope) evalIdent(node *ast.Ident) (*Variable, error) {
 	return nil, origErr
 }
 
+// registerVariable returns the value of register name of the scope's
+// thread, pc, sp and bp can be used as aliases of the instruction pointer,
+// stack pointer and frame pointer registers. The status flags can be read
+// as booleans using their name, for example $zf.
+func (scope *EvalScope) registerVariable(name string) (*Variable, error) {
+	regs, err := scope.Thread.Registers()
+	if err != nil {
+		return nil, err
+	}
+	for _, flag := range CPUFlags {
+		if !strings.EqualFold(flag.Name, name) {
+			continue
+		}
+		for _, reg := range regs.Slice() {
+			if IsFlagsRegister(reg.Name) {
+				v := newConstant(constant.MakeBool(reg.Value&(1<<flag.Bit) != 0), scope.Thread)
+				v.Name = "$" + name
+				return v, nil
+			}
+		}
+	}
+	regname := strings.ToLower(name)
+	switch regname {
+	case "pc":
+		regname = "rip"
+	case "sp":
+		regname = "rsp"
+	case "bp":
+		regname = "rbp"
+	}
+	for _, reg := range regs.Slice() {
+		if strings.ToLower(reg.Name) == regname {
+			v := newConstant(constant.MakeUint64(reg.Value), scope.Thread)
+			v.Name = "$" + name
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown register $%s", name)
+}
+
+// maxDefers is the maximum length of the chain of deferred calls followed
+// by $ndefers.
+const maxDefers = 1 << 20
+
+// goroutinePseudoVariable returns the value of $ndefers, the number of
+// deferred calls pending on the goroutine of the scope, or of $panicking,
+// true if the goroutine is panicking.
+// Open-coded defers, used since go1.14, are not linked to the goroutine
+// and are not counted by $ndefers.
+func (scope *EvalScope) goroutinePseudoVariable(name string) (*Variable, error) {
+	g := scope.g
+	if g == nil {
+		var err error
+		if g, err = scope.Thread.GetG(); err != nil {
+			return nil, err
+		}
+	}
+	gvar, err := scope.Thread.newGVariable(uintptr(g.Addr), false)
+	if err != nil {
+		return nil, err
+	}
+	dbp := scope.Thread.dbp
+	ptrSize := int64(dbp.arch.PtrSize())
+
+	var v *Variable
+	switch name {
+	case "panicking":
+		p, err := gvar.structMember("_panic")
+		if err != nil {
+			return nil, err
+		}
+		addr, err := readUintRaw(p.mem, p.Addr, ptrSize)
+		if err != nil {
+			return nil, err
+		}
+		v = newConstant(constant.MakeBool(addr != 0), scope.Thread)
+	case "ndefers":
+		d, err := gvar.structMember("_defer")
+		if err != nil {
+			return nil, err
+		}
+		addr, err := readUintRaw(d.mem, d.Addr, ptrSize)
+		if err != nil {
+			return nil, err
+		}
+		typ, err := dbp.findType("runtime._defer")
+		if err != nil {
+			return nil, err
+		}
+		n := int64(0)
+		for ; addr != 0 && n < maxDefers; n++ {
+			link, err := newVariable("", uintptr(addr), typ, dbp, scope.Thread).structMember("link")
+			if err != nil {
+				return nil, err
+			}
+			if addr, err = readUintRaw(link.mem, link.Addr, ptrSize); err != nil {
+				return nil, err
+			}
+		}
+		v = newConstant(constant.MakeInt64(n), scope.Thread)
+	}
+	v.Name = "$" + name
+	return v, nil
+}
+
+// dataSymbol is the address and size of a symbol of the executable.
+type dataSymbol struct {
+	addr, size uint64
+}
+
+// cGlobal returns the C global variable called name. The variable is
+// searched first in the debug information of C compile units and then in
+// the symbol table of the executable, in the latter case the type of the
+// variable is unknown and it is returned as an array of bytes. Only the
+// symbols of cgo executables are considered and only expressions of the
+// form C.name are resolved this way.
+func (scope *EvalScope) cGlobal(name string) (*Variable, error) {
+	if v, err := scope.packageVarAddr(name); err == nil {
+		return v, nil
+	}
+	sym, ok := scope.Thread.dbp.dataSymbols[name]
+	if !ok || sym.size == 0 {
+		return nil, fmt.Errorf("could not find symbol value for C.%s", name)
+	}
+	u8, err := scope.Thread.dbp.findType("uint8")
+	if err != nil {
+		return nil, err
+	}
+	typ := &dwarf.ArrayType{
+		CommonType: dwarf.CommonType{ByteSize: int64(sym.size), Name: fmt.Sprintf("[%d]uint8", sym.size), ReflectKind: reflect.Array},
+		Type:       u8,
+		Count:      int64(sym.size),
+	}
+	return scope.newVariable(name, uintptr(sym.addr), typ), nil
+}
+
 // Evaluates expressions <subexpr>.<field name> where subexpr is not a package name
 func (scope *EvalScope) evalStructSelector(node *ast.SelectorExpr) (*Variable, error) {
 	xv, err := scope.evalAST(node.X)
@@ -483,20 +908,28 @@ func (scope *EvalScope) evalTypeAssert(node *ast.TypeAssertExpr) (*Variable, err
 	if xv.Unreadable != nil {
 		return nil, xv.Unreadable
 	}
-	if xv.Children[0].Unreadable != nil {
-		return nil, xv.Children[0].Unreadable
+	data := &xv.Children[0]
+	if data.Unreadable != nil {
+		return nil, data.Unreadable
 	}
-	if xv.Children[0].Addr == 0 {
+	if data.Addr == 0 {
 		return nil, fmt.Errorf("interface conversion: %s is nil, not %s", xv.DwarfType.String(), exprToString(node.Type))
 	}
+	if data.ifaceIndirect {
+		// the interface holds a pointer to a value of the concrete type
+		data = data.maybeDereference()
+		if data.Unreadable != nil {
+			return nil, data.Unreadable
+		}
+	}
 	typ, err := scope.Thread.dbp.findTypeExpr(node.Type)
 	if err != nil {
 		return nil, err
 	}
-	if xv.Children[0].DwarfType.Common().Name != typ.Common().Name {
-		return nil, fmt.Errorf("interface conversion: %s is %s, not %s", xv.DwarfType.Common().Name, xv.Children[0].TypeString(), typ.Common().Name)
+	if data.DwarfType.Common().Name != typ.Common().Name {
+		return nil, fmt.Errorf("interface conversion: %s is %s, not %s", xv.DwarfType.Common().Name, data.TypeString(), typ.Common().Name)
 	}
-	return &xv.Children[0], nil
+	return data, nil
 }
 
 // Evaluates expressions <subexpr>[<subexpr>] (subscript access to arrays, slices and maps)
@@ -1083,7 +1516,7 @@ func (v *Variable) mapAccess(idx *Variable) (*Variable, error) {
 }
 
 func (v *Variable) reslice(low int64, high int64) (*Variable, error) {
-	if low < 0 || low >= v.Len || high < 0 || high > v.Len {
+	if low < 0 || low > v.Len || high < 0 || high > v.Len {
 		return nil, fmt.Errorf("index out of bounds")
 	}
 
@@ -0,0 +1,677 @@
+package proc
+
+import (
+	"debug/gosym"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/derekparker/delve/dwarf/op"
+	"golang.org/x/debug/dwarf"
+	"rsc.io/x86/x86asm"
+)
+
+// Function calls are injected into the target using the protocol of
+// runtime.debugCallV1, described in runtime/asm_amd64.s:
+//  1. the current PC is pushed on the stack of the goroutine and the size
+//     of the argument frame written at SP-16,
+//  2. the registers are saved and the PC is set to runtime.debugCallV1,
+//  3. the runtime checks that the goroutine is at a safe point, allocates
+//     the argument frame and stops with an INT3, the value of RAX tells the
+//     debugger what it is expected to do next (see the debugCall
+//     constants).
+//
+// This protocol is only implemented by the runtime of Go 1.11 to 1.16:
+// earlier versions have no runtime.debugCallV1 and later ones replaced it
+// with runtime.debugCallV2, which uses the register based calling
+// convention. See FunctionCallsSupported.
+const (
+	debugCallFunctionName = "runtime.debugCallV1"
+
+	// debugCallV2FunctionName is the entry point of the protocol of Go 1.17
+	// and later, which is not supported.
+	debugCallV2FunctionName = "runtime.debugCallV2"
+
+	// debugCallMinStack is the amount of free stack that the goroutine must
+	// have for runtime.debugCallV1 to run.
+	debugCallMinStack = 256
+
+	debugCallAXCall     = 0  // the argument frame is at SP, the function can be called
+	debugCallAXReturned = 1  // the function returned, its results are at SP
+	debugCallAXPanicked = 2  // the function panicked, the panic value is at SP
+	debugCallAXRejected = 8  // the call can not be injected, the reason is a string at SP
+	debugCallAXRestore  = 16 // the registers must be restored
+)
+
+var errFunctionCallsUnsupported = errors.New("function calls are not supported on this platform")
+
+// FunctionCallsUnsupportedError is returned when the runtime of the target
+// can not execute injected function calls.
+type FunctionCallsUnsupportedError struct {
+	Reason string
+}
+
+func (err *FunctionCallsUnsupportedError) Error() string {
+	return "function calls are not supported by the runtime of the target: " + err.Reason
+}
+
+// FunctionCallsSupported returns a *FunctionCallsUnsupportedError if the
+// runtime of the target does not implement the call injection protocol of
+// runtime.debugCallV1, that is if it wasn't built with Go 1.11 to 1.16.
+// Function calls are only supported on linux/amd64.
+func (dbp *Process) FunctionCallsSupported() error {
+	dbp.fncallSupportOnce.Do(func() {
+		dbp.fncallSupportErr = dbp.checkFunctionCallsSupport()
+	})
+	return dbp.fncallSupportErr
+}
+
+func (dbp *Process) checkFunctionCallsSupport() error {
+	if dbp.goSymTable.LookupFunc(debugCallFunctionName) == nil {
+		return &FunctionCallsUnsupportedError{Reason: debugCallFunctionName + " not found, Go 1.11 to 1.16 is required"}
+	}
+	if dbp.goSymTable.LookupFunc(debugCallV2FunctionName) != nil {
+		return &FunctionCallsUnsupportedError{Reason: "the register based protocol of " + debugCallV2FunctionName + " is not supported, Go 1.11 to 1.16 is required"}
+	}
+	ver, _, err := dbp.getGoInformation()
+	if err != nil {
+		return &FunctionCallsUnsupportedError{Reason: strings.TrimSpace(err.Error())}
+	}
+	if !ver.IsDevel() && (!ver.AfterOrEqual(GoVersion{1, 11, -1, 0, 0}) || ver.AfterOrEqual(GoVersion{1, 17, -1, 0, 0})) {
+		return &FunctionCallsUnsupportedError{Reason: fmt.Sprintf("Go %d.%d is not supported, Go 1.11 to 1.16 is required", ver.Major, ver.Minor)}
+	}
+	return nil
+}
+
+// callParam is an argument or return value of a called function.
+type callParam struct {
+	name string
+	typ  dwarf.Type
+	off  int64 // offset from the start of the argument frame
+}
+
+// functionCall is a function call being injected into a goroutine.
+type functionCall struct {
+	fn            *gosym.Func
+	args, results []callParam
+	frameSize     int64
+
+	argv []*Variable // values of args
+	retv *Variable   // value of the result, if any
+
+	panicked error
+	rejected error
+}
+
+// callTarget returns the function called by fun and, if it's a method,
+// the variable used as its receiver. The returned function is nil if fun
+// does not refer to a function of the target.
+func (scope *EvalScope) callTarget(fun ast.Expr) (*gosym.Func, *Variable) {
+	dbp := scope.Thread.dbp
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		if _, err := scope.extractVarInfo(fun.Name); err == nil {
+			// a local variable, calling function values is not supported
+			return nil, nil
+		}
+		cur := dbp.goSymTable.PCToFunc(scope.PC)
+		if cur == nil {
+			return nil, nil
+		}
+		return dbp.goSymTable.LookupFunc(packagePath(cur.Name) + "." + fun.Name), nil
+
+	case *ast.SelectorExpr:
+		if pkg, ok := fun.X.(*ast.Ident); ok {
+			if fn := dbp.goSymTable.LookupFunc(pkg.Name + "." + fun.Sel.Name); fn != nil {
+				return fn, nil
+			}
+			if err := dbp.loadPackageMap(); err == nil {
+				if path, ok := dbp.packageMap[pkg.Name]; ok {
+					if fn := dbp.goSymTable.LookupFunc(path + "." + fun.Sel.Name); fn != nil {
+						return fn, nil
+					}
+				}
+			}
+		}
+		recv, err := scope.evalAST(fun.X)
+		if err != nil || recv.DwarfType == nil {
+			return nil, nil
+		}
+		return scope.methodTarget(recv, fun.Sel.Name)
+	}
+	return nil, nil
+}
+
+// methodTarget returns the method called name of the type of recv, and the
+// receiver to pass to it.
+func (scope *EvalScope) methodTarget(recv *Variable, name string) (*gosym.Func, *Variable) {
+	dbp := scope.Thread.dbp
+	typename := recv.DwarfType.Common().Name
+	isptr := strings.HasPrefix(typename, "*")
+	typename = strings.TrimPrefix(typename, "*")
+	dot := strings.LastIndex(typename, ".")
+	if dot < 0 {
+		return nil, nil
+	}
+	pkg, typ := typename[:dot], typename[dot+1:]
+	valueMethod := dbp.goSymTable.LookupFunc(pkg + "." + typ + "." + name)
+	ptrMethod := dbp.goSymTable.LookupFunc(pkg + ".(*" + typ + ")." + name)
+
+	switch {
+	case isptr && ptrMethod != nil:
+		return ptrMethod, recv
+	case isptr && valueMethod != nil:
+		recv.loadValue(loadSingleValue)
+		if len(recv.Children) == 0 {
+			return nil, nil
+		}
+		return valueMethod, &recv.Children[0]
+	case !isptr && valueMethod != nil:
+		return valueMethod, recv
+	case !isptr && ptrMethod != nil && recv.Addr != 0:
+		ptrtyp := &dwarf.PtrType{CommonType: dwarf.CommonType{ByteSize: int64(dbp.arch.PtrSize()), Name: "*" + typename}, Type: recv.DwarfType}
+		rv := scope.newVariable("", 0, ptrtyp)
+		recv.OnlyAddr = true
+		rv.Children = []Variable{*recv}
+		rv.loaded = true
+		return ptrMethod, rv
+	}
+	return nil, nil
+}
+
+// packagePath returns the path of the package of the function called name.
+func packagePath(name string) string {
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+	return name
+}
+
+// evalFunctionCall calls fn in the goroutine of scope, with the arguments
+// of node preceded by recv, and returns its result.
+// Only functions with at most one result can be called, and arguments
+// that would have to be allocated in the target, like string constants,
+// are rejected.
+// Breakpoints hit by the called function are ignored, the threads of other
+// goroutines hitting a breakpoint while it runs stay stopped on it.
+func (scope *EvalScope) evalFunctionCall(node *ast.CallExpr, fn *gosym.Func, recv *Variable) (*Variable, error) {
+	var argv []*Variable
+	if recv != nil {
+		argv = append(argv, recv)
+	}
+	for _, arg := range node.Args {
+		av, err := scope.evalAST(arg)
+		if err != nil {
+			return nil, err
+		}
+		argv = append(argv, av)
+	}
+	retv, err := scope.callFunction(fn, argv)
+	if err != nil {
+		return nil, err
+	}
+	if retv == nil {
+		return nil, fmt.Errorf("%s returned no value", fn.Name)
+	}
+	return retv, nil
+}
+
+// callFunction calls fn in the goroutine of scope with the arguments argv
+// and returns its result, nil if fn has no result. See evalFunctionCall.
+func (scope *EvalScope) callFunction(fn *gosym.Func, argv []*Variable) (*Variable, error) {
+	dbp := scope.Thread.dbp
+	if dbp.exited {
+		return nil, &ProcessExitedError{}
+	}
+	if dbp.core != nil {
+		return nil, CoreReadOnlyErr
+	}
+	if dbp.fncallForbidden {
+		return nil, fmt.Errorf("can not call %s: function calls are not allowed here", fn.Name)
+	}
+	if err := dbp.FunctionCallsSupported(); err != nil {
+		return nil, err
+	}
+	debugCall := dbp.goSymTable.LookupFunc(debugCallFunctionName)
+
+	call := &functionCall{fn: fn, argv: argv}
+	if err := dbp.callParams(call); err != nil {
+		return nil, fmt.Errorf("can not call %s: %v", fn.Name, err)
+	}
+	if len(call.results) > 1 {
+		return nil, fmt.Errorf("can not call %s: functions with more than one result are not supported", fn.Name)
+	}
+
+	if len(call.argv) != len(call.args) {
+		return nil, fmt.Errorf("wrong number of arguments to %s: got %d, expected %d", fn.Name, len(call.argv), len(call.args))
+	}
+	for i, param := range call.args {
+		if err := scope.checkCallArg(call, param, call.argv[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	g := scope.g
+	if g == nil {
+		var err error
+		if g, err = scope.Thread.GetG(); err != nil {
+			return nil, err
+		}
+	}
+	if g == nil || g.thread == nil {
+		return nil, fmt.Errorf("can not call %s: the goroutine is not running on a thread", fn.Name)
+	}
+	if g.Status != Grunning {
+		return nil, fmt.Errorf("can not call %s: goroutine %d is not running", fn.Name, g.ID)
+	}
+
+	if err := dbp.injectCall(g, call, debugCall); err != nil {
+		return nil, err
+	}
+	if call.rejected != nil {
+		return nil, fmt.Errorf("can not call %s: %v", fn.Name, call.rejected)
+	}
+	if call.panicked != nil {
+		return nil, call.panicked
+	}
+	scope.Thread = g.thread
+	return call.retv, nil
+}
+
+// checkCallArg returns an error if av can not be passed as the argument
+// param of call.
+func (scope *EvalScope) checkCallArg(call *functionCall, param callParam, av *Variable) error {
+	pv := scope.newVariable(param.name, 0, param.typ)
+	av.loadValue(loadSingleValue)
+	if av.Unreadable != nil {
+		return fmt.Errorf("argument %s of %s is unreadable: %v", param.name, call.fn.Name, av.Unreadable)
+	}
+	if err := av.isType(pv.RealType, pv.Kind); err != nil {
+		return fmt.Errorf("can not use argument %s of %s: %v", param.name, call.fn.Name, err)
+	}
+	switch pv.Kind {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.Ptr:
+		return nil
+	}
+	if av != nilVariable && av.Addr == 0 {
+		return fmt.Errorf("can not pass argument %s of %s: the value would have to be allocated in the target", param.name, call.fn.Name)
+	}
+	return nil
+}
+
+// callParams reads the arguments and results of call.fn, and the size of
+// its argument frame, from the debug info.
+func (dbp *Process) callParams(call *functionCall) error {
+	rdr := dbp.DwarfReader()
+	if _, err := rdr.SeekToFunction(call.fn.Entry); err != nil {
+		return err
+	}
+	for entry, err := rdr.NextScopeVariable(); entry != nil; entry, err = rdr.NextScopeVariable() {
+		if err != nil {
+			return err
+		}
+		if entry.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+		name, _ := entry.Val(dwarf.AttrName).(string)
+		typoff, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+		if !ok {
+			return fmt.Errorf("could not read type of %s", name)
+		}
+		typ, err := dbp.dwarf.Type(typoff)
+		if err != nil {
+			return err
+		}
+		instructions, ok := entry.Val(dwarf.AttrLocation).([]byte)
+		if !ok {
+			return fmt.Errorf("could not find the location of %s", name)
+		}
+		// the argument frame starts at the CFA of the called function
+		off, err := op.ExecuteStackProgram(0, instructions)
+		if err != nil {
+			return err
+		}
+		param := callParam{name: name, typ: typ, off: off}
+		if end := off + typ.Size(); end > call.frameSize {
+			call.frameSize = end
+		}
+		if isResult, _ := entry.Val(dwarf.AttrVarParam).(bool); isResult {
+			call.results = append(call.results, param)
+		} else {
+			call.args = append(call.args, param)
+		}
+	}
+	ptrSize := int64(dbp.arch.PtrSize())
+	call.frameSize = (call.frameSize + ptrSize - 1) / ptrSize * ptrSize
+	return nil
+}
+
+// callInjection is the state of a function call being injected by
+// injectCall.
+type callInjection struct {
+	call      *functionCall
+	gid       int
+	debugCall *gosym.Func
+	state     *callState
+
+	savedBp      *Breakpoint
+	savedCondMet bool
+	savedCondErr error
+	wasCurrent   bool
+
+	started bool // the called function has been entered
+	aborted bool // the function must not be called, or its result is discarded
+}
+
+// errCallInterrupted is returned by injectCall when a call is interrupted
+// by RequestManualStop or by the deadline of the evaluation.
+type errCallInterrupted struct {
+	fn      string
+	running bool
+}
+
+func (err errCallInterrupted) Error() string {
+	if err.running {
+		return fmt.Sprintf("call to %s interrupted, the function is still running and will complete when the process is resumed", err.fn)
+	}
+	return fmt.Sprintf("call to %s interrupted", err.fn)
+}
+
+// injectCall runs call in goroutine g, using debugCall as entry point.
+//
+// The call can be interrupted with RequestManualStop or by the deadline of
+// the evaluation. If the function was not entered yet the call is
+// abandoned through the debug call protocol, otherwise the function is
+// left running and the protocol is completed by the next Continue, see
+// pendingCall.
+// Threads reaching user breakpoints while the call runs are kept stopped
+// on them, so that the hits are reported once the call completes.
+func (dbp *Process) injectCall(g *G, call *functionCall, debugCall *gosym.Func) error {
+	if dbp.pendingCall != nil {
+		return fmt.Errorf("can not call %s: the interrupted call to %s has not completed yet", call.fn.Name, dbp.pendingCall.call.fn.Name)
+	}
+	thread := g.thread
+	regs, err := thread.Registers()
+	if err != nil {
+		return err
+	}
+	sp := regs.SP()
+	if g.StackLo != 0 && sp-g.StackLo < debugCallMinStack {
+		return fmt.Errorf("can not call %s: not enough stack space", call.fn.Name)
+	}
+
+	state, err := thread.saveCallState()
+	if err != nil {
+		return err
+	}
+	ci := &callInjection{call: call, gid: g.ID, debugCall: debugCall, state: state}
+
+	// push the return address and write the size of the argument frame
+	sp -= uint64(dbp.arch.PtrSize())
+	if err := thread.writeUint64(sp, regs.PC()); err != nil {
+		return err
+	}
+	if err := thread.writeUint64(sp-16, uint64(call.frameSize)); err != nil {
+		return err
+	}
+	if err := thread.setSP(sp); err != nil {
+		ci.restore(thread)
+		return err
+	}
+	if err := thread.SetPC(debugCall.Entry); err != nil {
+		ci.restore(thread)
+		return err
+	}
+
+	ci.savedBp, ci.savedCondMet, ci.savedCondErr = thread.CurrentBreakpoint, thread.BreakpointConditionMet, thread.BreakpointConditionError
+	thread.clearBreakpointState()
+	ci.wasCurrent = dbp.CurrentThread == thread
+
+	oldForbidden := dbp.fncallForbidden
+	dbp.fncallForbidden = true
+	defer func() { dbp.fncallForbidden = oldForbidden }()
+
+	dbp.callInterrupted = false
+	if !dbp.evalDeadline.IsZero() {
+		// wakes up trapWait if the call is still running at the deadline
+		timer := time.AfterFunc(dbp.evalDeadline.Sub(time.Now()), func() { dbp.RequestManualStop() })
+		defer timer.Stop()
+	}
+
+	// threads stopped at a user breakpoint hit while the call was running
+	held := make(map[int]bool)
+
+	for {
+		if err := dbp.resumeExcept(held); err != nil {
+			return err
+		}
+		dbp.allGCache = nil
+		for _, th := range dbp.Threads {
+			if !held[th.ID] {
+				th.clearBreakpointState()
+			}
+		}
+		trapthread, err := dbp.trapWait(-1)
+		if err != nil {
+			return err
+		}
+		if err := dbp.Halt(); err != nil {
+			return dbp.exitGuard(err)
+		}
+		if err := dbp.setCurrentBreakpoints(trapthread); err != nil {
+			return err
+		}
+		for _, th := range dbp.Threads {
+			if !th.onTriggeredBreakpoint() || th.CurrentBreakpoint.Internal() {
+				continue
+			}
+			// breakpoints hit by the called function are ignored
+			if g, err := th.GetG(); err == nil && g != nil && g.ID == ci.gid {
+				continue
+			}
+			held[th.ID] = true
+		}
+
+		if dbp.callInterrupted || dbp.evalTimedOut() {
+			dbp.callInterrupted = false
+			if ci.started && !ci.aborted {
+				// the registers of the thread will be restored when the
+				// function returns, but not its breakpoint state
+				ci.aborted = true
+				ci.savedBp, ci.savedCondMet, ci.savedCondErr = nil, false, nil
+				ci.wasCurrent = false
+				dbp.pendingCall = ci
+				return errCallInterrupted{fn: call.fn.Name, running: true}
+			}
+			ci.aborted = true
+		}
+
+		th := dbp.debugCallThread(ci.gid, debugCall)
+		if th == nil {
+			// some other thread stopped, or the goroutine is still running
+			continue
+		}
+		done, err := ci.step(th)
+		if err != nil {
+			return err
+		}
+		if done {
+			if ci.aborted {
+				return errCallInterrupted{fn: call.fn.Name}
+			}
+			g.thread = th
+			return nil
+		}
+	}
+}
+
+// restore restores the registers saved before the call was injected.
+func (ci *callInjection) restore(th *Thread) error {
+	if err := th.restoreCallState(ci.state); err != nil {
+		return fmt.Errorf("could not restore registers after calling %s: %v", ci.call.fn.Name, err)
+	}
+	return nil
+}
+
+// step executes the next step of the debug call protocol for th, stopped
+// on one of its INT3 instructions, and returns true once the registers
+// of the goroutine have been restored.
+func (ci *callInjection) step(th *Thread) (bool, error) {
+	dbp := th.dbp
+	call := ci.call
+	regs, err := th.Registers()
+	if err != nil {
+		return false, err
+	}
+	status, err := regs.Get(int(x86asm.RAX))
+	if err != nil {
+		return false, err
+	}
+
+	switch status {
+	case debugCallAXCall:
+		if ci.aborted {
+			// not calling the function makes the runtime go on to report
+			// its return and restore the registers
+			return false, nil
+		}
+		if err := call.writeArgs(th, regs.SP()); err != nil {
+			ci.restore(th)
+			return false, err
+		}
+		// call the function, it will return to the INT3 reporting its results
+		sp := regs.SP() - uint64(dbp.arch.PtrSize())
+		if err := th.writeUint64(sp, regs.PC()); err != nil {
+			return false, err
+		}
+		if err := th.setSP(sp); err != nil {
+			return false, err
+		}
+		if err := th.SetPC(call.fn.Entry); err != nil {
+			return false, err
+		}
+		ci.started = true
+
+	case debugCallAXReturned:
+		if len(call.results) > 0 && !ci.aborted {
+			param := call.results[0]
+			addr := uintptr(regs.SP()) + uintptr(param.off)
+			mem := cacheMemory(th, addr, int(param.typ.Size()))
+			call.retv = newVariable("", addr, param.typ, dbp, mem)
+			call.retv.loadValue(loadFullValue)
+		}
+
+	case debugCallAXPanicked:
+		if ci.aborted {
+			break
+		}
+		call.panicked = fmt.Errorf("%s panicked", call.fn.Name)
+		if typ, err := dbp.findType("interface {}"); err == nil {
+			pv := th.newVariable("", uintptr(regs.SP()), typ)
+			pv.loadValue(loadFullValue)
+			if len(pv.Children) > 0 {
+				v := &pv.Children[0]
+				if v.Value != nil {
+					call.panicked = fmt.Errorf("%s panicked: %s", call.fn.Name, v.Value.String())
+				} else {
+					call.panicked = fmt.Errorf("%s panicked: %s", call.fn.Name, v.TypeString())
+				}
+			}
+		}
+
+	case debugCallAXRejected:
+		strAddr, strLen, err := readStringInfo(th, dbp.arch, uintptr(regs.SP()))
+		if err == nil {
+			var reason string
+			reason, err = readStringValue(th, strAddr, strLen, loadFullValue)
+			call.rejected = errors.New(reason)
+		}
+		if err != nil {
+			call.rejected = fmt.Errorf("unknown reason (%v)", err)
+		}
+
+	case debugCallAXRestore:
+		if err := ci.restore(th); err != nil {
+			return false, err
+		}
+		th.CurrentBreakpoint, th.BreakpointConditionMet, th.BreakpointConditionError = ci.savedBp, ci.savedCondMet, ci.savedCondErr
+		dbp.allGCache = nil
+		if ci.wasCurrent {
+			dbp.CurrentThread = th
+		}
+		return true, nil
+
+	default:
+		ci.restore(th)
+		return false, fmt.Errorf("unexpected status %d of %s while calling %s", status, debugCallFunctionName, call.fn.Name)
+	}
+	return false, nil
+}
+
+// stepPendingCall advances the protocol of the call interrupted while its
+// function was running, if its goroutine is stopped on one of the INT3
+// of the protocol. Returns true if the stop was caused by the protocol.
+func (dbp *Process) stepPendingCall() (bool, error) {
+	ci := dbp.pendingCall
+	th := dbp.debugCallThread(ci.gid, ci.debugCall)
+	if th == nil {
+		return false, nil
+	}
+	done, err := ci.step(th)
+	if done || err != nil {
+		dbp.pendingCall = nil
+	}
+	return true, err
+}
+
+// debugCallThread returns the thread running goroutine gid if it is
+// stopped on one of the INT3 instructions of the debug call protocol.
+func (dbp *Process) debugCallThread(gid int, debugCall *gosym.Func) *Thread {
+	for _, th := range dbp.Threads {
+		if th.CurrentBreakpoint != nil {
+			continue
+		}
+		g, err := th.GetG()
+		if err != nil || g == nil || g.ID != gid {
+			continue
+		}
+		pc, err := th.PC()
+		if err != nil {
+			continue
+		}
+		fn := dbp.goSymTable.PCToFunc(pc - 1)
+		if fn == nil || (fn != debugCall && !strings.HasPrefix(fn.Name, "runtime.debugCall")) {
+			continue
+		}
+		if instr, err := th.readMemory(uintptr(pc-1), 1); err != nil || instr[0] != 0xcc {
+			continue
+		}
+		return th
+	}
+	return nil
+}
+
+// writeArgs writes the arguments of call in the argument frame at sp.
+func (call *functionCall) writeArgs(thread *Thread, sp uint64) error {
+	for i, param := range call.args {
+		pv := thread.newVariable(param.name, uintptr(sp)+uintptr(param.off), param.typ)
+		if err := pv.setValue(call.argv[i]); err != nil {
+			return fmt.Errorf("could not write argument %s of %s: %v", param.name, call.fn.Name, err)
+		}
+	}
+	return nil
+}
+
+func (thread *Thread) writeUint64(addr, val uint64) error {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, val)
+	_, err := thread.writeMemory(uintptr(addr), buf)
+	return err
+}
@@ -0,0 +1,93 @@
+package proc
+
+import (
+	"debug/gosym"
+	"fmt"
+	"go/constant"
+	"reflect"
+)
+
+// setGCPercentFunctions are the functions that can be called to change
+// the garbage collection target percentage, in order of preference:
+// runtime/debug.SetGCPercent is only linked into programs that use it,
+// the runtime implements runtime/debug.setGCPercent for it.
+var setGCPercentFunctions = []string{"runtime/debug.SetGCPercent", "runtime/debug.setGCPercent"}
+
+// GCPercent returns the current value of the garbage collection target
+// percentage of the target process, as set by the GOGC environment
+// variable or by runtime/debug.SetGCPercent. A negative value means that
+// the garbage collector is disabled.
+func (dbp *Process) GCPercent() (int, error) {
+	if dbp.exited {
+		return 0, &ProcessExitedError{}
+	}
+	scope := &EvalScope{Thread: dbp.CurrentThread, PC: 0, CFA: 0}
+	v, err := scope.packageVarAddr("runtime.gcpercent")
+	if err != nil {
+		// starting with go1.18 the percentage is stored in gcController
+		ctl, err1 := scope.packageVarAddr("runtime.gcController")
+		if err1 != nil {
+			return 0, err
+		}
+		if v, err = ctl.structMember("gcPercent"); err != nil {
+			return 0, err
+		}
+	}
+	// the atomic.Int32 used by go1.19 and later is loaded as an integer
+	v.loadValue(loadFullValue)
+	if v.Unreadable != nil {
+		return 0, v.Unreadable
+	}
+	switch v.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, _ := constant.Int64Val(v.Value)
+		return int(n), nil
+	default:
+		return 0, fmt.Errorf("unknown type for gcpercent %s", v.RealType)
+	}
+}
+
+// ForceGC runs a garbage collection in the target process, calling
+// runtime.GC on the current goroutine.
+func (dbp *Process) ForceGC() error {
+	scope, err := dbp.ConvertEvalScope(-1, 0)
+	if err != nil {
+		return err
+	}
+	fn := dbp.goSymTable.LookupFunc("runtime.GC")
+	if fn == nil {
+		return fmt.Errorf("could not find runtime.GC")
+	}
+	_, err = scope.callFunction(fn, nil)
+	return err
+}
+
+// SetGCPercent sets the garbage collection target percentage of the target
+// process, calling runtime/debug.SetGCPercent on the current goroutine, and
+// returns the previous value. A negative value disables the garbage
+// collector.
+func (dbp *Process) SetGCPercent(pct int) (int, error) {
+	scope, err := dbp.ConvertEvalScope(-1, 0)
+	if err != nil {
+		return 0, err
+	}
+	var fn *gosym.Func
+	for _, name := range setGCPercentFunctions {
+		if fn = dbp.goSymTable.LookupFunc(name); fn != nil {
+			break
+		}
+	}
+	if fn == nil {
+		return 0, fmt.Errorf("could not find runtime/debug.SetGCPercent")
+	}
+	arg := newConstant(constant.MakeInt64(int64(pct)), scope.Thread)
+	v, err := scope.callFunction(fn, []*Variable{arg})
+	if err != nil {
+		return 0, err
+	}
+	if v == nil || v.Unreadable != nil || v.Value == nil {
+		return 0, fmt.Errorf("could not read the result of %s", fn.Name)
+	}
+	n, _ := constant.Int64Val(v.Value)
+	return int(n), nil
+}
@@ -1,7 +1,50 @@
 package proc
 
+import (
+	"fmt"
+	"os"
+)
+
 const cacheEnabled = true
 
+// PartialReadError is returned when only the first Valid bytes of a
+// memory range could be read.
+type PartialReadError struct {
+	Addr  uint64
+	Size  int
+	Valid int
+	Err   error
+}
+
+func (err *PartialReadError) Error() string {
+	return fmt.Sprintf("could only read %d of %d bytes at %#x: %v", err.Valid, err.Size, err.Addr, err.Err)
+}
+
+// readMemoryPrefix reads the range [addr, addr+size) one page at a time,
+// after the whole range failed to read with err, and returns the bytes
+// that precede the first unreadable page.
+func readMemoryPrefix(mem memoryReadWriter, addr uint64, size int, err error) ([]byte, error) {
+	pageSize := uint64(os.Getpagesize())
+	r := make([]byte, 0, size)
+	for cur, end := addr, addr+uint64(size); cur < end; {
+		n := pageSize - cur%pageSize
+		if cur+n > end {
+			n = end - cur
+		}
+		data, rerr := mem.readMemory(uintptr(cur), int(n))
+		if rerr != nil {
+			err = rerr
+			break
+		}
+		r = append(r, data...)
+		if uint64(len(data)) < n {
+			break
+		}
+		cur += n
+	}
+	return r, &PartialReadError{Addr: addr, Size: size, Valid: len(r), Err: err}
+}
+
 type memoryReadWriter interface {
 	readMemory(addr uintptr, size int) (data []byte, err error)
 	writeMemory(addr uintptr, data []byte) (written int, err error)
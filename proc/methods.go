@@ -0,0 +1,109 @@
+package proc
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/debug/dwarf"
+)
+
+// Method describes a method of a type, as compiled in the target program.
+type Method struct {
+	// Name is the name of the method.
+	Name string
+	// Receiver is the type of the receiver, either the type itself or a
+	// pointer to it.
+	Receiver string
+	// Args and Results are the arguments and return values of the method,
+	// excluding the receiver.
+	Args, Results []MethodParam
+	// Entry is the entry point of the method.
+	Entry uint64
+}
+
+// MethodParam is an argument or return value of a method.
+type MethodParam struct {
+	Name string
+	Type dwarf.Type
+}
+
+type methodsByName []Method
+
+func (s methodsByName) Len() int      { return len(s) }
+func (s methodsByName) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s methodsByName) Less(i, j int) bool {
+	if s[i].Name == s[j].Name {
+		return s[i].Receiver < s[j].Receiver
+	}
+	return s[i].Name < s[j].Name
+}
+
+// TypeMethods returns the methods of typeName, with either typeName or a
+// pointer to typeName as receiver, sorted by name.
+// Only methods compiled in the target program are returned, methods that
+// are never used may have been removed by the linker. Wrappers generated
+// by the compiler are ignored.
+func (dbp *Process) TypeMethods(typeName string) ([]Method, error) {
+	_, typeErr := dbp.findType(typeName)
+
+	methods := []Method{}
+	for i := range dbp.goSymTable.Funcs {
+		fn := &dbp.goSymTable.Funcs[i]
+		recv := methodReceiverType(fn.Sym)
+		if recv != typeName && recv != "*"+typeName {
+			continue
+		}
+		if file, _, _ := dbp.goSymTable.PCToLine(fn.Entry); file == "<autogenerated>" {
+			continue
+		}
+		m := Method{Name: fn.BaseName(), Receiver: recv, Entry: fn.Entry}
+		if err := dbp.methodParams(&m); err != nil {
+			return nil, fmt.Errorf("could not read parameters of %s: %v", fn.Name, err)
+		}
+		methods = append(methods, m)
+	}
+	if len(methods) == 0 && typeErr != nil {
+		return nil, fmt.Errorf("could not find type %s", typeName)
+	}
+	sort.Sort(methodsByName(methods))
+	return methods, nil
+}
+
+// methodParams fills the arguments and return values of m from the debug
+// info of its function.
+func (dbp *Process) methodParams(m *Method) error {
+	rdr := dbp.DwarfReader()
+	if _, err := rdr.SeekToFunction(m.Entry); err != nil {
+		return err
+	}
+	first := true
+	for entry, err := rdr.NextScopeVariable(); entry != nil; entry, err = rdr.NextScopeVariable() {
+		if err != nil {
+			return err
+		}
+		if entry.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+		name, _ := entry.Val(dwarf.AttrName).(string)
+		off, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+		if !ok {
+			continue
+		}
+		typ, err := dbp.dwarf.Type(off)
+		if err != nil {
+			return err
+		}
+		param := MethodParam{Name: name, Type: typ}
+		if isResult, _ := entry.Val(dwarf.AttrVarParam).(bool); isResult {
+			m.Results = append(m.Results, param)
+			continue
+		}
+		if first && (typ.Common().Name == m.Receiver || typ.String() == m.Receiver) {
+			first = false
+			continue
+		}
+		first = false
+		m.Args = append(m.Args, param)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package proc
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PointerKind is the kind of memory a pointer sized value points to.
+type PointerKind uint8
+
+const (
+	NotAPointer PointerKind = iota // the value does not point to any known memory
+	CodePointer                    // the value points inside a function
+	DataPointer                    // the value points inside a package variable
+	HeapPointer                    // the value points inside the heap arena
+)
+
+// PointerTarget describes the memory a pointer sized value points to.
+type PointerTarget struct {
+	Kind PointerKind
+	// Symbol is the function or package variable containing the pointed
+	// memory followed by the offset from its start, for example
+	// "main.main+0x1a", it is empty for heap pointers.
+	Symbol string
+}
+
+type globalRange struct {
+	name       string
+	addr, size uint64
+}
+
+type byAddr []globalRange
+
+func (s byAddr) Len() int           { return len(s) }
+func (s byAddr) Less(i, j int) bool { return s[i].addr < s[j].addr }
+func (s byAddr) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// ClassifyPointers returns, for each value in vals, what kind of memory
+// the value would point to if it was a pointer.
+func (dbp *Process) ClassifyPointers(vals []uint64) []PointerTarget {
+	globals := dbp.packageVarRanges()
+	heapStart, heapEnd := dbp.heapArena()
+
+	r := make([]PointerTarget, len(vals))
+	for i, val := range vals {
+		if val == 0 {
+			continue
+		}
+		if _, _, fn := dbp.PCToLine(val); fn != nil {
+			r[i] = PointerTarget{CodePointer, fmt.Sprintf("%s+%#x", fn.Name, val-fn.Entry)}
+			continue
+		}
+		j := sort.Search(len(globals), func(j int) bool { return globals[j].addr+globals[j].size > val })
+		if j < len(globals) && globals[j].addr <= val {
+			r[i] = PointerTarget{DataPointer, fmt.Sprintf("%s+%#x", globals[j].name, val-globals[j].addr)}
+			continue
+		}
+		if val >= heapStart && val < heapEnd {
+			r[i] = PointerTarget{Kind: HeapPointer}
+		}
+	}
+	return r
+}
+
+// packageVarRanges returns the memory ranges occupied by package
+// variables, sorted by address.
+func (dbp *Process) packageVarRanges() []globalRange {
+	scope := &EvalScope{Thread: dbp.CurrentThread, PC: 0, CFA: 0}
+	var globals []globalRange
+	reader := scope.DwarfReader()
+	for entry, err := reader.NextPackageVariable(); entry != nil; entry, err = reader.NextPackageVariable() {
+		if err != nil {
+			break
+		}
+		v, err := scope.extractVarInfoFromEntry(entry, reader)
+		if err != nil || v.Addr == 0 || v.RealType.Size() <= 0 {
+			continue
+		}
+		globals = append(globals, globalRange{v.Name, uint64(v.Addr), uint64(v.RealType.Size())})
+	}
+	sort.Sort(byAddr(globals))
+	return globals
+}
+
+// heapArena returns the start and end address of the heap arena, if they
+// can not be determined both are 0.
+func (dbp *Process) heapArena() (start, end uint64) {
+	scope := &EvalScope{Thread: dbp.CurrentThread, PC: 0, CFA: 0}
+	mheap, err := scope.packageVarAddr("runtime.mheap_")
+	if err != nil {
+		return 0, 0
+	}
+	startVar, err := mheap.structMember("arena_start")
+	if err != nil {
+		return 0, 0
+	}
+	endVar, err := mheap.structMember("arena_used")
+	if err != nil {
+		return 0, 0
+	}
+	if start, err = startVar.asUint(); err != nil {
+		return 0, 0
+	}
+	if end, err = endVar.asUint(); err != nil {
+		return 0, 0
+	}
+	return start, end
+}
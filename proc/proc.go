@@ -11,9 +11,11 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/derekparker/delve/dwarf/frame"
 	"github.com/derekparker/delve/dwarf/line"
@@ -41,6 +43,10 @@ type Process struct {
 	// Normally SelectedGoroutine is CurrentThread.GetG, it will not be only if SwitchGoroutine is called with a goroutine that isn't attached to a thread
 	SelectedGoroutine *G
 
+	// Watchpoints removed because the local variable they watched went out
+	// of scope, appended by Continue.
+	WatchOutOfScope []*Breakpoint
+
 	// Maps package names to package paths, needed to lookup types inside DWARF info
 	packageMap map[string]string
 
@@ -56,17 +62,48 @@ type Process struct {
 	firstStart                  bool
 	halt                        bool
 	exited                      bool
+	signalPolicies              map[int]SignalPolicy
 	ptraceChan                  chan func()
 	ptraceDoneChan              chan interface{}
 	types                       map[string]dwarf.Offset
+	buildInfoData               []byte
+	buildID                     string
+	dataSymbols                 map[string]dataSymbol
+	evalDeadline                time.Time
+	fncallForbidden             bool           // function calls can not be injected in the target
+	callInterrupted             bool           // set by RequestManualStop to interrupt the function call being injected
+	pendingCall                 *callInjection // function call interrupted while running, see injectCall
+	stepIntoFn                  string         // only function entered by the step in progress, see StepInto
+	constants                   map[dwarf.Offset][]constantValue
+	runTime                     time.Duration // time spent running by Continue, see Breakpoint.ActiveAfter
+	core                        *coreFile // set when examining a core file, see OpenCore
 
 	loadModuleDataOnce sync.Once
 	moduleData         []moduleData
 	nameOfRuntimeType  map[uintptr]nameOfRuntimeTypeEntry
+
+	loadWaitReasonsOnce sync.Once
+	waitReasons         []string
+
+	fncallSupportOnce sync.Once
+	fncallSupportErr  error // see FunctionCallsSupported
 }
 
 var NotExecutableErr = errors.New("not an executable file")
 
+// LaunchOptions are the options of LaunchWithOptions.
+type LaunchOptions struct {
+	// Env is the environment of the new process, if it is nil the new
+	// process inherits the environment of delve.
+	Env []string
+	// PTY connects the standard input, output and error of the new process
+	// to a pseudo-terminal, see LaunchPTY. Stdout and Stderr are ignored.
+	PTY bool
+	// Stdout and Stderr replace the standard output and error of delve as
+	// the ones of the new process.
+	Stdout, Stderr *os.File
+}
+
 // New returns an initialized Process struct. Before returning,
 // it will also launch a goroutine in order to handle ptrace(2)
 // functions. For more information, see the documentation on
@@ -103,14 +140,22 @@ func (pe ProcessExitedError) Error() string {
 }
 
 // Detach from the process being debugged, optionally killing it.
+// If kill is false all breakpoints are removed first and the process
+// keeps running after Detach returns.
 func (dbp *Process) Detach(kill bool) (err error) {
+	if dbp.core != nil {
+		dbp.core.close()
+		dbp.postExit()
+		return nil
+	}
 	if dbp.Running() {
 		if err = dbp.Halt(); err != nil {
 			return
 		}
 	}
 	if !kill {
-		// Clean up any breakpoints we've set.
+		// Clean up any breakpoints we've set, the process would receive a
+		// SIGTRAP the next time it executes one of them.
 		for _, bp := range dbp.Breakpoints {
 			if bp != nil {
 				_, err := dbp.ClearBreakpoint(bp.Addr)
@@ -121,7 +166,7 @@ func (dbp *Process) Detach(kill bool) (err error) {
 		}
 	}
 	dbp.execPtraceFunc(func() {
-		err = PtraceDetach(dbp.Pid, 0)
+		err = dbp.detach()
 		if err != nil {
 			return
 		}
@@ -129,6 +174,9 @@ func (dbp *Process) Detach(kill bool) (err error) {
 			err = killProcess(dbp.Pid)
 		}
 	})
+	if err == nil {
+		dbp.postExit()
+	}
 	return
 }
 
@@ -138,6 +186,12 @@ func (dbp *Process) Exited() bool {
 	return dbp.exited
 }
 
+// evalTimedOut returns true if the expression currently being evaluated
+// has passed its deadline.
+func (dbp *Process) evalTimedOut() bool {
+	return !dbp.evalDeadline.IsZero() && time.Now().After(dbp.evalDeadline)
+}
+
 // Running returns whether the debugged
 // process is currently executing.
 func (dbp *Process) Running() bool {
@@ -162,12 +216,15 @@ func (dbp *Process) LoadInformation(path string) error {
 		return err
 	}
 
-	wg.Add(5)
+	wg.Add(8)
 	go dbp.loadProcessInformation(&wg)
 	go dbp.parseDebugFrame(exe, &wg)
 	go dbp.obtainGoSymbols(exe, &wg)
 	go dbp.parseDebugLineInfo(exe, &wg)
 	go dbp.loadTypeMap(&wg)
+	go dbp.loadConstants(&wg)
+	go dbp.loadBuildInfo(exe, &wg)
+	go dbp.loadDataSymbols(exe, &wg)
 	wg.Wait()
 
 	return nil
@@ -209,6 +266,114 @@ func (dbp *Process) FindFunctionLocation(funcName string, firstLine bool, lineOf
 	return origfn.Entry, nil
 }
 
+// StatementIndex returns the name of the function containing pc and the
+// index, in order of line number, of the source line of pc among the lines
+// of the function that have code.
+// Together they identify the same statement in a different build of the
+// program, provided that the statements of the function that precede it
+// have not changed, see FindFunctionStatement.
+func (dbp *Process) StatementIndex(pc uint64) (string, int, error) {
+	fn := dbp.goSymTable.PCToFunc(pc)
+	if fn == nil {
+		return "", 0, fmt.Errorf("could not find function containing %#x", pc)
+	}
+	_, line, _ := dbp.goSymTable.PCToLine(pc)
+	lines, _ := dbp.functionStatements(fn)
+	for i := range lines {
+		if lines[i] == line {
+			return fn.Name, i, nil
+		}
+	}
+	return "", 0, fmt.Errorf("could not find line %d in function %s", line, fn.Name)
+}
+
+// FindFunctionStatement returns the address of the statement of funcName
+// with index idx, as returned by StatementIndex.
+func (dbp *Process) FindFunctionStatement(funcName string, idx int) (uint64, error) {
+	fn := dbp.goSymTable.LookupFunc(funcName)
+	if fn == nil {
+		return 0, fmt.Errorf("Could not find function %s\n", funcName)
+	}
+	lines, pcs := dbp.functionStatements(fn)
+	if idx < 0 || idx >= len(lines) {
+		return 0, fmt.Errorf("function %s has no statement %d", funcName, idx)
+	}
+	return pcs[idx], nil
+}
+
+// functionStatements returns the lines of fn that have code, sorted by
+// line number, and the lowest address of each one.
+// Lines of other files, belonging to inlined calls, are ignored.
+func (dbp *Process) functionStatements(fn *gosym.Func) ([]int, []uint64) {
+	filename, _, _ := dbp.goSymTable.PCToLine(fn.Entry)
+	firstPC := map[int]uint64{}
+	for _, pc := range dbp.lineInfo.AllPCsBetween(fn.Entry, fn.End-1, filename) {
+		f, l, _ := dbp.goSymTable.PCToLine(pc)
+		if f != filename {
+			continue
+		}
+		if addr, ok := firstPC[l]; !ok || pc < addr {
+			firstPC[l] = pc
+		}
+	}
+	lines := make([]int, 0, len(firstPC))
+	for l := range firstPC {
+		lines = append(lines, l)
+	}
+	sort.Ints(lines)
+	pcs := make([]uint64, len(lines))
+	for i := range lines {
+		pcs[i] = firstPC[lines[i]]
+	}
+	return lines, pcs
+}
+
+// FindInlinedCallSites returns the entry address of every inlined
+// instance of funcName recorded in the debug info.
+// Functions that are always inlined have no standalone entry point,
+// setting a breakpoint on each of these addresses is the only way to
+// stop when they are executed.
+func (dbp *Process) FindInlinedCallSites(funcName string) ([]uint64, error) {
+	rdr := dbp.DwarfReader()
+	origins := map[dwarf.Offset]bool{}
+	for entry, err := rdr.Next(); entry != nil; entry, err = rdr.Next() {
+		if err != nil {
+			return nil, err
+		}
+		if entry.Tag != dwarf.TagSubprogram {
+			continue
+		}
+		if name, _ := entry.Val(dwarf.AttrName).(string); name == funcName {
+			origins[entry.Offset] = true
+		}
+	}
+	if len(origins) == 0 {
+		return nil, fmt.Errorf("Could not find function %s\n", funcName)
+	}
+
+	var pcs []uint64
+	rdr.Seek(0)
+	for entry, err := rdr.Next(); entry != nil; entry, err = rdr.Next() {
+		if err != nil {
+			return nil, err
+		}
+		if entry.Tag != dwarf.TagInlinedSubroutine {
+			continue
+		}
+		origin, ok := entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		if !ok || !origins[origin] {
+			continue
+		}
+		if lowpc, ok := entry.Val(dwarf.AttrLowpc).(uint64); ok {
+			pcs = append(pcs, lowpc)
+		}
+	}
+	if len(pcs) == 0 {
+		return nil, fmt.Errorf("Could not find inlined instances of function %s\n", funcName)
+	}
+	return pcs, nil
+}
+
 // CurrentLocation returns the location of the current thread.
 func (dbp *Process) CurrentLocation() (*Location, error) {
 	return dbp.CurrentThread.Location()
@@ -220,7 +385,11 @@ func (dbp *Process) RequestManualStop() error {
 	if dbp.exited {
 		return &ProcessExitedError{}
 	}
+	if dbp.core != nil {
+		return CoreReadOnlyErr
+	}
 	dbp.halt = true
+	dbp.callInterrupted = true
 	return dbp.requestManualStop()
 }
 
@@ -228,6 +397,9 @@ func (dbp *Process) RequestManualStop() error {
 // break point table. Setting a break point must be thread specific due to
 // ptrace actions needing the thread to be in a signal-delivery-stop.
 func (dbp *Process) SetBreakpoint(addr uint64, kind BreakpointKind, cond ast.Expr) (*Breakpoint, error) {
+	if dbp.core != nil {
+		return nil, CoreReadOnlyErr
+	}
 	tid := dbp.CurrentThread.ID
 
 	if bp, ok := dbp.FindBreakpoint(addr); ok {
@@ -300,6 +472,9 @@ func (dbp *Process) Next() (err error) {
 	if dbp.exited {
 		return &ProcessExitedError{}
 	}
+	if dbp.core != nil {
+		return CoreReadOnlyErr
+	}
 	for i := range dbp.Breakpoints {
 		if dbp.Breakpoints[i].Internal() {
 			return fmt.Errorf("next while nexting")
@@ -325,7 +500,11 @@ func (dbp *Process) Continue() error {
 	if dbp.exited {
 		return &ProcessExitedError{}
 	}
+	if dbp.core != nil {
+		return CoreReadOnlyErr
+	}
 	for {
+		resumed := time.Now()
 		if err := dbp.resume(); err != nil {
 			return err
 		}
@@ -336,6 +515,7 @@ func (dbp *Process) Continue() error {
 		}
 
 		trapthread, err := dbp.trapWait(-1)
+		dbp.runTime += time.Since(resumed)
 		if err != nil {
 			return err
 		}
@@ -345,6 +525,15 @@ func (dbp *Process) Continue() error {
 		if err := dbp.setCurrentBreakpoints(trapthread); err != nil {
 			return err
 		}
+		if dbp.pendingCall != nil {
+			stepped, err := dbp.stepPendingCall()
+			if err != nil {
+				return err
+			}
+			if stepped && !dbp.anyTriggeredBreakpoint() {
+				continue
+			}
+		}
 		if err := dbp.pickCurrentThread(trapthread); err != nil {
 			return err
 		}
@@ -359,6 +548,9 @@ func (dbp *Process) Continue() error {
 					}
 				}
 			}
+			if err := dbp.removeOutOfScopeWatchpoints(); err != nil {
+				return err
+			}
 			return dbp.conditionErrors()
 		case dbp.CurrentThread.onTriggeredInternalBreakpoint():
 			if dbp.CurrentThread.CurrentBreakpoint.Kind == StepBreakpoint {
@@ -384,9 +576,19 @@ func (dbp *Process) Continue() error {
 				if err := dbp.ClearInternalBreakpoints(); err != nil {
 					return err
 				}
+				if err := dbp.removeOutOfScopeWatchpoints(); err != nil {
+					return err
+				}
 				return dbp.conditionErrors()
 			}
 		case dbp.CurrentThread.onTriggeredBreakpoint():
+			if err := dbp.removeOutOfScopeWatchpoints(); err != nil {
+				return err
+			}
+			if !dbp.CurrentThread.onTriggeredBreakpoint() {
+				// stopped only by a watchpoint that went out of scope
+				continue
+			}
 			onNextGoroutine, err := dbp.CurrentThread.onNextGoroutine()
 			if err != nil {
 				return err
@@ -418,6 +620,17 @@ func (dbp *Process) conditionErrors() error {
 	return condErr
 }
 
+// anyTriggeredBreakpoint returns true if any thread is stopped at a
+// breakpoint whose condition is met.
+func (dbp *Process) anyTriggeredBreakpoint() bool {
+	for _, th := range dbp.Threads {
+		if th.onTriggeredBreakpoint() {
+			return true
+		}
+	}
+	return false
+}
+
 // pick a new dbp.CurrentThread, with the following priority:
 // 	- a thread with onTriggeredInternalBreakpoint() == true
 // 	- a thread with onTriggeredBreakpoint() == true (prioritizing trapthread)
@@ -445,6 +658,9 @@ func (dbp *Process) Step() (err error) {
 	if dbp.exited {
 		return &ProcessExitedError{}
 	}
+	if dbp.core != nil {
+		return CoreReadOnlyErr
+	}
 	for i := range dbp.Breakpoints {
 		if dbp.Breakpoints[i].Internal() {
 			return fmt.Errorf("next while nexting")
@@ -463,6 +679,38 @@ func (dbp *Process) Step() (err error) {
 	return dbp.Continue()
 }
 
+// StepInto continues until the function called fnName is entered, if it
+// is called by the current source line, stopping after its prologue.
+// If the current line does not call fnName it behaves like Next.
+func (dbp *Process) StepInto(fnName string) (err error) {
+	if dbp.exited {
+		return &ProcessExitedError{}
+	}
+	if dbp.core != nil {
+		return CoreReadOnlyErr
+	}
+	for i := range dbp.Breakpoints {
+		if dbp.Breakpoints[i].Internal() {
+			return fmt.Errorf("next while nexting")
+		}
+	}
+	if dbp.goSymTable.LookupFunc(fnName) == nil {
+		return fmt.Errorf("could not find function %s", fnName)
+	}
+
+	dbp.stepIntoFn = fnName
+	if err = dbp.next(true); err != nil {
+		switch err.(type) {
+		case ThreadBlockedError, NoReturnAddr: // Noop
+		default:
+			dbp.ClearInternalBreakpoints()
+			return
+		}
+	}
+
+	return dbp.Continue()
+}
+
 // Returns an expression that evaluates to true when the current goroutine is g
 func sameGoroutineCondition(g *G) ast.Expr {
 	if g == nil {
@@ -486,6 +734,9 @@ func sameGoroutineCondition(g *G) ast.Expr {
 // asssociated with the selected goroutine. All other
 // threads will remain stopped.
 func (dbp *Process) StepInstruction() (err error) {
+	if dbp.core != nil {
+		return CoreReadOnlyErr
+	}
 	if dbp.SelectedGoroutine == nil {
 		return errors.New("cannot single step: no selected goroutine")
 	}
@@ -508,9 +759,145 @@ func (dbp *Process) StepInstruction() (err error) {
 	return dbp.SelectedGoroutine.thread.SetCurrentBreakpoint()
 }
 
+// ContinueRecordingLines resumes the thread running the selected goroutine
+// one instruction at a time until a user breakpoint is reached, the
+// process exits or maxInstructions instructions have been executed.
+// The distinct source lines executed are returned in the order they were
+// first reached.
+// Other threads are not resumed: if the selected goroutine blocks waiting
+// for another thread it will not make progress.
+func (dbp *Process) ContinueRecordingLines(maxInstructions int) ([]Location, error) {
+	if dbp.exited {
+		return nil, &ProcessExitedError{}
+	}
+	if dbp.core != nil {
+		return nil, CoreReadOnlyErr
+	}
+	if dbp.SelectedGoroutine == nil {
+		return nil, errors.New("cannot single step: no selected goroutine")
+	}
+	thread := dbp.SelectedGoroutine.thread
+	if thread == nil {
+		return nil, errors.New("cannot single step: selected goroutine is not running on a thread")
+	}
+	dbp.allGCache = nil
+	thread.clearBreakpointState()
+
+	type fileLine struct {
+		file string
+		line int
+	}
+	seen := map[fileLine]bool{}
+	lines := []Location{}
+	for i := 0; i < maxInstructions; i++ {
+		pc, err := thread.PC()
+		if err != nil {
+			return lines, err
+		}
+		if f, l, fn := dbp.PCToLine(pc); fn != nil && !seen[fileLine{f, l}] {
+			seen[fileLine{f, l}] = true
+			lines = append(lines, Location{PC: pc, File: f, Line: l, Fn: fn})
+		}
+		if err := thread.StepInstruction(); err != nil {
+			return lines, err
+		}
+		if err := thread.SetCurrentBreakpoint(); err != nil {
+			return lines, err
+		}
+		if thread.onTriggeredBreakpoint() && !thread.CurrentBreakpoint.Internal() {
+			break
+		}
+	}
+	return lines, nil
+}
+
+// maxScheduleInstructions is the maximum number of instructions executed
+// by ContinueUntilGoroutineChange, after runtime.execute is called, while
+// waiting for the new goroutine to be installed on the thread.
+const maxScheduleInstructions = 10000
+
+// ContinueUntilGoroutineChange resumes the process until a goroutine other
+// than the selected one starts executing on one of the threads, a user
+// breakpoint is reached or the process exits. When the switch happens the
+// new goroutine becomes the selected goroutine.
+func (dbp *Process) ContinueUntilGoroutineChange() error {
+	if dbp.exited {
+		return &ProcessExitedError{}
+	}
+	if dbp.core != nil {
+		return CoreReadOnlyErr
+	}
+	if dbp.SelectedGoroutine == nil {
+		return errors.New("no selected goroutine")
+	}
+	for i := range dbp.Breakpoints {
+		if dbp.Breakpoints[i].Internal() {
+			return fmt.Errorf("next while nexting")
+		}
+	}
+	execute := dbp.goSymTable.LookupFunc("runtime.execute")
+	if execute == nil {
+		return errors.New("could not find runtime.execute")
+	}
+	startID := dbp.SelectedGoroutine.ID
+
+	for {
+		if _, err := dbp.SetBreakpoint(execute.Entry, NextBreakpoint, nil); err != nil {
+			dbp.ClearInternalBreakpoints()
+			return err
+		}
+		if err := dbp.Continue(); err != nil {
+			dbp.ClearInternalBreakpoints()
+			return err
+		}
+		if err := dbp.ClearInternalBreakpoints(); err != nil {
+			return err
+		}
+
+		thread := dbp.CurrentThread
+		pc, err := thread.PC()
+		if err != nil {
+			return err
+		}
+		if pc != execute.Entry || thread.CurrentBreakpoint != nil {
+			// stopped by a user breakpoint or a manual stop
+			return nil
+		}
+
+		// The thread is about to switch to a new goroutine, step it until the
+		// goroutine is installed.
+		for i := 0; i < maxScheduleInstructions; i++ {
+			if err := thread.StepInstruction(); err != nil {
+				return err
+			}
+			if err := thread.SetCurrentBreakpoint(); err != nil {
+				return err
+			}
+			if thread.onTriggeredBreakpoint() && !thread.CurrentBreakpoint.Internal() {
+				return dbp.SwitchThread(thread.ID)
+			}
+			if g, err := thread.GetG(); err == nil && g != nil && g.ID != 0 {
+				if g.ID == startID {
+					break
+				}
+				return dbp.SwitchThread(thread.ID)
+			}
+		}
+		dbp.allGCache = nil
+	}
+}
+
 // StepOut will continue until the current goroutine exits the
 // function currently being executed or a deferred function is executed
 func (dbp *Process) StepOut() error {
+	if dbp.exited {
+		return &ProcessExitedError{}
+	}
+	for i := range dbp.Breakpoints {
+		if dbp.Breakpoints[i].Internal() {
+			return fmt.Errorf("next while nexting")
+		}
+	}
 	cond := sameGoroutineCondition(dbp.SelectedGoroutine)
 
 	topframe, err := topframe(dbp.SelectedGoroutine, dbp.CurrentThread)
@@ -678,6 +1065,10 @@ func (dbp *Process) Halt() (err error) {
 	if dbp.exited {
 		return &ProcessExitedError{}
 	}
+	if dbp.core != nil {
+		// the threads of a core file never run
+		return nil
+	}
 	for _, th := range dbp.Threads {
 		if err := th.Halt(); err != nil {
 			return err
@@ -713,6 +1104,22 @@ func (dbp *Process) Sources() map[string]*gosym.Obj {
 	return dbp.goSymTable.Files
 }
 
+// LineTableSources returns the source files referenced by the DWARF line
+// tables, they can include files that have no entry in Sources.
+func (dbp *Process) LineTableSources() []string {
+	var r []string
+	for _, info := range dbp.lineInfo {
+		for _, entry := range info.FileNames {
+			name := entry.Name
+			if !filepath.IsAbs(name) && entry.DirIdx > 0 && int(entry.DirIdx) <= len(info.IncludeDirs) {
+				name = filepath.Join(info.IncludeDirs[entry.DirIdx-1], name)
+			}
+			r = append(r, name)
+		}
+	}
+	return r
+}
+
 // Funcs returns list of functions present in the debugged program.
 func (dbp *Process) Funcs() []gosym.Func {
 	return dbp.goSymTable.Funcs
@@ -727,6 +1134,62 @@ func (dbp *Process) Types() ([]string, error) {
 	return types, nil
 }
 
+// MaxReadMemorySize is the maximum number of bytes ReadMemory reads at
+// once.
+const MaxReadMemorySize = 1 << 20
+
+// ReadMemory reads size bytes of memory starting at addr from the
+// address space of the process. The original instructions are returned
+// in place of the breakpoints set inside the range.
+// If the range crosses unmapped memory the readable prefix is returned
+// along with a *PartialReadError.
+func (dbp *Process) ReadMemory(addr uint64, size int) ([]byte, error) {
+	if dbp.exited {
+		return nil, &ProcessExitedError{}
+	}
+	if size <= 0 || size > MaxReadMemorySize {
+		return nil, fmt.Errorf("invalid size %d, at most %d bytes can be read at once", size, MaxReadMemorySize)
+	}
+	mem, err := dbp.CurrentThread.readMemory(uintptr(addr), size)
+	if err != nil {
+		mem, err = readMemoryPrefix(dbp.CurrentThread, addr, size, err)
+	}
+	for _, bp := range dbp.Breakpoints {
+		if bp.IsWatchpoint() {
+			continue
+		}
+		for i := range bp.OriginalData {
+			if a := bp.Addr + uint64(i); a >= addr && a < addr+uint64(len(mem)) {
+				mem[a-addr] = bp.OriginalData[i]
+			}
+		}
+	}
+	return mem, err
+}
+
+// WriteMemory writes data to the address space of the process starting
+// at addr and returns the number of bytes written. Breakpoints set inside
+// the range are kept: the new bytes become their original instructions.
+func (dbp *Process) WriteMemory(addr uint64, data []byte) (int, error) {
+	if dbp.exited {
+		return 0, &ProcessExitedError{}
+	}
+	n, err := dbp.CurrentThread.writeMemory(uintptr(addr), data)
+	if err != nil {
+		return n, err
+	}
+	for _, bp := range dbp.Breakpoints {
+		if bp.IsWatchpoint() || bp.Addr < addr || bp.Addr >= addr+uint64(n) {
+			continue
+		}
+		copy(bp.OriginalData, data[bp.Addr-addr:])
+		if _, err := dbp.CurrentThread.writeMemory(uintptr(bp.Addr), dbp.arch.BreakpointInstruction()); err != nil {
+			return n, fmt.Errorf("could not restore breakpoint %d: %v", bp.ID, err)
+		}
+	}
+	return n, nil
+}
+
 // PCToLine converts an instruction address to a file/line/function.
 func (dbp *Process) PCToLine(pc uint64) (string, int, *gosym.Func) {
 	return dbp.goSymTable.PCToLine(pc)
@@ -810,6 +1273,7 @@ func initializeDebugProcess(dbp *Process, path string, attach bool) (*Process, e
 }
 
 func (dbp *Process) ClearInternalBreakpoints() error {
+	dbp.stepIntoFn = ""
 	for _, bp := range dbp.Breakpoints {
 		if !bp.Internal() {
 			continue
@@ -893,8 +1357,24 @@ func (dbp *Process) FindGoroutine(gid int) (*G, error) {
 	return nil, fmt.Errorf("Unknown goroutine %d", gid)
 }
 
+// FindGoroutineByAddress returns the goroutine whose runtime.g struct
+// is stored at the specified address.
+func (dbp *Process) FindGoroutineByAddress(addr uint64) (*G, error) {
+	gs, err := dbp.GoroutinesInfo()
+	if err != nil {
+		return nil, err
+	}
+	for i := range gs {
+		if gs[i].Addr == addr {
+			return gs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no goroutine at address %#x", addr)
+}
+
 // ConvertEvalScope returns a new EvalScope in the context of the
 // specified goroutine ID and stack frame.
+// The selected goroutine and the current thread are not changed.
 func (dbp *Process) ConvertEvalScope(gid, frame int) (*EvalScope, error) {
 	if dbp.exited {
 		return nil, &ProcessExitedError{}
@@ -904,7 +1384,14 @@ func (dbp *Process) ConvertEvalScope(gid, frame int) (*EvalScope, error) {
 		return nil, err
 	}
 	if g == nil {
-		return dbp.CurrentThread.Scope()
+		locs, err := dbp.CurrentThread.Stacktrace(frame)
+		if err != nil {
+			return nil, err
+		}
+		if frame >= len(locs) {
+			return nil, fmt.Errorf("Frame %d does not exist in thread %d", frame, dbp.CurrentThread.ID)
+		}
+		return locs[frame].Scope(dbp.CurrentThread), nil
 	}
 
 	var out EvalScope
@@ -925,6 +1412,7 @@ func (dbp *Process) ConvertEvalScope(gid, frame int) (*EvalScope, error) {
 	}
 
 	out.PC, out.CFA = locs[frame].Current.PC, locs[frame].CFA
+	out.g = g
 
 	return &out, nil
 }
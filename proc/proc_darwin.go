@@ -22,6 +22,10 @@ import (
 	sys "golang.org/x/sys/unix"
 )
 
+// signalsSupported is true if SetSignalPolicy and DeliverSignal are
+// supported, signals are received as mach exceptions on darwin.
+const signalsSupported = false
+
 // OSProcessDetails holds Darwin specific information.
 type OSProcessDetails struct {
 	task             C.task_t      // mach task for the debugged process.
@@ -33,6 +37,21 @@ type OSProcessDetails struct {
 	portSet C.mach_port_t
 }
 
+// LaunchPTY is not supported on darwin.
+func LaunchPTY(cmd []string, wd string) (*Process, error) {
+	return nil, errors.New("launching a process with a pseudo-terminal is not supported on darwin")
+}
+
+// LaunchWithOptions is not supported on darwin.
+func LaunchWithOptions(cmd []string, wd string, opts LaunchOptions) (*Process, error) {
+	return nil, errors.New("launching a process with options is not supported on darwin")
+}
+
+// OpenCore is not supported on darwin.
+func OpenCore(corePath, exePath string) (*Process, error) {
+	return nil, errors.New("core files are not supported on darwin")
+}
+
 // Launch creates and begins debugging a new process. Uses a
 // custom fork/exec process in order to take advantage of
 // PT_SIGEXC on Darwin which will turn Unix signals into
@@ -128,6 +147,10 @@ func (dbp *Process) Kill() (err error) {
 	return
 }
 
+func (dbp *Process) detach() error {
+	return PtraceDetach(dbp.Pid, 0)
+}
+
 func (dbp *Process) requestManualStop() (err error) {
 	var (
 		task          = C.mach_port_t(dbp.os.task)
@@ -271,6 +294,30 @@ func (dbp *Process) parseDebugLineInfo(exe *macho.File, wg *sync.WaitGroup) {
 	}
 }
 
+func (dbp *Process) loadBuildInfo(exe *macho.File, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if sec := exe.Section("__go_buildinfo"); sec != nil {
+		if data, err := sec.Data(); err == nil {
+			if off := findBuildInfo(data); off >= 0 {
+				dbp.buildInfoData = data[off:]
+			}
+		}
+	}
+
+	if sec := exe.Section("__text"); sec != nil {
+		if text, err := sec.Data(); err == nil {
+			dbp.buildID = findBuildID(text)
+		}
+	}
+}
+
+func (dbp *Process) loadDataSymbols(exe *macho.File, wg *sync.WaitGroup) {
+	// Mach-O symbols do not record their size, C globals without debug
+	// information are not supported.
+	wg.Done()
+}
+
 var UnsupportedArchErr = errors.New("unsupported architecture - only darwin/amd64 is supported")
 
 func (dbp *Process) findExecutable(path string) (*macho.File, error) {
@@ -403,6 +450,15 @@ func (dbp *Process) exitGuard(err error) error {
 	return err
 }
 
+// resumeExcept resumes all threads except the ones in held, it is only
+// used by function calls, which are not supported on this platform.
+func (dbp *Process) resumeExcept(held map[int]bool) error {
+	if len(held) > 0 {
+		return errFunctionCallsUnsupported
+	}
+	return dbp.resume()
+}
+
 func (dbp *Process) resume() error {
 	// all threads stopped over a breakpoint are made to step over it
 	for _, thread := range dbp.Threads {
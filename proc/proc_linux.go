@@ -37,6 +37,10 @@ const (
 	StatusTraceStopT = 'T'
 )
 
+// signalsSupported is true if SetSignalPolicy and DeliverSignal are
+// supported.
+const signalsSupported = true
+
 // OSProcessDetails contains Linux specific
 // process details.
 type OSProcessDetails struct {
@@ -47,6 +51,24 @@ type OSProcessDetails struct {
 // `cmd` is the program to run, and then rest are the arguments
 // to be supplied to that process. `wd` is working directory of the program.
 func Launch(cmd []string, wd string) (*Process, error) {
+	return launch(cmd, wd, LaunchOptions{})
+}
+
+// LaunchPTY is like Launch but the standard input, output and error of the
+// new process are connected to a newly allocated pseudo-terminal, so that
+// the process sees them as a terminal. The output written to the
+// pseudo-terminal is copied to the standard output of delve.
+func LaunchPTY(cmd []string, wd string) (*Process, error) {
+	return launch(cmd, wd, LaunchOptions{PTY: true})
+}
+
+// LaunchWithOptions is like Launch but the environment and the standard
+// output and error of the new process are set by opts.
+func LaunchWithOptions(cmd []string, wd string, opts LaunchOptions) (*Process, error) {
+	return launch(cmd, wd, opts)
+}
+
+func launch(cmd []string, wd string, opts LaunchOptions) (*Process, error) {
 	var (
 		proc *exec.Cmd
 		err  error
@@ -55,27 +77,56 @@ func Launch(cmd []string, wd string) (*Process, error) {
 	if fi, staterr := os.Stat(cmd[0]); staterr == nil && (fi.Mode()&0111) == 0 {
 		return nil, NotExecutableErr
 	}
+	var master, slave *os.File
+	if opts.PTY {
+		if master, slave, err = openPTY(); err != nil {
+			return nil, fmt.Errorf("could not allocate pseudo-terminal: %v", err)
+		}
+		defer slave.Close()
+	}
 	dbp := New(0)
 	dbp.execPtraceFunc(func() {
 		proc = exec.Command(cmd[0])
 		proc.Args = cmd
+		proc.Env = opts.Env
 		proc.Stdout = os.Stdout
 		proc.Stderr = os.Stderr
+		if opts.Stdout != nil {
+			proc.Stdout = opts.Stdout
+		}
+		if opts.Stderr != nil {
+			proc.Stderr = opts.Stderr
+		}
+		if opts.PTY {
+			proc.Stdin = slave
+			proc.Stdout = slave
+			proc.Stderr = slave
+		}
 		proc.SysProcAttr = &syscall.SysProcAttr{Ptrace: true, Setpgid: true}
 		if wd != "" {
 			proc.Dir = wd
 		}
 		err = proc.Start()
 	})
+	if err == nil {
+		dbp.Pid = proc.Process.Pid
+		if _, _, err = dbp.wait(proc.Process.Pid, 0); err != nil {
+			err = fmt.Errorf("waiting for target execve failed: %s", err)
+		}
+	}
+	if err == nil {
+		dbp, err = initializeDebugProcess(dbp, proc.Path, false)
+	}
 	if err != nil {
+		if master != nil {
+			master.Close()
+		}
 		return nil, err
 	}
-	dbp.Pid = proc.Process.Pid
-	_, _, err = dbp.wait(proc.Process.Pid, 0)
-	if err != nil {
-		return nil, fmt.Errorf("waiting for target execve failed: %s", err)
+	if master != nil {
+		go copyPTYOutput(os.Stdout, master)
 	}
-	return initializeDebugProcess(dbp, proc.Path, false)
+	return dbp, nil
 }
 
 // Attach to an existing process with the given PID.
@@ -88,6 +139,11 @@ func (dbp *Process) Kill() (err error) {
 	if dbp.exited {
 		return nil
 	}
+	if dbp.core != nil {
+		dbp.core.close()
+		dbp.postExit()
+		return nil
+	}
 	if !dbp.Threads[dbp.Pid].Stopped() {
 		return errors.New("process must be stopped in order to kill it")
 	}
@@ -101,6 +157,19 @@ func (dbp *Process) Kill() (err error) {
 	return
 }
 
+// detach detaches from every thread of the process, resuming them.
+func (dbp *Process) detach() error {
+	for _, th := range dbp.Threads {
+		if th.ID == dbp.Pid {
+			continue
+		}
+		if err := PtraceDetach(th.ID, 0); err != nil && err != sys.ESRCH {
+			return fmt.Errorf("could not detach from thread %d: %v", th.ID, err)
+		}
+	}
+	return PtraceDetach(dbp.Pid, 0)
+}
+
 func (dbp *Process) requestManualStop() (err error) {
 	return sys.Kill(dbp.Pid, sys.SIGTRAP)
 }
@@ -150,6 +219,10 @@ func (dbp *Process) addThread(tid int, attach bool) (*Thread, error) {
 		dbp: dbp,
 		os:  new(OSSpecificDetails),
 	}
+	// debug registers are not inherited by cloned threads
+	if err := dbp.Threads[tid].writeWatchpoints(); err != nil {
+		return nil, err
+	}
 	if dbp.CurrentThread == nil {
 		dbp.SwitchThread(tid)
 	}
@@ -270,6 +343,55 @@ func (dbp *Process) parseDebugLineInfo(exe *elf.File, wg *sync.WaitGroup) {
 	}
 }
 
+func (dbp *Process) loadBuildInfo(exe *elf.File, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if sec := exe.Section(".go.buildinfo"); sec != nil {
+		if data, err := sec.Data(); err == nil {
+			if off := findBuildInfo(data); off >= 0 {
+				dbp.buildInfoData = data[off:]
+			}
+		}
+	}
+
+	if sec := exe.Section(".note.go.buildid"); sec != nil {
+		if note, err := sec.Data(); err == nil {
+			dbp.buildID = parseBuildIDNote(note)
+		}
+	}
+	if dbp.buildID == "" {
+		if sec := exe.Section(".text"); sec != nil {
+			if text, err := sec.Data(); err == nil {
+				dbp.buildID = findBuildID(text)
+			}
+		}
+	}
+}
+
+func (dbp *Process) loadDataSymbols(exe *elf.File, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	syms, err := exe.Symbols()
+	if err != nil {
+		return
+	}
+	dataSymbols := make(map[string]dataSymbol)
+	cgo := false
+	for _, sym := range syms {
+		if sym.Name == "_cgo_init" {
+			cgo = true
+		}
+		if elf.ST_TYPE(sym.Info) == elf.STT_OBJECT && sym.Value != 0 {
+			dataSymbols[sym.Name] = dataSymbol{addr: sym.Value, size: sym.Size}
+		}
+	}
+	// the symbols of Go executables are all Go variables, found through
+	// their debug information
+	if cgo {
+		dbp.dataSymbols = dataSymbols
+	}
+}
+
 func (dbp *Process) trapWait(pid int) (*Thread, error) {
 	for {
 		wpid, status, err := dbp.wait(pid, 0)
@@ -340,8 +462,18 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 			return th, nil
 		}
 		if th != nil {
-			// TODO(dp) alert user about unexpected signals here.
-			if err := th.resumeWithSig(int(status.StopSignal())); err != nil {
+			sig := int(status.StopSignal())
+			policy := dbp.SignalPolicy(sig)
+			if !policy.Pass {
+				sig = 0
+			}
+			if policy.Stop {
+				th.running = false
+				th.Signal = int(status.StopSignal())
+				th.pendingSignal = sig
+				return th, nil
+			}
+			if err := th.resumeWithSig(sig); err != nil {
 				if err == sys.ESRCH {
 					return nil, ProcessExitedError{Pid: dbp.Pid}
 				}
@@ -354,6 +486,11 @@ func (dbp *Process) trapWait(pid int) (*Thread, error) {
 func (dbp *Process) loadProcessInformation(wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	if dbp.core != nil {
+		dbp.os.comm = dbp.core.comm
+		return
+	}
+
 	comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", dbp.Pid))
 	if err == nil {
 		// removes newline character
@@ -459,9 +596,23 @@ func (dbp *Process) exitGuard(err error) error {
 }
 
 func (dbp *Process) resume() error {
+	return dbp.resumeExcept(nil)
+}
+
+// resumeExcept resumes all threads except the ones in held, which are left
+// stopped.
+func (dbp *Process) resumeExcept(held map[int]bool) error {
 	// all threads stopped over a breakpoint are made to step over it
 	for _, thread := range dbp.Threads {
+		if held[thread.ID] {
+			continue
+		}
 		if thread.CurrentBreakpoint != nil {
+			if thread.CurrentBreakpoint.IsWatchpoint() {
+				// the access to the watched memory has already been executed
+				thread.CurrentBreakpoint = nil
+				continue
+			}
 			if err := thread.StepInstruction(); err != nil {
 				return err
 			}
@@ -470,6 +621,9 @@ func (dbp *Process) resume() error {
 	}
 	// everything is resumed
 	for _, thread := range dbp.Threads {
+		if held[thread.ID] {
+			continue
+		}
 		if err := thread.resume(); err != nil && err != sys.ESRCH {
 			return err
 		}
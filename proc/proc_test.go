@@ -2,6 +2,7 @@ package proc
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"go/ast"
 	"go/constant"
@@ -18,6 +19,7 @@ import (
 	"time"
 
 	protest "github.com/derekparker/delve/proc/test"
+	"golang.org/x/debug/dwarf"
 )
 
 var normalLoadConfig = LoadConfig{true, 1, 64, 64, -1}
@@ -697,6 +699,209 @@ func TestCGONext(t *testing.T) {
 	})
 }
 
+func TestCGOGlobals(t *testing.T) {
+	// On OSX with Go < 1.5 CGO is not supported due to: https://github.com/golang/go/issues/8973
+	if runtime.GOOS == "darwin" && strings.Contains(runtime.Version(), "1.4") {
+		return
+	}
+
+	withTestProcess("cgotest", t, func(p *Process, fixture protest.Fixture) {
+		_, err := setFunctionBreakpoint(p, "main.main")
+		assertNoError(err, t, "setFunctionBreakpoint()")
+		assertNoError(p.Continue(), t, "Continue()")
+
+		for _, expr := range []string{"C.cglobal"} {
+			v, err := evalVariable(p, expr)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", expr))
+			if v.Kind == reflect.Array {
+				// no debug information for C code, the variable is read from the symbol table
+				if v.Len != 4 || len(v.Children) != 4 {
+					t.Fatalf("%s: wrong size %d", expr, v.Len)
+				}
+				continue
+			}
+			if n, _ := constant.Int64Val(v.Value); n != 42 {
+				t.Fatalf("%s: expected 42 got %v", expr, v.Value)
+			}
+		}
+
+		for _, expr := range []string{"C.nonexistent", "cglobal"} {
+			if _, err := evalVariable(p, expr); err == nil {
+				t.Fatalf("expected error evaluating %s", expr)
+			}
+		}
+	})
+}
+
+func TestSyncMapVariable(t *testing.T) {
+	withTestProcess("syncmap", t, func(p *Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		sm, err := evalVariable(p, "sm")
+		assertNoError(err, t, "EvalVariable(sm)")
+		if sm.Kind != reflect.Map || sm.Len != 2 || len(sm.Children) != 4 {
+			t.Fatalf("wrong sync.Map kind %s len %d children %d", sm.Kind, sm.Len, len(sm.Children))
+		}
+		found := map[string]int64{}
+		for i := 0; i < len(sm.Children); i += 2 {
+			key, val := &sm.Children[i], &sm.Children[i+1]
+			if key.Kind != reflect.Interface || val.Kind != reflect.Interface {
+				t.Fatalf("wrong kinds %s %s", key.Kind, val.Kind)
+			}
+			n, _ := constant.Int64Val(val.Children[0].Value)
+			found[constant.StringVal(key.Children[0].Value)] = n
+		}
+		if len(found) != 2 || found["b"] != 2 || found["c"] != 3 {
+			t.Fatalf("wrong sync.Map contents %v", found)
+		}
+
+		empty, err := evalVariable(p, "empty")
+		assertNoError(err, t, "EvalVariable(empty)")
+		if empty.Kind != reflect.Map || empty.Len != 0 {
+			t.Fatalf("wrong empty sync.Map kind %s len %d", empty.Kind, empty.Len)
+		}
+	})
+}
+
+func TestAtomicVariables(t *testing.T) {
+	withTestProcess("atomicvalues", t, func(p *Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		for _, tc := range []struct {
+			expr  string
+			kind  reflect.Kind
+			value string
+		}{
+			{"c.hits", reflect.Int64, "42"},
+			{"c.flag", reflect.Bool, "true"},
+			{"c.count", reflect.Uint32, "7"},
+			{"raw", reflect.Int64, "5"},
+		} {
+			v, err := evalVariable(p, tc.expr)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			if v.Kind != tc.kind || v.Value == nil || v.Value.String() != tc.value {
+				t.Fatalf("%s: wrong kind %s or value %v", tc.expr, v.Kind, v.Value)
+			}
+		}
+
+		last, err := evalVariable(p, "c.last")
+		assertNoError(err, t, "EvalVariable(c.last)")
+		if last.Kind != reflect.Ptr || len(last.Children) != 1 || constant.StringVal(last.Children[0].Value) != "hello" {
+			t.Fatalf("wrong atomic.Pointer %s %v", last.Kind, last.Children)
+		}
+
+		val, err := evalVariable(p, "c.val")
+		assertNoError(err, t, "EvalVariable(c.val)")
+		if val.Kind != reflect.Interface || len(val.Children) != 1 || val.Children[0].Value.String() != "3" {
+			t.Fatalf("wrong atomic.Value %s %v", val.Kind, val.Children)
+		}
+	})
+}
+
+func TestGoroutinePseudoVariables(t *testing.T) {
+	withTestProcess("defercall", t, func(p *Process, fixture protest.Fixture) {
+		bp, err := setFunctionBreakpoint(p, "main.sampleFunction")
+		assertNoError(err, t, "setFunctionBreakpoint()")
+		assertNoError(p.Continue(), t, "Continue()")
+
+		v, err := evalVariable(p, "$panicking")
+		assertNoError(err, t, "EvalVariable($panicking)")
+		if constant.BoolVal(v.Value) {
+			t.Fatal("$panicking true before panic")
+		}
+		v, err = evalVariable(p, "$ndefers")
+		assertNoError(err, t, "EvalVariable($ndefers)")
+		if n, _ := constant.Int64Val(v.Value); n != 1 {
+			t.Fatalf("wrong number of defers %d", n)
+		}
+
+		bp.Cond, err = ParseExpr("$panicking")
+		assertNoError(err, t, "ParseExpr()")
+		assertNoError(p.Continue(), t, "Continue()")
+
+		frames, err := p.SelectedGoroutine.Stacktrace(10)
+		assertNoError(err, t, "Stacktrace()")
+		panicking := false
+		for _, frame := range frames {
+			if frame.Call.Fn != nil && frame.Call.Fn.Name == "runtime.gopanic" {
+				panicking = true
+			}
+		}
+		if !panicking {
+			t.Fatal("breakpoint with condition $panicking reached outside of a panic")
+		}
+		v, err = evalVariable(p, "$panicking")
+		assertNoError(err, t, "EvalVariable($panicking)")
+		if !constant.BoolVal(v.Value) {
+			t.Fatal("$panicking false during panic")
+		}
+	})
+}
+
+func TestParseExprRegisters(t *testing.T) {
+	for _, expr := range []string{"$sp != 0", "*(*uint64)($sp + 8)", "\"$sp\""} {
+		t0, err := ParseExpr(expr)
+		assertNoError(err, t, fmt.Sprintf("ParseExpr(%s)", expr))
+		if s := ExprString(t0); s != expr {
+			t.Fatalf("%s: wrong source form %q", expr, s)
+		}
+	}
+}
+
+func TestRegisterPseudoVariables(t *testing.T) {
+	withTestProcess("testnextprog", t, func(p *Process, fixture protest.Fixture) {
+		_, err := setFunctionBreakpoint(p, "main.helloworld")
+		assertNoError(err, t, "setFunctionBreakpoint()")
+		assertNoError(p.Continue(), t, "Continue()")
+
+		regs, err := p.CurrentThread.Registers()
+		assertNoError(err, t, "Registers()")
+
+		for _, tc := range []struct {
+			expr  string
+			value uint64
+		}{
+			{"$rsp", regs.SP()},
+			{"$sp", regs.SP()},
+			{"$RIP", regs.PC()},
+			{"$pc", regs.PC()},
+			{"$sp+8", regs.SP() + 8},
+		} {
+			v, err := evalVariable(p, tc.expr)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			if n, _ := constant.Uint64Val(v.Value); n != tc.value {
+				t.Fatalf("%s: expected %#x got %v", tc.expr, tc.value, v.Value)
+			}
+		}
+
+		mem, err := p.CurrentThread.readMemory(uintptr(regs.SP()), 8)
+		assertNoError(err, t, "readMemory()")
+		v, err := evalVariable(p, "*(*uint64)($sp)")
+		assertNoError(err, t, "EvalVariable(*(*uint64)($sp))")
+		if n, _ := constant.Uint64Val(v.Value); n != binary.LittleEndian.Uint64(mem) {
+			t.Fatalf("*(*uint64)($sp): expected %#x got %v", binary.LittleEndian.Uint64(mem), v.Value)
+		}
+
+		if _, err := evalVariable(p, "$nonexistent"); err == nil {
+			t.Fatal("expected error evaluating $nonexistent")
+		}
+
+		var flags uint64
+		for _, reg := range regs.Slice() {
+			if IsFlagsRegister(reg.Name) {
+				flags = reg.Value
+			}
+		}
+		for name, set := range DecodeFlags(flags) {
+			v, err := evalVariable(p, "$"+strings.ToLower(name))
+			assertNoError(err, t, fmt.Sprintf("EvalVariable($%s)", name))
+			if constant.BoolVal(v.Value) != set {
+				t.Fatalf("$%s: expected %v got %v", name, set, v.Value)
+			}
+		}
+	})
+}
+
 type loc struct {
 	line int
 	fn   string
@@ -2351,3 +2556,116 @@ func TestNegativeIntEvaluation(t *testing.T) {
 		}
 	})
 }
+
+func TestGoroutineWaitReason(t *testing.T) {
+	go113 := append(append([]string{}, waitReasonStrings[:13]...), "GC scavenge wait")
+	go113 = append(go113, waitReasonStrings[13:]...)
+	for _, tc := range []struct {
+		v       *Variable
+		reasons []string
+		out     string
+	}{
+		{&Variable{Value: constant.MakeString("select")}, nil, "select"},
+		{&Variable{Value: constant.MakeInt64(13)}, waitReasonStrings[:], "chan receive"},
+		{&Variable{Value: constant.MakeInt64(13)}, go113, "GC scavenge wait"},
+		{&Variable{Value: constant.MakeInt64(13)}, nil, "13"},
+		{&Variable{Value: constant.MakeInt64(200)}, waitReasonStrings[:], "200"},
+		{&Variable{}, waitReasonStrings[:], ""},
+		{nil, waitReasonStrings[:], ""},
+	} {
+		if out := goroutineWaitReason(tc.v, tc.reasons); out != tc.out {
+			t.Errorf("wait reason mismatch, expected %q got %q", tc.out, out)
+		}
+	}
+}
+
+// corruptMemory is a memoryReadWriter that contains a slice header at
+// address 0x1000 and zeroes everywhere else.
+type corruptMemory struct {
+	header []byte
+}
+
+func (mem *corruptMemory) readMemory(addr uintptr, size int) ([]byte, error) {
+	if size > 64*maxLoadLen {
+		return nil, fmt.Errorf("read of %d bytes", size)
+	}
+	buf := make([]byte, size)
+	if addr >= 0x1000 && addr < 0x1000+uintptr(len(mem.header)) {
+		copy(buf, mem.header[addr-0x1000:])
+	}
+	return buf, nil
+}
+
+func (mem *corruptMemory) writeMemory(addr uintptr, data []byte) (int, error) {
+	return 0, fmt.Errorf("not implemented")
+}
+
+func TestUnboundedLoadCorruptHeader(t *testing.T) {
+	// a string or slice header containing garbage must not make us
+	// allocate or read its whole length when loading without limits
+	unbounded := LoadConfig{false, 1, -1, -1, -1}
+	mem := &corruptMemory{}
+
+	s, err := readStringValue(mem, 0x2000, 1<<50, unbounded)
+	if err != nil {
+		t.Fatalf("readStringValue: %v", err)
+	}
+	if len(s) != maxLoadLen {
+		t.Fatalf("wrong string length %d", len(s))
+	}
+	if _, err := readStringValue(mem, 0x2000, -1, unbounded); err == nil {
+		t.Fatalf("no error for negative string length")
+	}
+
+	inttyp := &dwarf.IntType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: 8, Name: "int"}, BitSize: 64}}
+	bytetyp := &dwarf.UintType{BasicType: dwarf.BasicType{CommonType: dwarf.CommonType{ByteSize: 1, Name: "uint8"}, BitSize: 8}}
+	slicetyp := &dwarf.SliceType{
+		StructType: dwarf.StructType{
+			CommonType: dwarf.CommonType{ByteSize: 24},
+			StructName: "[]uint8",
+			Kind:       "struct",
+			Field: []*dwarf.StructField{
+				{Name: "array", Type: &dwarf.PtrType{CommonType: dwarf.CommonType{ByteSize: 8}, Type: bytetyp}, ByteOffset: 0},
+				{Name: "len", Type: inttyp, ByteOffset: 8},
+				{Name: "cap", Type: inttyp, ByteOffset: 16},
+			},
+		},
+		ElemType: bytetyp,
+	}
+	mem.header = make([]byte, 24)
+	binary.LittleEndian.PutUint64(mem.header[0:], 0x2000)
+	binary.LittleEndian.PutUint64(mem.header[8:], 1<<50)
+	binary.LittleEndian.PutUint64(mem.header[16:], 1<<50)
+
+	v := newVariable("s", 0x1000, slicetyp, nil, mem)
+	v.loadValue(unbounded)
+	if v.Unreadable != nil {
+		t.Fatalf("unreadable slice: %v", v.Unreadable)
+	}
+	if v.Len != 1<<50 || len(v.Children) != maxLoadLen {
+		t.Fatalf("slice not truncated: len %d, %d children", v.Len, len(v.Children))
+	}
+}
+
+func TestBreakpointActiveRunTime(t *testing.T) {
+	// only the time spent running counts, not the time spent stopped
+	thread := &Thread{dbp: &Process{runTime: 2 * time.Second}}
+	for _, tc := range []struct {
+		after, until time.Duration
+		active       bool
+	}{
+		{time.Second, 0, true},
+		{3 * time.Second, 0, false},
+		{0, time.Second, false},
+		{time.Second, 3 * time.Second, true},
+	} {
+		bp := &Breakpoint{ActiveAfter: tc.after, ActiveUntil: tc.until}
+		active, err := bp.checkCondition(thread)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if active != tc.active {
+			t.Errorf("ActiveAfter %v ActiveUntil %v: expected active %v", tc.after, tc.until, tc.active)
+		}
+	}
+}
@@ -3,6 +3,12 @@
 package proc
 
 import (
+	"bytes"
+	"fmt"
+	"go/constant"
+	"os"
+	"os/exec"
+	"runtime"
 	"syscall"
 	"testing"
 	"time"
@@ -29,3 +35,59 @@ func TestIssue419(t *testing.T) {
 		}
 	})
 }
+
+func TestLaunchPTY(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminals only supported on linux")
+	}
+	fixture := protest.BuildFixture("ptyprog")
+	p, err := LaunchPTY([]string{fixture.Path}, ".")
+	assertNoError(err, t, "LaunchPTY()")
+	defer func() {
+		p.Halt()
+		p.Kill()
+	}()
+
+	assertNoError(p.Continue(), t, "Continue()")
+	v, err := evalVariable(p, "stdoutTTY")
+	assertNoError(err, t, "EvalVariable(stdoutTTY)")
+	if !constant.BoolVal(v.Value) {
+		t.Fatal("standard output of the process is not a terminal")
+	}
+}
+
+func TestDetachRestoresInstructions(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("reading the memory of a process without ptrace is only supported on linux")
+	}
+	fixture := protest.BuildFixture("loopprog")
+	cmd := exec.Command(fixture.Path)
+	assertNoError(cmd.Start(), t, "starting fixture")
+	defer cmd.Process.Kill()
+	// give the fixture time to reach its loop
+	time.Sleep(500 * time.Millisecond)
+
+	p, err := Attach(cmd.Process.Pid)
+	assertNoError(err, t, "Attach()")
+	bp := setFileBreakpoint(p, t, fixture, 8)
+	original := append([]byte(nil), bp.OriginalData...)
+	assertNoError(p.Detach(false), t, "Detach(false)")
+
+	mem, err := os.Open(fmt.Sprintf("/proc/%d/mem", cmd.Process.Pid))
+	assertNoError(err, t, "opening process memory")
+	defer mem.Close()
+	data := make([]byte, len(original))
+	_, err = mem.ReadAt(data, int64(bp.Addr))
+	assertNoError(err, t, "reading process memory")
+	if !bytes.Equal(data, original) {
+		t.Fatalf("instructions at %#x not restored: %x, expected %x", bp.Addr, data, original)
+	}
+
+	// the loop executes the instructions of the breakpoint, the process
+	// would be killed by a SIGTRAP if they had not been restored.
+	time.Sleep(500 * time.Millisecond)
+	var ws syscall.WaitStatus
+	if wpid, _ := syscall.Wait4(cmd.Process.Pid, &ws, syscall.WNOHANG, nil); wpid == cmd.Process.Pid {
+		t.Fatalf("process is not running after Detach(false): %v", ws)
+	}
+}
@@ -19,12 +19,31 @@ import (
 	"golang.org/x/debug/dwarf"
 )
 
+// signalsSupported is true if SetSignalPolicy and DeliverSignal are
+// supported, there are no signals on windows.
+const signalsSupported = false
+
 // OSProcessDetails holds Windows specific information.
 type OSProcessDetails struct {
 	hProcess    syscall.Handle
 	breakThread int
 }
 
+// LaunchPTY is not supported on windows.
+func LaunchPTY(cmd []string, wd string) (*Process, error) {
+	return nil, errors.New("launching a process with a pseudo-terminal is not supported on windows")
+}
+
+// LaunchWithOptions is not supported on windows.
+func LaunchWithOptions(cmd []string, wd string, opts LaunchOptions) (*Process, error) {
+	return nil, errors.New("launching a process with options is not supported on windows")
+}
+
+// OpenCore is not supported on windows.
+func OpenCore(corePath, exePath string) (*Process, error) {
+	return nil, errors.New("core files are not supported on windows")
+}
+
 // Launch creates and begins debugging a new process.
 func Launch(cmd []string, wd string) (*Process, error) {
 	argv0Go, err := filepath.Abs(cmd[0])
@@ -220,6 +239,10 @@ func (dbp *Process) Kill() error {
 	return nil
 }
 
+func (dbp *Process) detach() error {
+	return PtraceDetach(dbp.Pid, 0)
+}
+
 func (dbp *Process) requestManualStop() error {
 	return _DebugBreakProcess(dbp.os.hProcess)
 }
@@ -390,6 +413,31 @@ func (dbp *Process) parseDebugLineInfo(exe *pe.File, wg *sync.WaitGroup) {
 
 var UnsupportedArchErr = errors.New("unsupported architecture of windows/386 - only windows/amd64 is supported")
 
+func (dbp *Process) loadBuildInfo(exe *pe.File, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	// The build information is stored at the start of the .data section.
+	if sec := exe.Section(".data"); sec != nil {
+		if data, err := sec.Data(); err == nil {
+			if off := findBuildInfo(data); off >= 0 {
+				dbp.buildInfoData = data[off:]
+			}
+		}
+	}
+
+	if sec := exe.Section(".text"); sec != nil {
+		if text, err := sec.Data(); err == nil {
+			dbp.buildID = findBuildID(text)
+		}
+	}
+}
+
+func (dbp *Process) loadDataSymbols(exe *pe.File, wg *sync.WaitGroup) {
+	// COFF symbols do not record their size, C globals without debug
+	// information are not supported.
+	wg.Done()
+}
+
 func (dbp *Process) findExecutable(path string) (*pe.File, error) {
 	peFile, err := openExecutablePath(path)
 	if err != nil {
@@ -613,6 +661,15 @@ func (dbp *Process) exitGuard(err error) error {
 	return err
 }
 
+// resumeExcept resumes all threads except the ones in held, it is only
+// used by function calls, which are not supported on this platform.
+func (dbp *Process) resumeExcept(held map[int]bool) error {
+	if len(held) > 0 {
+		return errFunctionCallsUnsupported
+	}
+	return dbp.resume()
+}
+
 func (dbp *Process) resume() error {
 	for _, thread := range dbp.Threads {
 		if thread.CurrentBreakpoint != nil {
@@ -49,3 +49,24 @@ func PtracePeekUser(tid int, off uintptr) (uintptr, error) {
 	}
 	return val, nil
 }
+
+// fpRegsSize is the size of struct user_fpregs_struct.
+const fpRegsSize = 512
+
+// PtraceGetFpRegs executes ptrace PTRACE_GETFPREGS.
+func PtraceGetFpRegs(tid int, fpregs *[fpRegsSize]byte) error {
+	_, _, err := syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_GETFPREGS, uintptr(tid), 0, uintptr(unsafe.Pointer(fpregs)), 0, 0)
+	if err != syscall.Errno(0) {
+		return err
+	}
+	return nil
+}
+
+// PtraceSetFpRegs executes ptrace PTRACE_SETFPREGS.
+func PtraceSetFpRegs(tid int, fpregs *[fpRegsSize]byte) error {
+	_, _, err := syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETFPREGS, uintptr(tid), 0, uintptr(unsafe.Pointer(fpregs)), 0, 0)
+	if err != syscall.Errno(0) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package proc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+
+	sys "golang.org/x/sys/unix"
+)
+
+// openPTY allocates a pseudo-terminal and returns its master and slave
+// sides.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var unlock int32
+	if err := ptyIoctl(master, sys.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("could not unlock pseudo-terminal: %v", err)
+	}
+	var n uint32
+	if err := ptyIoctl(master, sys.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		master.Close()
+		return nil, nil, fmt.Errorf("could not get pseudo-terminal number: %v", err)
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+func ptyIoctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := sys.Syscall(sys.SYS_IOCTL, f.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// copyPTYOutput copies everything written to the pseudo-terminal master
+// to out, until the last process using the slave side exits.
+func copyPTYOutput(out io.Writer, master *os.File) {
+	io.Copy(out, master)
+	master.Close()
+}
@@ -0,0 +1,95 @@
+package proc
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Reference is a pointer sized word of memory, in a goroutine stack or in
+// a package variable, containing a given address.
+type Reference struct {
+	// Addr is the address of the word containing the pointer.
+	Addr uint64
+	// GoroutineID is the ID of the goroutine whose stack contains the
+	// pointer, or 0 if the pointer is inside a package variable.
+	GoroutineID int
+	// Symbol is the package variable containing the pointer followed by
+	// the offset from its start, it is empty for stack references.
+	Symbol string
+}
+
+// maxRootChunk is the maximum size of memory read at once while scanning
+// GC roots.
+const maxRootChunk = 64 * 1024
+
+// FindDirectRootReferences returns all the words of the GC roots, the
+// used portion of goroutine stacks and package variables, that contain
+// exactly addr. Pointers stored in heap objects are not followed and
+// pointers to the inside of an object are not considered, an object with
+// no direct root references can still be reachable.
+func (dbp *Process) FindDirectRootReferences(addr uint64) ([]Reference, error) {
+	if dbp.exited {
+		return nil, &ProcessExitedError{}
+	}
+	var refs []Reference
+
+	gs, err := dbp.GoroutinesInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gs {
+		_, hi, used, err := g.StackUsage()
+		if err != nil {
+			continue
+		}
+		err = dbp.scanForPointer(hi-used, hi, addr, func(ptrAddr uint64) {
+			refs = append(refs, Reference{Addr: ptrAddr, GoroutineID: g.ID})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not read stack of goroutine %d: %v", g.ID, err)
+		}
+	}
+
+	for _, global := range dbp.packageVarRanges() {
+		global := global
+		err := dbp.scanForPointer(global.addr, global.addr+global.size, addr, func(ptrAddr uint64) {
+			refs = append(refs, Reference{Addr: ptrAddr, Symbol: fmt.Sprintf("%s+%#x", global.name, ptrAddr-global.addr)})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %v", global.name, err)
+		}
+	}
+
+	return refs, nil
+}
+
+// scanForPointer calls found with the address of every pointer aligned
+// word between start and end that contains addr.
+func (dbp *Process) scanForPointer(start, end, addr uint64, found func(uint64)) error {
+	ptrSize := uint64(dbp.arch.PtrSize())
+	start = (start + ptrSize - 1) &^ (ptrSize - 1)
+	for start+ptrSize <= end {
+		size := end - start
+		if size > maxRootChunk {
+			size = maxRootChunk
+		}
+		size &^= ptrSize - 1
+		mem, err := dbp.CurrentThread.readMemory(uintptr(start), int(size))
+		if err != nil {
+			return err
+		}
+		for off := uint64(0); off+ptrSize <= uint64(len(mem)); off += ptrSize {
+			var val uint64
+			if ptrSize == 4 {
+				val = uint64(binary.LittleEndian.Uint32(mem[off:]))
+			} else {
+				val = binary.LittleEndian.Uint64(mem[off:])
+			}
+			if val == addr {
+				found(start + off)
+			}
+		}
+		start += size
+	}
+	return nil
+}
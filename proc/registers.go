@@ -1,6 +1,9 @@
 package proc
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Registers is an interface for a generic register type. The
 // interface encapsulates the generic values / actions
@@ -13,16 +16,81 @@ type Registers interface {
 	TLS() uint64
 	Get(int) (uint64, error)
 	SetPC(*Thread, uint64) error
+	Slice() []Register
 	String() string
 }
 
+// Register is the name and value of a single CPU register.
+type Register struct {
+	Name  string
+	Value uint64
+}
+
 var UnknownRegisterError = errors.New("unknown register")
 
+var errSetRegisterUnsupported = errors.New("writing registers is not supported on this platform")
+
+// ReadOnlyRegisterError is returned when trying to write a register that
+// can not be changed by the debugger.
+type ReadOnlyRegisterError struct {
+	Name string
+}
+
+func (err ReadOnlyRegisterError) Error() string {
+	return fmt.Sprintf("register %s is read-only", err.Name)
+}
+
+// CPUFlag is a bit of the flags register.
+type CPUFlag struct {
+	Name string
+	Bit  uint
+}
+
+// CPUFlags are the status and direction flags of the flags register, in
+// the order of their bits.
+var CPUFlags = []CPUFlag{
+	{"CF", 0},
+	{"PF", 2},
+	{"AF", 4},
+	{"ZF", 6},
+	{"SF", 7},
+	{"DF", 10},
+	{"OF", 11},
+}
+
+// IsFlagsRegister returns true if name is the name of the flags register
+// returned by Registers.Slice.
+func IsFlagsRegister(name string) bool {
+	return name == "Eflags" || name == "Rflags"
+}
+
+// DecodeFlags returns the value of each of CPUFlags in the value of the
+// flags register.
+func DecodeFlags(flags uint64) map[string]bool {
+	r := make(map[string]bool, len(CPUFlags))
+	for _, flag := range CPUFlags {
+		r[flag.Name] = flags&(1<<flag.Bit) != 0
+	}
+	return r
+}
+
 // Registers obtains register values from the debugged process.
 func (t *Thread) Registers() (Registers, error) {
 	return registers(t)
 }
 
+// SetRegister sets the register of t called name, as returned by
+// Registers.Slice, to value. The name is not case sensitive.
+func (t *Thread) SetRegister(name string, value uint64) error {
+	if t.dbp.exited {
+		return &ProcessExitedError{}
+	}
+	if t.dbp.core != nil {
+		return CoreReadOnlyErr
+	}
+	return t.setRegister(name, value)
+}
+
 // PC returns the current PC for this thread.
 func (t *Thread) PC() (uint64, error) {
 	regs, err := t.Registers()
@@ -34,12 +34,9 @@ type Regs struct {
 	gsBase uint64
 }
 
-func (r *Regs) String() string {
-	var buf bytes.Buffer
-	var regs = []struct {
-		k string
-		v uint64
-	}{
+// Slice returns the registers as a list of name/value pairs.
+func (r *Regs) Slice() []Register {
+	return []Register{
 		{"Rip", r.rip},
 		{"Rsp", r.rsp},
 		{"Rax", r.rax},
@@ -63,8 +60,12 @@ func (r *Regs) String() string {
 		{"Gs", r.gs},
 		{"Gs_base", r.gsBase},
 	}
-	for _, reg := range regs {
-		fmt.Fprintf(&buf, "%8s = %0#16x\n", reg.k, reg.v)
+}
+
+func (r *Regs) String() string {
+	var buf bytes.Buffer
+	for _, reg := range r.Slice() {
+		fmt.Fprintf(&buf, "%8s = %0#16x\n", reg.Name, reg.Value)
 	}
 	return buf.String()
 }
@@ -2,6 +2,7 @@ package proc
 
 import "fmt"
 import "bytes"
+import "strings"
 import sys "golang.org/x/sys/unix"
 import "rsc.io/x86/x86asm"
 
@@ -10,12 +11,9 @@ type Regs struct {
 	regs *sys.PtraceRegs
 }
 
-func (r *Regs) String() string {
-	var buf bytes.Buffer
-	var regs = []struct {
-		k string
-		v uint64
-	}{
+// Slice returns the registers as a list of name/value pairs.
+func (r *Regs) Slice() []Register {
+	return []Register{
 		{"Rip", r.regs.Rip},
 		{"Rsp", r.regs.Rsp},
 		{"Rax", r.regs.Rax},
@@ -44,8 +42,12 @@ func (r *Regs) String() string {
 		{"Fs", r.regs.Fs},
 		{"Gs", r.regs.Gs},
 	}
-	for _, reg := range regs {
-		fmt.Fprintf(&buf, "%8s = %0#16x\n", reg.k, reg.v)
+}
+
+func (r *Regs) String() string {
+	var buf bytes.Buffer
+	for _, reg := range r.Slice() {
+		fmt.Fprintf(&buf, "%8s = %0#16x\n", reg.Name, reg.Value)
 	}
 	return buf.String()
 }
@@ -78,6 +80,58 @@ func (r *Regs) SetPC(thread *Thread, pc uint64) (err error) {
 	return
 }
 
+// set changes the register called name to value, segment registers and
+// the segment bases can not be changed.
+func (r *Regs) set(name string, value uint64) error {
+	var p *uint64
+	switch strings.ToLower(name) {
+	case "rip":
+		p = &r.regs.Rip
+	case "rsp":
+		p = &r.regs.Rsp
+	case "rax":
+		p = &r.regs.Rax
+	case "rbx":
+		p = &r.regs.Rbx
+	case "rcx":
+		p = &r.regs.Rcx
+	case "rdx":
+		p = &r.regs.Rdx
+	case "rdi":
+		p = &r.regs.Rdi
+	case "rsi":
+		p = &r.regs.Rsi
+	case "rbp":
+		p = &r.regs.Rbp
+	case "r8":
+		p = &r.regs.R8
+	case "r9":
+		p = &r.regs.R9
+	case "r10":
+		p = &r.regs.R10
+	case "r11":
+		p = &r.regs.R11
+	case "r12":
+		p = &r.regs.R12
+	case "r13":
+		p = &r.regs.R13
+	case "r14":
+		p = &r.regs.R14
+	case "r15":
+		p = &r.regs.R15
+	case "orig_rax":
+		p = &r.regs.Orig_rax
+	case "eflags":
+		p = &r.regs.Eflags
+	case "cs", "ss", "ds", "es", "fs", "gs", "fs_base", "gs_base":
+		return ReadOnlyRegisterError{name}
+	default:
+		return UnknownRegisterError
+	}
+	*p = value
+	return nil
+}
+
 func (r *Regs) Get(n int) (uint64, error) {
 	reg := x86asm.Reg(n)
 	const (
@@ -236,6 +290,9 @@ func (r *Regs) Get(n int) (uint64, error) {
 }
 
 func registers(thread *Thread) (Registers, error) {
+	if thread.dbp.core != nil {
+		return thread.dbp.core.threads[thread.ID], nil
+	}
 	var (
 		regs sys.PtraceRegs
 		err  error
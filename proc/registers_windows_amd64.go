@@ -33,12 +33,9 @@ type Regs struct {
 	tls    uint64
 }
 
-func (r *Regs) String() string {
-	var buf bytes.Buffer
-	var regs = []struct {
-		k string
-		v uint64
-	}{
+// Slice returns the registers as a list of name/value pairs.
+func (r *Regs) Slice() []Register {
+	return []Register{
 		{"Rip", r.rip},
 		{"Rsp", r.rsp},
 		{"Rax", r.rax},
@@ -62,8 +59,12 @@ func (r *Regs) String() string {
 		{"Gs", r.gs},
 		{"TLS", r.tls},
 	}
-	for _, reg := range regs {
-		fmt.Fprintf(&buf, "%8s = %0#16x\n", reg.k, reg.v)
+}
+
+func (r *Regs) String() string {
+	var buf bytes.Buffer
+	for _, reg := range r.Slice() {
+		fmt.Fprintf(&buf, "%8s = %0#16x\n", reg.Name, reg.Value)
 	}
 	return buf.String()
 }
@@ -0,0 +1,95 @@
+package proc
+
+import (
+	"fmt"
+	"go/constant"
+	"reflect"
+)
+
+// RuntimeConfig is the configuration of the Go runtime of the target
+// process, as read from the runtime's own variables.
+type RuntimeConfig struct {
+	// GOMAXPROCS is the number of Ps in use by the scheduler.
+	GOMAXPROCS int64
+	// GCPercent is the value of GOGC, a negative value means the garbage
+	// collector is disabled.
+	GCPercent int64
+	// Debug is the list of GODEBUG settings as parsed by the runtime.
+	Debug []DebugVar
+}
+
+// DebugVar is the name and value of a GODEBUG setting.
+type DebugVar struct {
+	Name  string
+	Value int64
+}
+
+// RuntimeConfig reads the configuration of the Go runtime from the
+// variables of the stopped process.
+func (dbp *Process) RuntimeConfig() (*RuntimeConfig, error) {
+	if dbp.exited {
+		return nil, &ProcessExitedError{}
+	}
+	scope := &EvalScope{Thread: dbp.CurrentThread, PC: 0, CFA: 0}
+	r := &RuntimeConfig{}
+
+	gomaxprocs, err := scope.packageVarAddr("runtime.gomaxprocs")
+	if err != nil {
+		return nil, err
+	}
+	if r.GOMAXPROCS, err = runtimeIntValue(gomaxprocs); err != nil {
+		return nil, fmt.Errorf("could not read runtime.gomaxprocs: %v", err)
+	}
+
+	// Starting with go1.9 gcpercent is a field of gcController.
+	gcpercent, err := scope.packageVarAddr("runtime.gcpercent")
+	if err != nil {
+		var gcController *Variable
+		if gcController, err = scope.packageVarAddr("runtime.gcController"); err == nil {
+			gcpercent, err = gcController.structMember("gcPercent")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find GC percent: %v", err)
+	}
+	if r.GCPercent, err = runtimeIntValue(gcpercent); err != nil {
+		return nil, fmt.Errorf("could not read GC percent: %v", err)
+	}
+
+	if debug, err := scope.packageVarAddr("runtime.debug"); err == nil {
+		debug.loadValue(loadFullValue)
+		if debug.Unreadable == nil && debug.Kind == reflect.Struct {
+			for i := range debug.Children {
+				field := &debug.Children[i]
+				if n, err := runtimeIntValue(field); err == nil {
+					r.Debug = append(r.Debug, DebugVar{field.Name, n})
+				}
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// runtimeIntValue returns the value of v, which must be an integer or a
+// struct wrapping an integer in a field called value, like the types of
+// the runtime/internal/atomic package.
+func runtimeIntValue(v *Variable) (int64, error) {
+	v.loadValue(loadFullValue)
+	if v.Unreadable != nil {
+		return 0, v.Unreadable
+	}
+	if v.Kind == reflect.Struct {
+		for i := range v.Children {
+			if v.Children[i].Name == "value" {
+				return runtimeIntValue(&v.Children[i])
+			}
+		}
+		return 0, fmt.Errorf("%s is not an integer", v.TypeString())
+	}
+	if v.Value == nil || v.Value.Kind() != constant.Int {
+		return 0, fmt.Errorf("%s is not an integer", v.TypeString())
+	}
+	n, _ := constant.Int64Val(v.Value)
+	return n, nil
+}
@@ -0,0 +1,68 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+var errSignalsUnsupported = errors.New("signal handling is not supported on this platform")
+
+// SignalPolicy is how a signal received by the process is handled.
+type SignalPolicy struct {
+	// Stop stops the process when the signal is received.
+	Stop bool
+	// Pass delivers the signal to the process when it is resumed, if not
+	// set the signal is discarded.
+	Pass bool
+}
+
+// SetSignalPolicy sets how the signal sig is handled when it is received
+// by the process.
+// Signals without a policy are passed to the process without stopping it,
+// the Go runtime relies on this for SIGURG, used to preempt goroutines, and
+// for SIGSEGV, turned into a panic.
+func (dbp *Process) SetSignalPolicy(sig int, policy SignalPolicy) error {
+	if !signalsSupported {
+		return errSignalsUnsupported
+	}
+	if sig <= 0 {
+		return fmt.Errorf("invalid signal %d", sig)
+	}
+	if sig == int(syscall.SIGTRAP) {
+		return errors.New("SIGTRAP is used by the debugger, its policy can not be changed")
+	}
+	if dbp.signalPolicies == nil {
+		dbp.signalPolicies = make(map[int]SignalPolicy)
+	}
+	dbp.signalPolicies[sig] = policy
+	return nil
+}
+
+// SignalPolicy returns how the signal sig is handled.
+func (dbp *Process) SignalPolicy(sig int) SignalPolicy {
+	if policy, ok := dbp.signalPolicies[sig]; ok {
+		return policy
+	}
+	return SignalPolicy{Stop: false, Pass: true}
+}
+
+// DeliverSignal sets the signal delivered to the current thread the next
+// time the process is resumed, replacing the signal the thread was stopped
+// by, if any. A sig of 0 discards the signal.
+func (dbp *Process) DeliverSignal(sig int) error {
+	if dbp.exited {
+		return &ProcessExitedError{}
+	}
+	if dbp.core != nil {
+		return CoreReadOnlyErr
+	}
+	if sig < 0 {
+		return fmt.Errorf("invalid signal %d", sig)
+	}
+	if !signalsSupported && sig != 0 {
+		return errSignalsUnsupported
+	}
+	dbp.CurrentThread.pendingSignal = sig
+	return nil
+}
@@ -29,8 +29,31 @@ type Stackframe struct {
 	FDE *frame.FrameDescriptionEntry
 	// Return address for this stack frame (as read from the stack frame itself).
 	Ret uint64
+	// Defers are the deferred calls registered by this frame that have
+	// not run yet, most recent first, see (*G).ReadDefers.
+	Defers []*Defer
 }
 
+// Defer is a deferred call pending on a goroutine.
+type Defer struct {
+	// DeferredPC is the entry point of the deferred function.
+	DeferredPC uint64
+	// DeferPC is the address the call to runtime.deferproc that registered
+	// the deferred call returns to.
+	DeferPC uint64
+	// SP is the stack pointer of the frame that registered the call.
+	SP uint64
+	// Unreadable is set if the deferred call could not be read.
+	Unreadable error
+
+	argsAddr uint64 // arguments of the deferred call, copied after the runtime._defer struct
+	argsSize int64
+}
+
+// maxDefersPerStack is the maximum number of deferred calls read by
+// ReadDefers.
+const maxDefersPerStack = 1024
+
 // Scope returns a new EvalScope using this frame.
 func (frame *Stackframe) Scope(thread *Thread) *EvalScope {
 	return &EvalScope{Thread: thread, PC: frame.Current.PC, CFA: frame.CFA}
@@ -74,6 +97,30 @@ func (g *G) stackIterator() (*stackIterator, error) {
 	return newStackIterator(g.dbp, g.PC, g.SP), nil
 }
 
+// StackUsage returns the bounds of the goroutine's stack and the number
+// of bytes of it currently in use.
+// If the goroutine is running on the system stack the stack pointer
+// saved when it last switched stacks is used.
+func (g *G) StackUsage() (lo, hi, used uint64, err error) {
+	if g.StackHi == 0 {
+		return 0, 0, 0, fmt.Errorf("could not read stack bounds of goroutine %d", g.ID)
+	}
+	sp := g.SP
+	if g.thread != nil {
+		regs, err := g.thread.Registers()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if tsp := regs.SP(); tsp >= g.StackLo && tsp <= g.StackHi {
+			sp = tsp
+		}
+	}
+	if sp < g.StackLo || sp > g.StackHi {
+		return g.StackLo, g.StackHi, 0, fmt.Errorf("stack pointer %#x of goroutine %d is outside its stack", sp, g.ID)
+	}
+	return g.StackLo, g.StackHi, g.StackHi - sp, nil
+}
+
 // Stacktrace returns the stack trace for a goroutine.
 // Note the locations in the array are return addresses not call addresses.
 func (g *G) Stacktrace(depth int) ([]Stackframe, error) {
@@ -209,3 +256,96 @@ func (it *stackIterator) stacktrace(depth int) ([]Stackframe, error) {
 	}
 	return frames, nil
 }
+
+// ReadDefers reads the chain of deferred calls of g and assigns each one
+// to the frame that registered it, frames must be a stacktrace of g.
+// Open-coded defers, used since go1.14, are not linked to the goroutine
+// unless it is panicking, those that are linked are marked Unreadable.
+func (g *G) ReadDefers(frames []Stackframe) error {
+	gvar, err := g.dbp.CurrentThread.newGVariable(uintptr(g.Addr), false)
+	if err != nil {
+		return err
+	}
+	d, err := gvar.structMember("_defer")
+	if err != nil {
+		return err
+	}
+	ptrSize := int64(g.dbp.arch.PtrSize())
+	addr, err := readUintRaw(d.mem, d.Addr, ptrSize)
+	if err != nil {
+		return err
+	}
+	typ, err := g.dbp.findType("runtime._defer")
+	if err != nil {
+		return err
+	}
+
+	i := 0
+	for n := 0; addr != 0 && n < maxDefersPerStack && i < len(frames); n++ {
+		dv := newVariable("", uintptr(addr), typ, g.dbp, g.dbp.CurrentThread)
+		d := &Defer{argsAddr: addr + uint64(typ.Size())}
+		if d.SP, err = deferField(dv, "sp", ptrSize); err != nil {
+			return err
+		}
+		if d.DeferPC, err = deferField(dv, "pc", ptrSize); err != nil {
+			return err
+		}
+		if fn, err := deferField(dv, "fn", ptrSize); err != nil {
+			d.Unreadable = err
+		} else if fn != 0 {
+			// fn is a *funcval, its first word is the entry point
+			d.DeferredPC, d.Unreadable = readUintRaw(g.dbp.CurrentThread, uintptr(fn), ptrSize)
+		}
+		if siz, err := deferField(dv, "siz", 4); err == nil {
+			d.argsSize = int64(int32(siz))
+		}
+		if open, err := deferField(dv, "openDefer", 1); err == nil && open != 0 {
+			d.Unreadable = errors.New("open-coded defer")
+		}
+
+		for i < len(frames) && d.SP >= uint64(frames[i].CFA) {
+			i++
+		}
+		if i < len(frames) {
+			frames[i].Defers = append(frames[i].Defers, d)
+		}
+
+		if addr, err = deferField(dv, "link", ptrSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deferField reads the field called name of a runtime._defer struct,
+// fields that do not exist in the runtime of the target return an error.
+func deferField(dv *Variable, name string, size int64) (uint64, error) {
+	f, err := dv.structMember(name)
+	if err != nil {
+		return 0, err
+	}
+	return readUintRaw(f.mem, f.Addr, size)
+}
+
+// Arguments returns the arguments of the deferred call. They are only
+// available for runtimes that copy them after the runtime._defer struct,
+// since go1.17 deferred calls never have arguments.
+func (d *Defer) Arguments(thread *Thread, cfg LoadConfig) ([]*Variable, error) {
+	if d.Unreadable != nil || d.DeferredPC == 0 || d.argsSize <= 0 {
+		return nil, nil
+	}
+	scope := &EvalScope{Thread: thread, PC: d.DeferredPC, CFA: int64(d.argsAddr)}
+	args, err := scope.FunctionArguments(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r := args[:0]
+	for _, arg := range args {
+		// return values are also formal parameters but they are not
+		// stored with the deferred call
+		if uint64(arg.Addr) < d.argsAddr+uint64(d.argsSize) {
+			r = append(r, arg)
+		}
+	}
+	return r, nil
+}
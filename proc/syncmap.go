@@ -0,0 +1,161 @@
+package proc
+
+import (
+	"errors"
+	"go/constant"
+	"reflect"
+
+	"golang.org/x/debug/dwarf"
+)
+
+// loadSyncMap loads the logical contents of the sync.Map v as if it was a
+// map: the entries of its read-only map and of its dirty map are merged
+// and deleted or expunged entries are skipped.
+// Returns false, leaving v unchanged, if the layout of sync.Map is not
+// recognized.
+func (v *Variable) loadSyncMap(recurseLevel int, cfg LoadConfig) bool {
+	m, amended, err := v.syncMapReadOnly()
+	if err != nil {
+		return false
+	}
+	dirty, err := v.structMember("dirty")
+	if err != nil || dirty.Kind != reflect.Map {
+		return false
+	}
+	maptyp, ok := dirty.RealType.(*dwarf.MapType)
+	if !ok {
+		return false
+	}
+
+	// The dirty map is only used when it contains entries that are not in
+	// the read-only map, entries present in both are shared.
+	maps := []*Variable{}
+	if m != nil {
+		maps = append(maps, m)
+	}
+	if amended {
+		maps = append(maps, dirty)
+	}
+
+	ptrSize := int64(v.dbp.arch.PtrSize())
+	expunged := v.syncMapExpunged()
+	seen := map[uint64]bool{}
+	children := []Variable{}
+	var count int64
+	for _, mv := range maps {
+		it := mv.mapIterator()
+		if it == nil {
+			if mv.Unreadable != nil {
+				return false
+			}
+			continue
+		}
+		for it.next() {
+			// Values are of type *sync.entry, the first word of sync.entry is
+			// a pointer to the value stored in the map.
+			val := it.value()
+			entry, err := readUintRaw(val.mem, val.Addr, ptrSize)
+			if err != nil || entry == 0 || seen[entry] {
+				continue
+			}
+			seen[entry] = true
+			p, err := readUintRaw(val.mem, uintptr(entry), ptrSize)
+			if err != nil || p == 0 || p == expunged {
+				continue
+			}
+			count++
+			if recurseLevel > cfg.MaxVariableRecurse || (cfg.MaxArrayValues >= 0 && len(children)/2 >= cfg.MaxArrayValues) || len(children)/2 >= maxLoadLen {
+				continue
+			}
+			key := it.key()
+			key.loadValueInternal(recurseLevel+1, cfg)
+			value := newVariable("", uintptr(p), maptyp.KeyType, v.dbp, v.mem)
+			value.loadValueInternal(recurseLevel+1, cfg)
+			children = append(children, *key, *value)
+		}
+		if mv.Unreadable != nil {
+			v.Unreadable = mv.Unreadable
+			break
+		}
+	}
+
+	v.Kind = reflect.Map
+	v.Len = count
+	v.Children = children
+	return true
+}
+
+// syncMapReadOnly returns the read-only map of the sync.Map v and the value
+// of its amended flag, m is nil if the read-only map has not been created
+// yet.
+func (v *Variable) syncMapReadOnly() (m *Variable, amended bool, err error) {
+	read, err := v.structMember("read")
+	if err != nil {
+		return nil, false, err
+	}
+	inner, err := read.structMember("v")
+	if err != nil {
+		return nil, false, err
+	}
+
+	var readOnly *Variable
+	switch inner.Kind {
+	case reflect.Interface:
+		// Before go1.20 read is an atomic.Value containing a sync.readOnly.
+		inner.loadInterface(0, false, loadFullValue)
+		if inner.Unreadable != nil {
+			return nil, false, inner.Unreadable
+		}
+		if len(inner.Children) == 0 || inner.Children[0].Addr == 0 {
+			return nil, false, nil
+		}
+		readOnly = &inner.Children[0]
+	case reflect.UnsafePointer:
+		// Starting with go1.20 read is an atomic.Pointer[sync.readOnly].
+		typ, err := v.dbp.findType("sync.readOnly")
+		if err != nil {
+			return nil, false, err
+		}
+		addr, err := readUintRaw(inner.mem, inner.Addr, int64(v.dbp.arch.PtrSize()))
+		if err != nil {
+			return nil, false, err
+		}
+		if addr == 0 {
+			return nil, false, nil
+		}
+		readOnly = newVariable("", uintptr(addr), typ, v.dbp, v.mem)
+	default:
+		return nil, false, errors.New("unknown sync.Map layout")
+	}
+	if readOnly.Kind != reflect.Struct {
+		return nil, false, errors.New("unknown sync.Map layout")
+	}
+
+	m, err = readOnly.structMember("m")
+	if err != nil {
+		return nil, false, err
+	}
+	if m.Kind != reflect.Map {
+		return nil, false, errors.New("unknown sync.Map layout")
+	}
+	if amendedVar := readOnly.toFieldNamed("amended"); amendedVar != nil && amendedVar.Value != nil {
+		amended = constant.BoolVal(amendedVar.Value)
+	}
+	return m, amended, nil
+}
+
+// syncMapExpunged returns the value of sync.expunged, the pointer used to
+// mark entries deleted from the read-only map of a sync.Map, or 0 if it
+// can not be read.
+func (v *Variable) syncMapExpunged() uint64 {
+	scope := &EvalScope{Thread: v.dbp.CurrentThread, PC: 0, CFA: 0}
+	expunged, err := scope.packageVarAddr("sync.expunged")
+	if err != nil {
+		return 0
+	}
+	p, err := readUintRaw(expunged.mem, expunged.Addr, int64(v.dbp.arch.PtrSize()))
+	if err != nil {
+		return 0
+	}
+	return p
+}
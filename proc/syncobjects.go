@@ -0,0 +1,126 @@
+package proc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+)
+
+// SyncObject is the decoded internal state of a sync.WaitGroup, sync.Once
+// or sync.Cond.
+type SyncObject struct {
+	// Type is the name of the type of the object.
+	Type string
+	// Counter is the counter of a sync.WaitGroup.
+	Counter int64
+	// Waiters is the number of goroutines blocked in the Wait method of a
+	// sync.WaitGroup or sync.Cond.
+	Waiters int64
+	// Done is true if the function of a sync.Once has been executed.
+	Done bool
+}
+
+// SyncObjectInfo evaluates expr, which must be a sync.WaitGroup, sync.Once
+// or sync.Cond or a pointer to one of them, and decodes its internal state.
+func (scope *EvalScope) SyncObjectInfo(expr string) (*SyncObject, error) {
+	t, err := ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	v, err := scope.evalAST(t)
+	if err != nil {
+		return nil, err
+	}
+	if v.Kind == reflect.Ptr {
+		v = v.maybeDereference()
+	}
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+
+	r := &SyncObject{Type: v.RealType.Common().Name}
+	switch r.Type {
+	case "sync.WaitGroup":
+		state, err := waitGroupState(v)
+		if err != nil {
+			return nil, err
+		}
+		// The counter is stored in the high 32 bits of the state, the number
+		// of waiters in the low 32 bits.
+		r.Counter = int64(int32(state >> 32))
+		r.Waiters = int64(uint32(state))
+	case "sync.Once":
+		done, err := v.structMember("done")
+		if err != nil {
+			return nil, err
+		}
+		n, err := atomicUintValue(done)
+		if err != nil {
+			return nil, fmt.Errorf("could not read done flag: %v", err)
+		}
+		r.Done = n != 0
+	case "sync.Cond":
+		notify, err := v.structMember("notify")
+		if err != nil {
+			return nil, err
+		}
+		// notify.wait is the ticket of the next waiter, notify.notify the
+		// ticket of the next waiter to be notified.
+		var tickets [2]uint64
+		for i, name := range []string{"wait", "notify"} {
+			field, err := notify.structMember(name)
+			if err != nil {
+				return nil, err
+			}
+			if tickets[i], err = atomicUintValue(field); err != nil {
+				return nil, fmt.Errorf("could not read notify list: %v", err)
+			}
+		}
+		r.Waiters = int64(uint32(tickets[0] - tickets[1]))
+	default:
+		return nil, fmt.Errorf("expression \"%s\" is not a sync.WaitGroup, sync.Once or sync.Cond", expr)
+	}
+	return r, nil
+}
+
+// waitGroupState returns the 64 bit state word of the sync.WaitGroup wg.
+func waitGroupState(wg *Variable) (uint64, error) {
+	// Starting with go1.20 the state is an atomic.Uint64 field.
+	if state, err := wg.structMember("state"); err == nil && state.Kind == reflect.Struct {
+		return atomicUintValue(state)
+	}
+	state1, err := wg.structMember("state1")
+	if err != nil {
+		return 0, err
+	}
+	if state1.Kind != reflect.Array {
+		// go1.18 and go1.19 store the state in a uint64 field.
+		return atomicUintValue(state1)
+	}
+	// Older versions store the state and the semaphore in an array of 12
+	// bytes, the state is whichever 8 bytes of it are 8 byte aligned.
+	buf, err := state1.mem.readMemory(state1.Addr, 12)
+	if err != nil {
+		return 0, err
+	}
+	if state1.Addr%8 != 0 {
+		buf = buf[4:]
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
+
+// atomicUintValue returns the value of v, which must be an unsigned
+// integer or one of the integer types of the sync/atomic package.
+func atomicUintValue(v *Variable) (uint64, error) {
+	if v.Unreadable != nil {
+		return 0, v.Unreadable
+	}
+	if v.Kind == reflect.Struct {
+		inner, err := v.structMember("v")
+		if err != nil {
+			return 0, err
+		}
+		return atomicUintValue(inner)
+	}
+	return v.asUint()
+}
@@ -36,8 +36,26 @@ func FindFixturesDir() string {
 	return fixturesDir
 }
 
+// BuildFlags modify how a fixture is built.
+type BuildFlags uint32
+
+const (
+	// EnableInlining builds the fixture with inlining enabled.
+	EnableInlining BuildFlags = 1 << iota
+)
+
 func BuildFixture(name string) Fixture {
-	if f, ok := Fixtures[name]; ok {
+	return BuildFixtureWithFlags(name, 0)
+}
+
+// BuildFixtureWithFlags is like BuildFixture but builds the fixture
+// according to flags.
+func BuildFixtureWithFlags(name string, flags BuildFlags) Fixture {
+	key := name
+	if flags != 0 {
+		key = fmt.Sprintf("%s-%d", name, flags)
+	}
+	if f, ok := Fixtures[key]; ok {
 		return f
 	}
 
@@ -54,7 +72,12 @@ func BuildFixture(name string) Fixture {
 		// Work-around for https://github.com/golang/go/issues/13154
 		buildFlags = append(buildFlags, "-ldflags=-linkmode internal")
 	}
-	buildFlags = append(buildFlags, "-gcflags=-N -l", "-o", tmpfile, path)
+	if flags&EnableInlining != 0 {
+		buildFlags = append(buildFlags, "-gcflags=-N")
+	} else {
+		buildFlags = append(buildFlags, "-gcflags=-N -l")
+	}
+	buildFlags = append(buildFlags, "-o", tmpfile, path)
 
 	// Build the test binary
 	if err := exec.Command("go", buildFlags...).Run(); err != nil {
@@ -65,8 +88,22 @@ func BuildFixture(name string) Fixture {
 	source, _ := filepath.Abs(path)
 	source = filepath.ToSlash(source)
 
-	Fixtures[name] = Fixture{Name: name, Path: tmpfile, Source: source}
-	return Fixtures[name]
+	Fixtures[key] = Fixture{Name: name, Path: tmpfile, Source: source}
+	return Fixtures[key]
+}
+
+// MustSupportFunctionCalls skips the test if the fixtures, built with the
+// toolchain running the test, can not execute injected function calls:
+// the protocol used by delve is only implemented by Go 1.11 to 1.16 on
+// linux/amd64.
+func MustSupportFunctionCalls(t testing.TB) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("function calls are only supported on linux/amd64")
+	}
+	var minor int
+	if _, err := fmt.Sscanf(runtime.Version(), "go1.%d", &minor); err != nil || minor < 11 || minor > 16 {
+		t.Skipf("function calls are not supported by the runtime of %s", runtime.Version())
+	}
 }
 
 // RunTestsWithFixtures will pre-compile test fixtures before running test
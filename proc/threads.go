@@ -6,10 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
+	"time"
 
 	"golang.org/x/debug/dwarf"
 )
@@ -25,11 +27,18 @@ type Thread struct {
 	CurrentBreakpoint        *Breakpoint // Breakpoint thread is currently stopped at
 	BreakpointConditionMet   bool        // Output of evaluating the breakpoint's condition
 	BreakpointConditionError error       // Error evaluating the breakpoint's condition
+	Signal                   int         // Signal the thread was stopped by, see SignalPolicy
 
 	dbp            *Process
 	singleStepping bool
 	running        bool
-	os             *OSSpecificDetails
+	// pendingSignal is the signal delivered to the thread when it is
+	// resumed, see DeliverSignal.
+	pendingSignal int
+	os            *OSSpecificDetails
+	// cachedName is the name of the thread, see Name.
+	cachedName string
+	nameLoaded bool
 }
 
 // Location represents the location of a thread.
@@ -282,6 +291,11 @@ func (dbp *Process) setStepIntoBreakpoint(text []AsmInstruction, cond ast.Expr)
 		return nil
 	}
 
+	// Only enter the requested function during StepInto
+	if dbp.stepIntoFn != "" && fn.Name != dbp.stepIntoFn {
+		return nil
+	}
+
 	//TODO(aarzilli): if we want to let users hide functions
 	// or entire packages from being stepped into with 'step'
 	// those extra checks should be done here.
@@ -399,6 +413,17 @@ func (thread *Thread) Stopped() bool {
 	return thread.stopped()
 }
 
+// Name returns the name the operating system assigned to the thread, or
+// an empty string if it is not available. The name is only read the first
+// time it is requested. Actual implementation is OS dependant, look in OS
+// thread file.
+func (thread *Thread) Name() string {
+	if !thread.nameLoaded {
+		thread.cachedName, thread.nameLoaded = thread.name(), true
+	}
+	return thread.cachedName
+}
+
 // Halt stops this thread from executing. Actual
 // implementation is OS dependant. Look in OS
 // thread file.
@@ -435,17 +460,43 @@ func (thread *Thread) SetCurrentBreakpoint() error {
 	if err != nil {
 		return err
 	}
-	if bp, ok := thread.dbp.FindBreakpoint(pc); ok {
-		thread.CurrentBreakpoint = bp
+	wp, err := thread.hitWatchpoint()
+	if err != nil {
+		return err
+	}
+	bp, ok := thread.dbp.FindBreakpoint(pc)
+	if ok {
 		if err = thread.SetPC(bp.Addr); err != nil {
 			return err
 		}
+	} else if wp != nil {
+		// watchpoints stop the thread after the instruction accessing the
+		// watched memory, the PC is left unchanged.
+		bp, ok = wp, true
+	}
+	if ok {
+		thread.CurrentBreakpoint = bp
+		start := time.Now()
 		thread.BreakpointConditionMet, thread.BreakpointConditionError = bp.checkCondition(thread)
+		if bp.Cond != nil {
+			bp.CondEvalTime += time.Since(start)
+		}
 		if thread.onTriggeredBreakpoint() {
 			if g, err := thread.GetG(); err == nil {
 				thread.CurrentBreakpoint.HitCount[g.ID]++
 			}
 			thread.CurrentBreakpoint.TotalHitCount++
+			if bp.Ignore > 0 {
+				bp.Ignore--
+				thread.BreakpointConditionMet = false
+			} else if bp.hitCond != nil {
+				v, err := evalHitCond(bp.hitCond, bp.TotalHitCount)
+				if err != nil {
+					thread.BreakpointConditionError = fmt.Errorf("error evaluating hit condition: %v", err)
+				} else {
+					thread.BreakpointConditionMet = constant.BoolVal(v)
+				}
+			}
 		}
 	}
 	return nil
@@ -2,6 +2,8 @@ package proc
 
 import (
 	"fmt"
+	"io/ioutil"
+	"strings"
 
 	sys "golang.org/x/sys/unix"
 )
@@ -14,6 +16,16 @@ type OSSpecificDetails struct {
 	registers sys.PtraceRegs
 }
 
+// debugRegOffset is the offset of u_debugreg in struct user.
+const debugRegOffset = 848
+
+// callState holds the registers of a thread saved before a function call
+// is injected into its goroutine.
+type callState struct {
+	regs   sys.PtraceRegs
+	fpregs [fpRegsSize]byte
+}
+
 func (t *Thread) halt() (err error) {
 	err = sys.Tgkill(t.dbp.Pid, t.ID, sys.SIGSTOP)
 	if err != nil {
@@ -34,7 +46,9 @@ func (t *Thread) stopped() bool {
 }
 
 func (t *Thread) resume() error {
-	return t.resumeWithSig(0)
+	sig := t.pendingSignal
+	t.pendingSignal, t.Signal = 0, 0
+	return t.resumeWithSig(sig)
 }
 
 func (t *Thread) resumeWithSig(sig int) (err error) {
@@ -94,6 +108,9 @@ func (t *Thread) writeMemory(addr uintptr, data []byte) (written int, err error)
 	if len(data) == 0 {
 		return
 	}
+	if t.dbp.core != nil {
+		return 0, CoreReadOnlyErr
+	}
 	t.dbp.execPtraceFunc(func() { written, err = sys.PtracePokeData(t.ID, addr, data) })
 	return
 }
@@ -102,7 +119,77 @@ func (t *Thread) readMemory(addr uintptr, size int) (data []byte, err error) {
 	if size == 0 {
 		return
 	}
+	if t.dbp.core != nil {
+		return t.dbp.core.readMemory(addr, size)
+	}
 	data = make([]byte, size)
 	t.dbp.execPtraceFunc(func() { _, err = sys.PtracePeekData(t.ID, addr, data) })
 	return
 }
+
+func (t *Thread) name() string {
+	if t.dbp.core != nil {
+		return ""
+	}
+	comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/task/%d/comm", t.dbp.Pid, t.ID))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+func (t *Thread) getDebugRegister(idx int) (value uint64, err error) {
+	var v uintptr
+	t.dbp.execPtraceFunc(func() { v, err = PtracePeekUser(t.ID, uintptr(debugRegOffset+idx*8)) })
+	return uint64(v), err
+}
+
+func (t *Thread) setDebugRegister(idx int, value uint64) (err error) {
+	t.dbp.execPtraceFunc(func() { err = PtracePokeUser(t.ID, uintptr(debugRegOffset+idx*8), uintptr(value)) })
+	return
+}
+
+func (t *Thread) saveCallState() (*callState, error) {
+	var s callState
+	var err error
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceGetRegs(t.ID, &s.regs) })
+	if err == nil {
+		t.dbp.execPtraceFunc(func() { err = PtraceGetFpRegs(t.ID, &s.fpregs) })
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not save register contents: %v", err)
+	}
+	return &s, nil
+}
+
+func (t *Thread) restoreCallState(s *callState) (err error) {
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(t.ID, &s.regs) })
+	if err == nil {
+		t.dbp.execPtraceFunc(func() { err = PtraceSetFpRegs(t.ID, &s.fpregs) })
+	}
+	return
+}
+
+func (t *Thread) setSP(sp uint64) (err error) {
+	var regs sys.PtraceRegs
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceGetRegs(t.ID, &regs) })
+	if err != nil {
+		return
+	}
+	regs.Rsp = sp
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(t.ID, &regs) })
+	return
+}
+
+func (t *Thread) setRegister(name string, value uint64) (err error) {
+	var regs sys.PtraceRegs
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceGetRegs(t.ID, &regs) })
+	if err != nil {
+		return
+	}
+	if err = (&Regs{&regs}).set(name, value); err != nil {
+		return
+	}
+	t.dbp.execPtraceFunc(func() { err = sys.PtraceSetRegs(t.ID, &regs) })
+	return
+}
@@ -149,3 +149,36 @@ func (t *Thread) readMemory(addr uintptr, size int) ([]byte, error) {
 	}
 	return buf[:count], nil
 }
+
+func (t *Thread) name() string {
+	// GetThreadDescription is only available starting with Windows 10.
+	return ""
+}
+
+func (t *Thread) getDebugRegister(idx int) (uint64, error) {
+	return 0, errWatchpointsUnsupported
+}
+
+func (t *Thread) setDebugRegister(idx int, value uint64) error {
+	return errWatchpointsUnsupported
+}
+
+// callState holds the registers of a thread saved before a function call
+// is injected into its goroutine.
+type callState struct{}
+
+func (t *Thread) saveCallState() (*callState, error) {
+	return nil, errFunctionCallsUnsupported
+}
+
+func (t *Thread) restoreCallState(s *callState) error {
+	return errFunctionCallsUnsupported
+}
+
+func (t *Thread) setSP(sp uint64) error {
+	return errFunctionCallsUnsupported
+}
+
+func (t *Thread) setRegister(name string, value uint64) error {
+	return errSetRegisterUnsupported
+}
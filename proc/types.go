@@ -136,6 +136,39 @@ func (dbp *Process) loadTypeMap(wg *sync.WaitGroup) {
 	}
 }
 
+// constantValue is the name and value of a typed constant.
+type constantValue struct {
+	name  string
+	value int64
+}
+
+// loadConstants indexes the typed constants described in the debug
+// information by the offset of their type.
+func (dbp *Process) loadConstants(wg *sync.WaitGroup) {
+	defer wg.Done()
+	dbp.constants = make(map[dwarf.Offset][]constantValue)
+	reader := dbp.DwarfReader()
+	for entry, err := reader.Next(); entry != nil; entry, err = reader.Next() {
+		if err != nil {
+			break
+		}
+		if entry.Tag != dwarf.TagConstant {
+			continue
+		}
+		name, okName := entry.Val(dwarf.AttrName).(string)
+		typ, okType := entry.Val(dwarf.AttrType).(dwarf.Offset)
+		val, okVal := entry.Val(dwarf.AttrConstValue).(int64)
+		if !okName || !okType || !okVal {
+			continue
+		}
+		// strip the package path
+		if dot := strings.LastIndex(name, "."); dot >= 0 {
+			name = name[dot+1:]
+		}
+		dbp.constants[typ] = append(dbp.constants[typ], constantValue{name, val})
+	}
+}
+
 func (dbp *Process) expandPackagesInType(expr ast.Expr) {
 	switch e := expr.(type) {
 	case *ast.ArrayType:
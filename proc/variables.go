@@ -2,14 +2,20 @@ package proc
 
 import (
 	"bytes"
+	"debug/gosym"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"go/ast"
 	"go/constant"
 	"go/parser"
 	"go/token"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/derekparker/delve/dwarf/op"
@@ -22,6 +28,15 @@ const (
 
 	maxArrayStridePrefetch = 1024 // Maximum size of array stride for which we will prefetch the array contents
 
+	maxGoroutineLabels = 256 // Max number of pprof labels read from a goroutine
+
+	// maxLoadLen is the maximum number of bytes read from a string and of
+	// elements read from an array, slice or map even when the LoadConfig
+	// has no limit, it protects against corrupted or uninitialized headers.
+	// Values longer than this are truncated: Len is larger than the number
+	// of bytes or children loaded.
+	maxLoadLen = 1 << 20
+
 	chanRecv = "chan receive"
 	chanSend = "chan send"
 
@@ -29,6 +44,79 @@ const (
 	hashMinTopHash   = 4 // used by map reading code, indicates minimum value of tophash that isn't empty or evacuated
 )
 
+// waitReasonStrings maps the numeric values of runtime.waitReason, used
+// by Go 1.11 and later in place of a string, to their description. The
+// order is the one of Go 1.11 and 1.12, later versions inserted new
+// reasons, it is only used when runtime.waitReasonStrings can not be read.
+var waitReasonStrings = [...]string{
+	"",
+	"GC assist marking",
+	"IO wait",
+	"chan receive (nil chan)",
+	"chan send (nil chan)",
+	"dumping heap",
+	"garbage collection",
+	"garbage collection scan",
+	"panicwait",
+	"select",
+	"select (no cases)",
+	"GC assist wait",
+	"GC sweep wait",
+	chanRecv,
+	chanSend,
+	"finalizer wait",
+	"force gc (idle)",
+	"semacquire",
+	"sleep",
+	"sync.Cond.Wait",
+	"timer goroutine (idle)",
+	"trace reader (blocked)",
+	"wait for GC cycle",
+	"GC worker (idle)",
+}
+
+// loadWaitReasons returns the descriptions of the numeric values of
+// runtime.waitReason, read from runtime.waitReasonStrings in the target.
+func (dbp *Process) loadWaitReasons() []string {
+	dbp.loadWaitReasonsOnce.Do(func() {
+		v, err := dbp.EvalPackageVariable("runtime.waitReasonStrings", LoadConfig{false, 0, 64, 256, 0})
+		if err == nil && v.Unreadable == nil && v.Kind == reflect.Array {
+			dbp.waitReasons = make([]string, len(v.Children))
+			for i := range v.Children {
+				if v.Children[i].Value != nil && v.Children[i].Value.Kind() == constant.String {
+					dbp.waitReasons[i] = constant.StringVal(v.Children[i].Value)
+				}
+			}
+			return
+		}
+		ver, _, err := dbp.getGoInformation()
+		if err == nil && !ver.IsDevel() && !ver.AfterOrEqual(GoVersion{1, 13, -1, 0, 0}) {
+			dbp.waitReasons = waitReasonStrings[:]
+		}
+	})
+	return dbp.waitReasons
+}
+
+// goroutineWaitReason returns the wait reason stored in the waitreason
+// field of a runtime.g, which is a string in older runtimes and a number
+// in newer ones, described by reasons. Unknown numbers are returned as is.
+func goroutineWaitReason(v *Variable, reasons []string) string {
+	if v == nil || v.Value == nil {
+		return ""
+	}
+	switch v.Value.Kind() {
+	case constant.String:
+		return constant.StringVal(v.Value)
+	case constant.Int:
+		n, _ := constant.Int64Val(v.Value)
+		if n >= 0 && n < int64(len(reasons)) {
+			return reasons[n]
+		}
+		return strconv.FormatInt(n, 10)
+	}
+	return ""
+}
+
 // Variable represents a variable. It contains the address, name,
 // type and other information parsed from both the Dwarf information
 // and the memory of the debugged process.
@@ -59,10 +147,22 @@ type Variable struct {
 	// number of elements to skip when loading a map
 	mapSkip int
 
+	// ifaceIndirect is set on the data of an interface whose concrete type
+	// is not a pointer, in that case the interface stores a pointer to the
+	// value and the variable has type *T instead of T.
+	ifaceIndirect bool
+
 	Children []Variable
 
 	loaded     bool
 	Unreadable error
+
+	// DeclLine is the line where the variable was declared, 0 if unknown.
+	// Only set for local variables.
+	DeclLine int64
+	// Shadowed is true for local variables hidden by a variable with the
+	// same name declared in an inner lexical block.
+	Shadowed bool
 }
 
 type LoadConfig struct {
@@ -70,9 +170,13 @@ type LoadConfig struct {
 	FollowPointers bool
 	// MaxVariableRecurse is how far to recurse when evaluating nested types.
 	MaxVariableRecurse int
-	// MaxStringLen is the maximum number of bytes read from a string
+	// MaxStringLen is the maximum number of bytes read from a string, -1
+	// will read the whole string, up to 1MB.
 	MaxStringLen int
-	// MaxArrayValues is the maximum number of elements read from an array, a slice or a map.
+	// MaxArrayValues is the maximum number of elements read from an array, a
+	// slice or a map, -1 will read all elements, up to about a million.
+	// Reading very long strings or very large arrays is slow and produces
+	// large responses, -1 should only be used to read a single value.
 	MaxArrayValues int
 	// MaxStructFields is the maximum number of fields read from a struct, -1 will read all fields.
 	MaxStructFields int
@@ -111,6 +215,9 @@ type G struct {
 	GoPC       uint64 // PC of 'go' statement that created this goroutine.
 	WaitReason string // Reason for goroutine being parked.
 	Status     uint64
+	Addr       uint64 // Address of the runtime.g struct.
+	StackLo    uint64 // Lowest address of the goroutine's stack.
+	StackHi    uint64 // Highest address of the goroutine's stack.
 
 	// Information on goroutine location
 	CurrentLoc Location
@@ -121,6 +228,9 @@ type G struct {
 	// Thread that this goroutine is currently allocated to
 	thread *Thread
 
+	// Address of the pprof labels of the goroutine, see Labels.
+	labelsAddr uint64
+
 	dbp *Process
 }
 
@@ -130,8 +240,17 @@ type EvalScope struct {
 	Thread *Thread
 	PC     uint64
 	CFA    int64
+	// Deadline, if not zero, is the time after which EvalExpression will
+	// stop loading values and return EvalTimeoutErr.
+	Deadline time.Time
+
+	g *G // goroutine of the scope, if nil it's the goroutine running on Thread
 }
 
+// EvalTimeoutErr is returned when the evaluation of an expression does not
+// complete before the deadline of its scope.
+var EvalTimeoutErr = errors.New("evaluation timed out")
+
 // IsNilErr is returned when a variable is nil.
 type IsNilErr struct {
 	name string
@@ -287,6 +406,32 @@ func (v *Variable) TypeString() string {
 	return v.Kind.String()
 }
 
+// ConstDescr returns the names of the constants of the type of v that
+// have the same value as v, separated by '/', or an empty string if there
+// are none.
+func (v *Variable) ConstDescr() string {
+	if v.dbp == nil || v.DwarfType == nil || v.Value == nil || v.Value.Kind() != constant.Int {
+		return ""
+	}
+	consts := v.dbp.constants[v.DwarfType.Common().Offset]
+	if len(consts) == 0 {
+		return ""
+	}
+	n, exact := constant.Int64Val(v.Value)
+	if !exact {
+		u, _ := constant.Uint64Val(v.Value)
+		n = int64(u)
+	}
+	var names []string
+	for _, c := range consts {
+		if c.value == n {
+			names = append(names, c.name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, "/")
+}
+
 func (v *Variable) toField(field *dwarf.StructField) (*Variable, error) {
 	if v.Unreadable != nil {
 		return v.clone(), nil
@@ -349,6 +494,16 @@ func (ng NoGError) Error() string {
 	return fmt.Sprintf("no G executing on thread %d", ng.tid)
 }
 
+// UnresolvedSymbolError is returned when a name used in an expression is
+// not a variable visible in the scope of the evaluation.
+type UnresolvedSymbolError struct {
+	Name string
+}
+
+func (err UnresolvedSymbolError) Error() string {
+	return fmt.Sprintf("could not find symbol value for %s", err.Name)
+}
+
 func (gvar *Variable) parseG() (*G, error) {
 	mem := gvar.mem
 	dbp := gvar.dbp
@@ -378,7 +533,7 @@ func (gvar *Variable) parseG() (*G, error) {
 	sp, _ := constant.Int64Val(schedVar.toFieldNamed("sp").Value)
 	id, _ := constant.Int64Val(gvar.toFieldNamed("goid").Value)
 	gopc, _ := constant.Int64Val(gvar.toFieldNamed("gopc").Value)
-	waitReason := constant.StringVal(gvar.toFieldNamed("waitreason").Value)
+	waitReason := goroutineWaitReason(gvar.toFieldNamed("waitreason"), dbp.loadWaitReasons())
 	d := gvar.toFieldNamed("_defer")
 	deferPC := int64(0)
 	fnvar := d.toFieldNamed("fn")
@@ -387,6 +542,16 @@ func (gvar *Variable) parseG() (*G, error) {
 		deferPC, _ = constant.Int64Val(fnvalvar.Value)
 	}
 	status, _ := constant.Int64Val(gvar.toFieldNamed("atomicstatus").Value)
+	var stacklo, stackhi int64
+	if stackVar := gvar.toFieldNamed("stack"); stackVar != nil {
+		stacklo, _ = constant.Int64Val(stackVar.toFieldNamed("lo").Value)
+		stackhi, _ = constant.Int64Val(stackVar.toFieldNamed("hi").Value)
+	}
+	var labelsAddr uintptr
+	// the labels field was added in Go 1.9
+	if labelsVar := gvar.toFieldNamed("labels"); labelsVar != nil && len(labelsVar.Children) == 1 {
+		labelsAddr = labelsVar.Children[0].Addr
+	}
 	f, l, fn := gvar.dbp.goSymTable.PCToLine(uint64(pc))
 	g := &G{
 		ID:         int(id),
@@ -396,7 +561,11 @@ func (gvar *Variable) parseG() (*G, error) {
 		WaitReason: waitReason,
 		DeferPC:    uint64(deferPC),
 		Status:     uint64(status),
+		Addr:       gaddr,
+		StackLo:    uint64(stacklo),
+		StackHi:    uint64(stackhi),
 		CurrentLoc: Location{PC: uint64(pc), File: f, Line: l, Fn: fn},
+		labelsAddr: uint64(labelsAddr),
 		dbp:        gvar.dbp,
 	}
 	return g, nil
@@ -441,6 +610,34 @@ func (g *G) UserCurrent() Location {
 	return g.CurrentLoc
 }
 
+// Labels returns the pprof labels of the goroutine, set with
+// runtime/pprof.Do or runtime/pprof.SetGoroutineLabels. The returned map
+// is empty if the goroutine has no labels or the runtime of the target
+// does not support them.
+func (g *G) Labels() map[string]string {
+	labels := map[string]string{}
+	if g.labelsAddr == 0 {
+		return labels
+	}
+	typ, err := g.dbp.findType("runtime/pprof.labelMap")
+	if err != nil {
+		return labels
+	}
+	m := newVariable("", uintptr(g.labelsAddr), typ, g.dbp, g.dbp.CurrentThread)
+	m.loadValue(LoadConfig{false, 0, 1024, maxGoroutineLabels, 0})
+	if m.Unreadable != nil || m.Kind != reflect.Map {
+		return labels
+	}
+	for i := 0; i+1 < len(m.Children); i += 2 {
+		k, v := m.Children[i].Value, m.Children[i+1].Value
+		if k == nil || v == nil || k.Kind() != constant.String || v.Kind() != constant.String {
+			continue
+		}
+		labels[constant.StringVal(k)] = constant.StringVal(v)
+	}
+	return labels
+}
+
 // Go returns the location of the 'go' statement
 // that spawned this goroutine.
 func (g *G) Go() Location {
@@ -455,7 +652,7 @@ func (scope *EvalScope) EvalVariable(name string, cfg LoadConfig) (*Variable, er
 
 // SetVariable sets the value of the named variable
 func (scope *EvalScope) SetVariable(name, value string) error {
-	t, err := parser.ParseExpr(name)
+	t, err := ParseExpr(name)
 	if err != nil {
 		return err
 	}
@@ -466,14 +663,18 @@ func (scope *EvalScope) SetVariable(name, value string) error {
 	}
 
 	if xv.Addr == 0 {
-		return fmt.Errorf("Can not assign to \"%s\"", name)
+		return fmt.Errorf("Can not assign to \"%s\": not addressable", name)
+	}
+
+	if scope.isMapElement(t) {
+		return fmt.Errorf("Can not assign to \"%s\": map elements are not addressable", name)
 	}
 
 	if xv.Unreadable != nil {
 		return fmt.Errorf("Expression \"%s\" is unreadable: %v", name, xv.Unreadable)
 	}
 
-	t, err = parser.ParseExpr(value)
+	t, err = ParseExpr(value)
 	if err != nil {
 		return err
 	}
@@ -528,17 +729,160 @@ func (scope *EvalScope) extractVarInfo(varName string) (*Variable, error) {
 			return scope.extractVarInfoFromEntry(entry, reader)
 		}
 	}
-	return nil, fmt.Errorf("could not find symbol value for %s", varName)
+	return nil, UnresolvedSymbolError{varName}
 }
 
 // LocalVariables returns all local variables from the current function scope.
 func (scope *EvalScope) LocalVariables(cfg LoadConfig) ([]*Variable, error) {
-	return scope.variablesByTag(dwarf.TagVariable, cfg)
+	return scope.variablesByTag(dwarf.TagVariable, nil, cfg)
+}
+
+// LocalVariablesMatching returns the local variables from the current
+// function scope whose name matches filter. The other variables are
+// skipped without reading their values.
+func (scope *EvalScope) LocalVariablesMatching(filter *regexp.Regexp, cfg LoadConfig) ([]*Variable, error) {
+	return scope.variablesByTag(dwarf.TagVariable, filter, cfg)
+}
+
+// LocalVariablesAllBlocks returns all local variables visible at the
+// current PC, including the ones declared in nested lexical blocks and the
+// ones shadowed by them. Each variable has its DeclLine and Shadowed
+// fields set.
+// Lexical blocks that do not describe their address range are assumed to
+// contain the current PC.
+// If filter is not nil only the variables whose name matches it are
+// returned.
+func (scope *EvalScope) LocalVariablesAllBlocks(filter *regexp.Regexp, cfg LoadConfig) ([]*Variable, error) {
+	reader := scope.DwarfReader()
+
+	fnEntry, err := reader.SeekToFunction(scope.PC)
+	if err != nil {
+		return nil, err
+	}
+	if !fnEntry.Children {
+		return nil, nil
+	}
+
+	var (
+		vars   []*Variable
+		depths []int
+		depth  = 1
+	)
+	for depth > 0 {
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			break
+		}
+
+		switch entry.Tag {
+		case 0:
+			depth--
+			continue
+		case dwarf.TagLexDwarfBlock:
+			if !lexicalBlockContains(entry, scope.PC) {
+				reader.SkipChildren()
+				continue
+			}
+		case dwarf.TagVariable:
+			if !entryMatches(entry, filter) {
+				break
+			}
+			if val, err := scope.extractVariableFromEntry(entry, cfg); err == nil {
+				if line, ok := entry.Val(dwarf.AttrDeclLine).(int64); ok {
+					val.DeclLine = line
+				}
+				vars = append(vars, val)
+				depths = append(depths, depth)
+			}
+		}
+		if entry.Children {
+			if entry.Tag == dwarf.TagLexDwarfBlock {
+				depth++
+			} else {
+				reader.SkipChildren()
+			}
+		}
+	}
+
+	// a variable is shadowed by a variable with the same name declared in
+	// a more deeply nested block
+	for i := range vars {
+		for j := range vars {
+			if vars[i].Name == vars[j].Name && depths[j] > depths[i] {
+				vars[i].Shadowed = true
+				break
+			}
+		}
+	}
+	return vars, nil
+}
+
+func lexicalBlockContains(entry *dwarf.Entry, pc uint64) bool {
+	lowpc, ok := entry.Val(dwarf.AttrLowpc).(uint64)
+	if !ok {
+		return true
+	}
+	switch highpc := entry.Val(dwarf.AttrHighpc).(type) {
+	case uint64:
+		return lowpc <= pc && pc < highpc
+	case int64:
+		// DWARF 4 encodes highpc as an offset from lowpc
+		return lowpc <= pc && pc < lowpc+uint64(highpc)
+	}
+	return true
 }
 
 // FunctionArguments returns the name, value, and type of all current function arguments.
 func (scope *EvalScope) FunctionArguments(cfg LoadConfig) ([]*Variable, error) {
-	return scope.variablesByTag(dwarf.TagFormalParameter, cfg)
+	return scope.variablesByTag(dwarf.TagFormalParameter, nil, cfg)
+}
+
+// FunctionArgumentsMatching returns the current function arguments whose
+// name matches filter. The other arguments are skipped without reading
+// their values.
+func (scope *EvalScope) FunctionArgumentsMatching(filter *regexp.Regexp, cfg LoadConfig) ([]*Variable, error) {
+	return scope.variablesByTag(dwarf.TagFormalParameter, filter, cfg)
+}
+
+// FunctionReceiver returns the receiver of the function of scope, if it is
+// a method, and its other arguments.
+// The debug info does not mark the receiver, it is recognized as the
+// first argument when its type is the receiver type in the name of the
+// function.
+func (scope *EvalScope) FunctionReceiver(cfg LoadConfig) (*Variable, []*Variable, error) {
+	args, err := scope.FunctionArguments(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	fn := scope.Thread.dbp.goSymTable.PCToFunc(scope.PC)
+	if fn == nil || len(args) == 0 || args[0].DwarfType == nil {
+		return nil, args, nil
+	}
+	recvtyp := methodReceiverType(fn.Sym)
+	if recvtyp == "" {
+		return nil, args, nil
+	}
+	if typ := args[0].DwarfType; typ.Common().Name != recvtyp && typ.String() != recvtyp {
+		return nil, args, nil
+	}
+	return args[0], args[1:], nil
+}
+
+// methodReceiverType returns the name of the receiver type of the method
+// fn, for example *main.T for main.(*T).String, or an empty string if fn
+// is not a method.
+func methodReceiverType(fn *gosym.Sym) string {
+	recv := fn.ReceiverName()
+	if recv == "" {
+		return ""
+	}
+	if strings.HasPrefix(recv, "(*") && strings.HasSuffix(recv, ")") {
+		return "*" + fn.PackageName() + "." + recv[2:len(recv)-1]
+	}
+	return fn.PackageName() + "." + recv
 }
 
 // PackageVariables returns the name, value, and type of all package variables in the application.
@@ -601,7 +945,7 @@ func (scope *EvalScope) packageVarAddr(name string) (*Variable, error) {
 			return scope.extractVarInfoFromEntry(entry, reader)
 		}
 	}
-	return nil, fmt.Errorf("could not find symbol value for %s", name)
+	return nil, UnresolvedSymbolError{name}
 }
 
 func (v *Variable) structMember(memberName string) (*Variable, error) {
@@ -730,6 +1074,10 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 	if v.Unreadable != nil || v.loaded || (v.Addr == 0 && v.Base == 0) {
 		return
 	}
+	if v.dbp != nil && v.dbp.evalTimedOut() {
+		v.Unreadable = EvalTimeoutErr
+		return
+	}
 
 	v.loaded = true
 	switch v.Kind {
@@ -766,6 +1114,12 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 		v.loadArrayValues(recurseLevel, cfg)
 
 	case reflect.Struct:
+		if v.RealType.Common().Name == "sync.Map" && v.loadSyncMap(recurseLevel, cfg) {
+			return
+		}
+		if v.loadAtomicWrapper(recurseLevel, cfg) {
+			return
+		}
 		v.mem = cacheMemory(v.mem, v.Addr, int(v.RealType.Size()))
 		t := v.RealType.(*dwarf.StructType)
 		v.Len = int64(len(t.Field))
@@ -815,6 +1169,39 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 	}
 }
 
+// isMapElement returns true if t is a map element or a field of a map
+// element. Map elements are loaded from the map buckets but, like in Go,
+// they are not addressable: writing to them could corrupt the map.
+func (scope *EvalScope) isMapElement(t ast.Expr) bool {
+	for {
+		switch node := t.(type) {
+		case *ast.ParenExpr:
+			t = node.X
+		case *ast.SelectorExpr:
+			x, err := scope.evalAST(node.X)
+			if err != nil || x.Kind == reflect.Ptr {
+				return false
+			}
+			t = node.X
+		case *ast.IndexExpr:
+			x, err := scope.evalAST(node.X)
+			if err != nil {
+				return false
+			}
+			if x.Kind == reflect.Map {
+				return true
+			}
+			if x.Kind != reflect.Array {
+				// slice elements are addressable
+				return false
+			}
+			t = node.X
+		default:
+			return false
+		}
+	}
+}
+
 func (v *Variable) setValue(y *Variable) error {
 	var err error
 	switch v.Kind {
@@ -833,18 +1220,48 @@ func (v *Variable) setValue(y *Variable) error {
 		real, _ := constant.Float64Val(constant.Real(y.Value))
 		imag, _ := constant.Float64Val(constant.Imag(y.Value))
 		err = v.writeComplex(real, imag, v.RealType.Size())
+	case reflect.Ptr:
+		switch {
+		case y == nilVariable:
+			err = v.writeZero()
+		case len(y.Children) > 0:
+			err = v.writeUint(uint64(y.Children[0].Addr), v.RealType.Size())
+		default:
+			return fmt.Errorf("can not set variables of type %s to %s", v.TypeString(), y.TypeString())
+		}
 	default:
-		fmt.Printf("default\n")
-		if t, isptr := v.RealType.(*dwarf.PtrType); isptr {
-			err = v.writeUint(uint64(y.Children[0].Addr), int64(t.ByteSize))
-		} else {
-			return fmt.Errorf("can not set variables of type %s (not implemented)", v.Kind.String())
+		switch {
+		case y == nilVariable:
+			err = v.writeZero()
+		case y.Addr != 0:
+			// assignment from a variable of the same type, copy its memory
+			err = v.writeCopy(y)
+		default:
+			// the value would have to be allocated in the target
+			return fmt.Errorf("can not set variables of type %s (not implemented)", v.TypeString())
 		}
 	}
 
 	return err
 }
 
+// writeZero sets the memory of v to zero.
+func (v *Variable) writeZero() error {
+	_, err := v.mem.writeMemory(v.Addr, make([]byte, v.RealType.Size()))
+	return err
+}
+
+// writeCopy copies the memory of y, which must have the same type, over
+// the memory of v.
+func (v *Variable) writeCopy(y *Variable) error {
+	val, err := y.mem.readMemory(y.Addr, int(v.RealType.Size()))
+	if err != nil {
+		return err
+	}
+	_, err = v.mem.writeMemory(v.Addr, val)
+	return err
+}
+
 func readStringInfo(mem memoryReadWriter, arch Arch, addr uintptr) (uintptr, int64, error) {
 	// string data structure is always two ptrs in size. Addr, followed by len
 	// http://research.swtch.com/godata
@@ -875,10 +1292,16 @@ func readStringInfo(mem memoryReadWriter, arch Arch, addr uintptr) (uintptr, int
 }
 
 func readStringValue(mem memoryReadWriter, addr uintptr, strlen int64, cfg LoadConfig) (string, error) {
+	if strlen < 0 {
+		return "", fmt.Errorf("invalid string length %d", strlen)
+	}
 	count := strlen
-	if count > int64(cfg.MaxStringLen) {
+	if cfg.MaxStringLen >= 0 && count > int64(cfg.MaxStringLen) {
 		count = int64(cfg.MaxStringLen)
 	}
+	if count > maxLoadLen {
+		count = maxLoadLen
+	}
 
 	val, err := mem.readMemory(addr, int(count))
 	if err != nil {
@@ -949,11 +1372,14 @@ func (v *Variable) loadArrayValues(recurseLevel int, cfg LoadConfig) {
 
 	count := v.Len
 	// Cap number of elements
-	if count > int64(cfg.MaxArrayValues) {
+	if cfg.MaxArrayValues >= 0 && count > int64(cfg.MaxArrayValues) {
 		count = int64(cfg.MaxArrayValues)
 	}
+	if count > maxLoadLen {
+		count = maxLoadLen
+	}
 
-	if v.stride < maxArrayStridePrefetch {
+	if v.stride < maxArrayStridePrefetch && v.stride*count <= maxLoadLen {
 		v.mem = cacheMemory(v.mem, v.Base, int(v.stride*count))
 	}
 
@@ -1158,7 +1584,7 @@ func (v *Variable) loadMap(recurseLevel int, cfg LoadConfig) {
 	count := 0
 	errcount := 0
 	for it.next() {
-		if count >= cfg.MaxArrayValues {
+		if (cfg.MaxArrayValues >= 0 && count >= cfg.MaxArrayValues) || count >= maxLoadLen {
 			break
 		}
 		key := it.key()
@@ -1343,6 +1769,10 @@ func (it *mapIterator) nextBucket() bool {
 
 func (it *mapIterator) next() bool {
 	for {
+		if it.v.dbp != nil && it.v.dbp.evalTimedOut() {
+			it.v.Unreadable = EvalTimeoutErr
+			return false
+		}
 		if it.b == nil || it.idx >= it.tophashes.Len {
 			r := it.nextBucket()
 			if !r {
@@ -1525,14 +1955,17 @@ func (v *Variable) loadInterface(recurseLevel int, loadData bool, cfg LoadConfig
 		}
 	}
 
+	indirect := false
 	if kind&kindDirectIface == 0 {
 		realtyp := resolveTypedef(typ)
 		if _, isptr := realtyp.(*dwarf.PtrType); !isptr {
 			typ = v.dbp.pointerTo(typ)
+			indirect = true
 		}
 	}
 
 	data = data.newVariable("data", data.Addr, typ)
+	data.ifaceIndirect = indirect
 
 	v.Children = []Variable{*data}
 	if loadData {
@@ -1544,7 +1977,7 @@ func (v *Variable) loadInterface(recurseLevel int, loadData bool, cfg LoadConfig
 }
 
 // Fetches all variables of a specific type in the current function scope
-func (scope *EvalScope) variablesByTag(tag dwarf.Tag, cfg LoadConfig) ([]*Variable, error) {
+func (scope *EvalScope) variablesByTag(tag dwarf.Tag, filter *regexp.Regexp, cfg LoadConfig) ([]*Variable, error) {
 	reader := scope.DwarfReader()
 
 	_, err := reader.SeekToFunction(scope.PC)
@@ -1558,7 +1991,7 @@ func (scope *EvalScope) variablesByTag(tag dwarf.Tag, cfg LoadConfig) ([]*Variab
 			return nil, err
 		}
 
-		if entry.Tag == tag {
+		if entry.Tag == tag && entryMatches(entry, filter) {
 			val, err := scope.extractVariableFromEntry(entry, cfg)
 			if err != nil {
 				// skip variables that we can't parse yet
@@ -1571,3 +2004,13 @@ func (scope *EvalScope) variablesByTag(tag dwarf.Tag, cfg LoadConfig) ([]*Variab
 
 	return vars, nil
 }
+
+// entryMatches returns true if filter is nil or the name of entry matches
+// it.
+func entryMatches(entry *dwarf.Entry, filter *regexp.Regexp) bool {
+	if filter == nil {
+		return true
+	}
+	name, _ := entry.Val(dwarf.AttrName).(string)
+	return filter.MatchString(name)
+}
@@ -0,0 +1,284 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+)
+
+// WatchType is the kind of memory access that triggers a watchpoint.
+type WatchType uint8
+
+const (
+	// WatchRead stops when the watched memory is read.
+	// The debug registers of amd64 can not watch reads alone, watchpoints
+	// of this kind will also stop when the memory is written.
+	WatchRead WatchType = 1 << iota
+	// WatchWrite stops when the watched memory is written.
+	WatchWrite
+)
+
+const (
+	// maxWatchpoints is the number of debug registers (DR0 to DR3) that
+	// can hold the address of a watchpoint.
+	maxWatchpoints = 4
+	// watchpointStackDepth is the maximum depth at which the frame
+	// containing a watched local variable is searched for.
+	watchpointStackDepth = 1024
+
+	dr6 = 6
+	dr7 = 7
+)
+
+var errWatchpointsUnsupported = errors.New("hardware watchpoints are not supported on this platform")
+
+// IsWatchpoint returns true if bp is a watchpoint.
+func (bp *Breakpoint) IsWatchpoint() bool {
+	return bp.WatchType != 0
+}
+
+// SetWatchpoint sets a hardware watchpoint on the memory occupied by the
+// value of expr, evaluated in scope.
+// The value must be addressable, its size must be 1, 2, 4 or 8 bytes and
+// its address aligned to its size.
+// If the value lives on the stack of a goroutine the watchpoint is
+// removed once the frame of scope returns, see WatchOutOfScope.
+func (dbp *Process) SetWatchpoint(scope *EvalScope, expr string, wtype WatchType, cond ast.Expr) (*Breakpoint, error) {
+	if dbp.exited {
+		return nil, &ProcessExitedError{}
+	}
+	if dbp.core != nil {
+		return nil, CoreReadOnlyErr
+	}
+	if wtype&(WatchRead|WatchWrite) == 0 {
+		return nil, errors.New("invalid watchpoint type")
+	}
+	v, err := scope.EvalExpression(expr, LoadConfig{})
+	if err != nil {
+		return nil, err
+	}
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	if v.Addr == 0 {
+		return nil, fmt.Errorf("can not watch %q: not addressable", expr)
+	}
+	addr := uint64(v.Addr)
+	size := v.RealType.Size()
+	switch size {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("can not watch %q: size of %s is %d, must be 1, 2, 4 or 8", expr, v.RealType.String(), size)
+	}
+	if addr%uint64(size) != 0 {
+		return nil, fmt.Errorf("can not watch %q: address %#x is not aligned to %d bytes", expr, addr, size)
+	}
+	if _, exists := dbp.Breakpoints[addr]; exists {
+		return nil, fmt.Errorf("watchpoint exists at %#x", addr)
+	}
+
+	var used [maxWatchpoints]bool
+	for _, bp := range dbp.Breakpoints {
+		if bp.IsWatchpoint() {
+			used[bp.hwidx] = true
+		}
+	}
+	hwidx := -1
+	for i := range used {
+		if !used[i] {
+			hwidx = i
+			break
+		}
+	}
+	if hwidx < 0 {
+		return nil, errors.New("all hardware watchpoints are in use")
+	}
+
+	dbp.breakpointIDCounter++
+	bp := &Breakpoint{
+		Addr:      addr,
+		ID:        dbp.breakpointIDCounter,
+		Kind:      UserBreakpoint,
+		Cond:      cond,
+		HitCount:  map[int]uint64{},
+		WatchExpr: expr,
+		WatchType: wtype,
+		watchSize: int(size),
+		hwidx:     hwidx,
+	}
+
+	g := scope.g
+	if g == nil {
+		g, _ = scope.Thread.GetG()
+	}
+	if g != nil && addr >= g.StackLo && addr < g.StackHi {
+		bp.watchStack = true
+		bp.watchGoroutine = g.ID
+		bp.watchCFA = scope.CFA
+		if fn := dbp.goSymTable.PCToFunc(scope.PC); fn != nil {
+			bp.watchFnEntry = fn.Entry
+		}
+	}
+
+	for _, thread := range dbp.Threads {
+		if err := thread.writeWatchpoint(bp); err != nil {
+			dbp.breakpointIDCounter--
+			for _, thread := range dbp.Threads {
+				thread.clearWatchpoint(bp)
+			}
+			return nil, err
+		}
+	}
+	dbp.Breakpoints[addr] = bp
+	return bp, nil
+}
+
+// writeWatchpoints programs the debug registers of a newly created thread
+// with the watchpoints currently set.
+func (thread *Thread) writeWatchpoints() error {
+	for _, bp := range thread.dbp.Breakpoints {
+		if bp.IsWatchpoint() {
+			if err := thread.writeWatchpoint(bp); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeWatchpoint loads the address of bp into its debug register and
+// enables it in DR7.
+func (thread *Thread) writeWatchpoint(bp *Breakpoint) error {
+	if err := thread.setDebugRegister(bp.hwidx, bp.Addr); err != nil {
+		return fmt.Errorf("could not set watchpoint: %v", err)
+	}
+	ctl, err := thread.getDebugRegister(dr7)
+	if err != nil {
+		return fmt.Errorf("could not set watchpoint: %v", err)
+	}
+	mask, bits := dr7Bits(bp)
+	if err := thread.setDebugRegister(dr7, ctl&^mask|bits); err != nil {
+		return fmt.Errorf("could not set watchpoint: %v", err)
+	}
+	return nil
+}
+
+// clearWatchpoint disables the debug register of bp.
+func (thread *Thread) clearWatchpoint(bp *Breakpoint) error {
+	ctl, err := thread.getDebugRegister(dr7)
+	if err != nil {
+		return fmt.Errorf("could not clear watchpoint: %v", err)
+	}
+	mask, _ := dr7Bits(bp)
+	if err := thread.setDebugRegister(dr7, ctl&^mask); err != nil {
+		return fmt.Errorf("could not clear watchpoint: %v", err)
+	}
+	return nil
+}
+
+// dr7Bits returns the bits of DR7 that control the debug register used
+// by bp and the value they must have to enable it.
+func dr7Bits(bp *Breakpoint) (mask, bits uint64) {
+	var rw, length uint64
+	if bp.WatchType&WatchRead != 0 {
+		rw = 3 // break on data reads or writes
+	} else {
+		rw = 1 // break on data writes
+	}
+	switch bp.watchSize {
+	case 1:
+		length = 0
+	case 2:
+		length = 1
+	case 4:
+		length = 3
+	case 8:
+		length = 2
+	}
+	enable := uint64(1) << uint(2*bp.hwidx)
+	shift := uint(16 + 4*bp.hwidx)
+	return enable | 0xf<<shift, enable | (rw|length<<2)<<shift
+}
+
+// hitWatchpoint returns the watchpoint that stopped the thread, if any,
+// and resets DR6.
+func (thread *Thread) hitWatchpoint() (*Breakpoint, error) {
+	found := false
+	for _, bp := range thread.dbp.Breakpoints {
+		if bp.IsWatchpoint() {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	status, err := thread.getDebugRegister(dr6)
+	if err != nil {
+		return nil, err
+	}
+	if status&(1<<maxWatchpoints-1) == 0 {
+		return nil, nil
+	}
+	if err := thread.setDebugRegister(dr6, 0); err != nil {
+		return nil, err
+	}
+	for _, bp := range thread.dbp.Breakpoints {
+		if bp.IsWatchpoint() && status&(1<<uint(bp.hwidx)) != 0 {
+			return bp, nil
+		}
+	}
+	return nil, nil
+}
+
+// removeOutOfScopeWatchpoints clears the watchpoints on local variables
+// whose frame has returned, or was moved by a stack growth, and appends
+// them to WatchOutOfScope.
+// It is only called when Continue is about to return to the user, since
+// it needs a stacktrace of every goroutine owning such a watchpoint.
+func (dbp *Process) removeOutOfScopeWatchpoints() error {
+	for _, bp := range dbp.Breakpoints {
+		if !bp.IsWatchpoint() || !bp.watchStack {
+			continue
+		}
+		if dbp.watchpointInScope(bp) {
+			continue
+		}
+		if _, err := dbp.ClearBreakpoint(bp.Addr); err != nil {
+			return err
+		}
+		for _, thread := range dbp.Threads {
+			if thread.CurrentBreakpoint == bp {
+				thread.clearBreakpointState()
+			}
+		}
+		dbp.WatchOutOfScope = append(dbp.WatchOutOfScope, bp)
+	}
+	return nil
+}
+
+// watchpointInScope returns true if the frame that contained the local
+// variable watched by bp is still on the stack of its goroutine.
+// A goroutine that no longer exists has no frames left in scope.
+func (dbp *Process) watchpointInScope(bp *Breakpoint) bool {
+	g, err := dbp.FindGoroutine(bp.watchGoroutine)
+	if err != nil {
+		// GoroutinesInfo is cached here, an error means the list could
+		// not be read at all and the goroutine may still exist.
+		_, err := dbp.GoroutinesInfo()
+		return err != nil
+	}
+	if g == nil {
+		return false
+	}
+	frames, err := g.Stacktrace(watchpointStackDepth)
+	if err != nil {
+		return true
+	}
+	for _, frame := range frames {
+		if frame.CFA == bp.watchCFA && frame.Current.Fn != nil && frame.Current.Fn.Entry == bp.watchFnEntry {
+			return true
+		}
+	}
+	return false
+}
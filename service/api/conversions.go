@@ -3,11 +3,11 @@ package api
 import (
 	"bytes"
 	"debug/gosym"
+	"fmt"
 	"go/constant"
-	"go/printer"
-	"go/token"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"golang.org/x/debug/dwarf"
 	"github.com/derekparker/delve/proc"
@@ -18,6 +18,7 @@ import (
 func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
 	b := &Breakpoint{
 		Name:          bp.Name,
+		Group:         bp.Group,
 		ID:            bp.ID,
 		FunctionName:  bp.FunctionName,
 		File:          bp.File,
@@ -26,10 +27,31 @@ func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
 		Tracepoint:    bp.Tracepoint,
 		Stacktrace:    bp.Stacktrace,
 		Goroutine:     bp.Goroutine,
+		GoroutineID:   bp.GoroutineID,
+		ThreadID:      bp.ThreadID,
 		Variables:     bp.Variables,
 		LoadArgs:      LoadConfigFromProc(bp.LoadArgs),
 		LoadLocals:    LoadConfigFromProc(bp.LoadLocals),
 		TotalHitCount: bp.TotalHitCount,
+		Ignore:        bp.Ignore,
+		HitCond:       bp.HitCond,
+		Aggregate:     bp.Aggregate,
+		LogMessage:    bp.LogMessage,
+		LogToFile:     bp.LogToFile,
+		CondEvalTime:  bp.CondEvalTime,
+		CaptureTime:   bp.CaptureTime,
+		ActiveAfter:   bp.ActiveAfter,
+		ActiveUntil:   bp.ActiveUntil,
+	}
+
+	if bp.IsWatchpoint() {
+		b.WatchExpr = bp.WatchExpr
+		b.WatchKind = WatchKind(bp.WatchType)
+	}
+
+	if bp.AnchorFunction != "" {
+		b.Anchored = true
+		b.AnchorStatement = bp.AnchorStatement
 	}
 
 	b.HitCount = map[string]uint64{}
@@ -37,9 +59,7 @@ func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
 		b.HitCount[strconv.Itoa(idx)] = bp.HitCount[idx]
 	}
 
-	var buf bytes.Buffer
-	printer.Fprint(&buf, token.NewFileSet(), bp.Cond)
-	b.Cond = buf.String()
+	b.Cond = proc.ExprString(bp.Cond)
 
 	return b
 }
@@ -52,6 +72,7 @@ func ConvertThread(th *proc.Thread) *Thread {
 		file     string
 		line     int
 		pc       uint64
+		pcsym    string
 		gid      int
 	)
 
@@ -61,6 +82,9 @@ func ConvertThread(th *proc.Thread) *Thread {
 		file = loc.File
 		line = loc.Line
 		function = ConvertFunction(loc.Fn)
+		if loc.Fn != nil {
+			pcsym = fmt.Sprintf("%s+%#x", loc.Fn.Name, loc.PC-loc.Fn.Entry)
+		}
 	}
 
 	var bp *Breakpoint
@@ -79,6 +103,8 @@ func ConvertThread(th *proc.Thread) *Thread {
 		File:        file,
 		Line:        line,
 		Function:    function,
+		PCSymbol:    pcsym,
+		Name:        th.Name(),
 		GoroutineID: gid,
 		Breakpoint:  bp,
 	}
@@ -107,6 +133,8 @@ func ConvertVar(v *proc.Variable) *Variable {
 		Kind:     v.Kind,
 		Len:      v.Len,
 		Cap:      v.Cap,
+		DeclLine: v.DeclLine,
+		Shadowed: v.Shadowed,
 	}
 
 	r.Type = prettyTypeName(v.DwarfType)
@@ -129,6 +157,7 @@ func ConvertVar(v *proc.Variable) *Variable {
 		default:
 			r.Value = v.Value.String()
 		}
+		r.ConstName = v.ConstDescr()
 	}
 
 	switch v.Kind {
@@ -187,6 +216,43 @@ func ConvertFunction(fn *gosym.Func) *Function {
 	}
 }
 
+// ConvertMethod converts from proc.Method to api.Method.
+func ConvertMethod(m *proc.Method) Method {
+	var buf bytes.Buffer
+	buf.WriteString("func")
+	writeMethodParams(&buf, m.Args, true)
+	switch {
+	case len(m.Results) == 1 && unnamedParam(m.Results[0]):
+		buf.WriteString(" ")
+		buf.WriteString(prettyTypeName(m.Results[0].Type))
+	case len(m.Results) > 0:
+		buf.WriteString(" ")
+		writeMethodParams(&buf, m.Results, false)
+	}
+	return Method{Name: m.Name, Receiver: m.Receiver, Signature: buf.String(), Entry: m.Entry}
+}
+
+// unnamedParam returns true for parameters without a name and for the
+// names assigned by the compiler to unnamed return values.
+func unnamedParam(p proc.MethodParam) bool {
+	return p.Name == "" || p.Name == "_" || strings.HasPrefix(p.Name, "~")
+}
+
+func writeMethodParams(buf *bytes.Buffer, params []proc.MethodParam, isArgs bool) {
+	buf.WriteString("(")
+	for i, p := range params {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if !unnamedParam(p) || (isArgs && p.Name == "_") {
+			buf.WriteString(p.Name)
+			buf.WriteString(" ")
+		}
+		buf.WriteString(prettyTypeName(p.Type))
+	}
+	buf.WriteString(")")
+}
+
 // ConvertGoroutine converts from proc.G to api.Goroutine.
 func ConvertGoroutine(g *proc.G) *Goroutine {
 	th := g.Thread()
@@ -199,7 +265,32 @@ func ConvertGoroutine(g *proc.G) *Goroutine {
 		CurrentLoc:     ConvertLocation(g.CurrentLoc),
 		UserCurrentLoc: ConvertLocation(g.UserCurrent()),
 		GoStatementLoc: ConvertLocation(g.Go()),
-		ThreadID: tid,
+		ThreadID:       tid,
+		Addr:           g.Addr,
+		Status:         convertGoroutineStatus(g.Status),
+		WaitReason:     g.WaitReason,
+		Labels:         g.Labels(),
+	}
+}
+
+func convertGoroutineStatus(status uint64) string {
+	switch status {
+	case proc.Gidle:
+		return GoroutineIdle
+	case proc.Grunnable:
+		return GoroutineRunnable
+	case proc.Grunning:
+		return GoroutineRunning
+	case proc.Gsyscall:
+		return GoroutineSyscall
+	case proc.Gwaiting:
+		return GoroutineWaiting
+	case proc.Gdead:
+		return GoroutineDead
+	case proc.Gcopystack:
+		return GoroutineCopystack
+	default:
+		return GoroutineUnknown
 	}
 }
 
@@ -220,13 +311,93 @@ func ConvertAsmInstruction(inst proc.AsmInstruction, text string) AsmInstruction
 		destloc = &r
 	}
 	return AsmInstruction{
-		Loc:        ConvertLocation(inst.Loc),
-		DestLoc:    destloc,
-		Text:       text,
-		Bytes:      inst.Bytes,
-		Breakpoint: inst.Breakpoint,
-		AtPC:       inst.AtPC,
+		Loc:            ConvertLocation(inst.Loc),
+		DestLoc:        destloc,
+		Text:           text,
+		Bytes:          inst.Bytes,
+		Breakpoint:     inst.Breakpoint,
+		AtPC:           inst.AtPC,
+		ResolvedTarget: inst.ResolvedTarget,
+	}
+}
+
+// ConvertRegisters converts from a list of proc.Register to api.Registers,
+// marking as changed the registers whose value differs from prev.
+func ConvertRegisters(regs []proc.Register, prev []proc.Register) Registers {
+	r := make(Registers, 0, len(regs))
+	for i, reg := range regs {
+		changed := false
+		if i < len(prev) && prev[i].Name == reg.Name {
+			changed = prev[i].Value != reg.Value
+		}
+		var flags map[string]bool
+		if proc.IsFlagsRegister(reg.Name) {
+			flags = proc.DecodeFlags(reg.Value)
+		}
+		r = append(r, Register{Name: reg.Name, Value: reg.Value, Changed: changed, Flags: flags})
+	}
+	return r
+}
+
+// ConvertPointerTarget fills the PointerKind and Symbol fields of w
+// from pt.
+func ConvertPointerTarget(w *MemWord, pt proc.PointerTarget) {
+	switch pt.Kind {
+	case proc.CodePointer:
+		w.PointerKind = "code"
+	case proc.DataPointer:
+		w.PointerKind = "data"
+	case proc.HeapPointer:
+		w.PointerKind = "heap"
+	}
+	w.Symbol = pt.Symbol
+}
+
+// ConvertReference converts from proc.Reference to api.Reference.
+func ConvertReference(ref proc.Reference) Reference {
+	return Reference{Addr: ref.Addr, GoroutineID: ref.GoroutineID, Symbol: ref.Symbol}
+}
+
+// ConvertSyncObject converts from proc.SyncObject to api.SyncObject.
+func ConvertSyncObject(obj *proc.SyncObject) *SyncObject {
+	return &SyncObject{Type: obj.Type, Counter: obj.Counter, Waiters: obj.Waiters, Done: obj.Done}
+}
+
+// ConvertRuntimeConfig converts from proc.RuntimeConfig to api.RuntimeConfig.
+func ConvertRuntimeConfig(cfg *proc.RuntimeConfig) *RuntimeConfig {
+	r := &RuntimeConfig{
+		GOMAXPROCS: cfg.GOMAXPROCS,
+		GCPercent:  cfg.GCPercent,
+		Debug:      make(map[string]int64, len(cfg.Debug)),
+	}
+	for _, dv := range cfg.Debug {
+		r.Debug[dv.Name] = dv.Value
+	}
+	return r
+}
+
+// ConvertBuildInfo converts from proc.BuildInfo to api.ModuleInfo.
+func ConvertBuildInfo(bi *proc.BuildInfo, buildID string) *ModuleInfo {
+	r := &ModuleInfo{
+		GoVersion: bi.GoVersion,
+		BuildID:   buildID,
+		Path:      bi.Path,
+		Main:      convertModule(bi.Main),
+		Deps:      make([]Module, 0, len(bi.Deps)),
 	}
+	for _, dep := range bi.Deps {
+		r.Deps = append(r.Deps, convertModule(dep))
+	}
+	return r
+}
+
+func convertModule(m proc.Module) Module {
+	r := Module{Path: m.Path, Version: m.Version, Sum: m.Sum}
+	if m.Replace != nil {
+		replace := convertModule(*m.Replace)
+		r.Replace = &replace
+	}
+	return r
 }
 
 func LoadConfigToProc(cfg *LoadConfig) *proc.LoadConfig {
@@ -252,5 +423,6 @@ func LoadConfigFromProc(cfg *proc.LoadConfig) *LoadConfig {
 		cfg.MaxStringLen,
 		cfg.MaxArrayValues,
 		cfg.MaxStructFields,
+		"",
 	}
 }
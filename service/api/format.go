@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Formats of numeric values, see LoadConfig.Format.
+const (
+	// FormatHex prints integers in hexadecimal, e.g. 0x1f.
+	FormatHex = "hex"
+	// FormatBinary prints integers in binary, e.g. 0b11111.
+	FormatBinary = "binary"
+	// FormatChar prints integers as quoted characters, e.g. 'a'.
+	FormatChar = "char"
+	// FormatScientific prints floating point numbers in scientific
+	// notation, e.g. 1.5e+06.
+	FormatScientific = "scientific"
+)
+
+// ApplyFormat rewrites the values of v and of all its children using
+// format, one of FormatHex, FormatBinary, FormatChar, FormatScientific or
+// the empty string, which leaves the values unchanged.
+// Values of kinds not affected by format are left unchanged.
+func (v *Variable) ApplyFormat(format string) error {
+	if err := ValidFormat(format); err != nil || format == "" {
+		return err
+	}
+	v.applyFormat(format)
+	return nil
+}
+
+// ValidFormat returns an error if format is not one of the formats
+// accepted by ApplyFormat.
+func ValidFormat(format string) error {
+	switch format {
+	case "", FormatHex, FormatBinary, FormatChar, FormatScientific:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func (v *Variable) applyFormat(format string) {
+	for i := range v.Children {
+		v.Children[i].applyFormat(format)
+	}
+	if v.Unreadable != "" || v.Value == "" {
+		return
+	}
+	switch v.Kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(v.Value, 10, 64)
+		if err != nil {
+			return
+		}
+		switch format {
+		case FormatHex:
+			v.Value = fmt.Sprintf("%#x", n)
+		case FormatBinary:
+			if n < 0 {
+				v.Value = "-0b" + strconv.FormatUint(uint64(-n), 2)
+			} else {
+				v.Value = "0b" + strconv.FormatUint(uint64(n), 2)
+			}
+		case FormatChar:
+			v.Value = strconv.QuoteRune(rune(n))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(v.Value, 10, 64)
+		if err != nil {
+			return
+		}
+		switch format {
+		case FormatHex:
+			v.Value = fmt.Sprintf("%#x", n)
+		case FormatBinary:
+			v.Value = "0b" + strconv.FormatUint(n, 2)
+		case FormatChar:
+			v.Value = strconv.QuoteRune(rune(n))
+		}
+	case reflect.Float32, reflect.Float64:
+		if format != FormatScientific {
+			return
+		}
+		bitSize := 64
+		if v.Kind == reflect.Float32 {
+			bitSize = 32
+		}
+		f, err := strconv.ParseFloat(v.Value, bitSize)
+		if err != nil {
+			return
+		}
+		v.Value = strconv.FormatFloat(f, 'e', -1, bitSize)
+	}
+}
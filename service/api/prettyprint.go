@@ -108,7 +108,9 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 	case reflect.Complex64, reflect.Complex128:
 		fmt.Fprintf(buf, "(%s + %si)", v.Children[0].Value, v.Children[1].Value)
 	default:
-		if v.Value != "" {
+		if v.ConstName != "" {
+			fmt.Fprintf(buf, "%s (%s)", v.ConstName, v.Value)
+		} else if v.Value != "" {
 			buf.Write([]byte(v.Value))
 		} else {
 			fmt.Fprintf(buf, "(unknown %s)", v.Kind)
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// ImportState reads a snapshot written by ExportState. The snapshot is a
+// read-only view of the process at the time it was exported: only the
+// registers, the stacktraces and the variables of the frames of the
+// selected goroutine it contains can be examined.
+func ImportState(path string) (*StateSnapshot, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot StateSnapshot
+	if err := json.Unmarshal(buf, &snapshot); err != nil {
+		return nil, fmt.Errorf("could not read state snapshot %s: %v", path, err)
+	}
+	if snapshot.State == nil {
+		return nil, fmt.Errorf("could not read state snapshot %s: no state", path)
+	}
+	return &snapshot, nil
+}
+
+// Goroutine returns the goroutine gid, -1 selects the goroutine that was
+// selected when the snapshot was exported.
+func (s *StateSnapshot) Goroutine(gid int) (*GoroutineSnapshot, error) {
+	if gid < 0 {
+		if s.State.SelectedGoroutine == nil {
+			return nil, fmt.Errorf("no goroutine selected")
+		}
+		gid = s.State.SelectedGoroutine.ID
+	}
+	for i := range s.Goroutines {
+		if s.Goroutines[i].Goroutine != nil && s.Goroutines[i].Goroutine.ID == gid {
+			return &s.Goroutines[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown goroutine %d", gid)
+}
+
+// ListGoroutines returns all the goroutines of the snapshot.
+func (s *StateSnapshot) ListGoroutines() []*Goroutine {
+	r := make([]*Goroutine, 0, len(s.Goroutines))
+	for _, gsnap := range s.Goroutines {
+		r = append(r, gsnap.Goroutine)
+	}
+	return r
+}
+
+// Stacktrace returns at most depth+1 frames of the stacktrace of goroutine
+// gid.
+func (s *StateSnapshot) Stacktrace(gid, depth int) ([]Stackframe, error) {
+	gsnap, err := s.Goroutine(gid)
+	if err != nil {
+		return nil, err
+	}
+	if gsnap.StacktraceError != "" {
+		return nil, fmt.Errorf("could not read stacktrace of goroutine %d: %s", gsnap.Goroutine.ID, gsnap.StacktraceError)
+	}
+	if depth >= 0 && depth+1 < len(gsnap.Stacktrace) {
+		return gsnap.Stacktrace[:depth+1], nil
+	}
+	return gsnap.Stacktrace, nil
+}
+
+// ListRegisters returns the registers of thread threadID, -1 selects the
+// current thread.
+func (s *StateSnapshot) ListRegisters(threadID int) (Registers, error) {
+	if threadID < 0 {
+		if s.State.CurrentThread == nil {
+			return nil, fmt.Errorf("no current thread")
+		}
+		threadID = s.State.CurrentThread.ID
+	}
+	regs, ok := s.Registers[threadID]
+	if !ok {
+		return nil, fmt.Errorf("unknown thread %d", threadID)
+	}
+	return regs, nil
+}
+
+// ListLocalVariables returns the local variables of the frame selected by
+// scope. Only the frames of the selected goroutine contain variables.
+func (s *StateSnapshot) ListLocalVariables(scope EvalScope) ([]Variable, error) {
+	frame, err := s.frame(scope)
+	if err != nil {
+		return nil, err
+	}
+	return frame.Locals, nil
+}
+
+// ListFunctionArgs returns the arguments of the frame selected by scope.
+// Only the frames of the selected goroutine contain variables.
+func (s *StateSnapshot) ListFunctionArgs(scope EvalScope) ([]Variable, error) {
+	frame, err := s.frame(scope)
+	if err != nil {
+		return nil, err
+	}
+	return frame.Arguments, nil
+}
+
+func (s *StateSnapshot) frame(scope EvalScope) (*Stackframe, error) {
+	stack, err := s.Stacktrace(scope.GoroutineID, -1)
+	if err != nil {
+		return nil, err
+	}
+	gid := scope.GoroutineID
+	if gid < 0 || (s.State.SelectedGoroutine != nil && gid == s.State.SelectedGoroutine.ID) {
+		if scope.Frame < 0 || scope.Frame >= len(stack) {
+			return nil, fmt.Errorf("Frame %d does not exist", scope.Frame)
+		}
+		return &stack[scope.Frame], nil
+	}
+	return nil, fmt.Errorf("variables of goroutine %d were not exported", gid)
+}
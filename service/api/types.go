@@ -1,10 +1,13 @@
 package api
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 	"unicode"
 
 	"github.com/derekparker/delve/proc"
@@ -18,6 +21,10 @@ type DebuggerState struct {
 	CurrentThread *Thread `json:"currentThread,omitempty"`
 	// SelectedGoroutine is the currently selected goroutine
 	SelectedGoroutine *Goroutine `json:"currentGoroutine,omitempty"`
+	// SelectedFrame is the frame of the selected goroutine selected by
+	// SwitchFrame, the frames of the scopes with GoroutineID -1 are
+	// relative to it.
+	SelectedFrame int `json:"selectedFrame"`
 	// List of all the process threads
 	Threads []*Thread
 	// NextInProgress indicates that a next or step operation was interrupted by another breakpoint
@@ -25,6 +32,28 @@ type DebuggerState struct {
 	// While NextInProgress is set further requests for next or step may be rejected.
 	// Either execute continue until NextInProgress is false or call CancelNext
 	NextInProgress bool
+	// WatchOutOfScope lists the watchpoints removed because the local
+	// variable they were watching went out of scope.
+	WatchOutOfScope []*Breakpoint `json:"watchOutOfScope,omitempty"`
+	// TracepointResults are the messages logged by tracepoints with a
+	// LogMessage since the process was resumed, in the order they were
+	// reached.
+	TracepointResults []TracepointResult `json:"tracepointResults,omitempty"`
+	// Output is the standard output and error written by the process
+	// since the previous state was returned. The output of each stream is
+	// in the order it was written, output written to different streams is
+	// not necessarily. Only set if the debugger was started with
+	// RedirectOutput.
+	Output []OutputEvent `json:"output,omitempty"`
+	// StopReason is the reason why the process stopped the last time it
+	// was resumed by a continue command: one of StopManual,
+	// StopBreakpoint, StopExited, StopSignal or StopNext. It is empty if
+	// the process stopped because a step command completed or was never
+	// resumed.
+	StopReason string `json:"stopReason,omitempty"`
+	// Signal is the signal the current thread was stopped by, if the
+	// policy of the signal is to stop the process, see SetSignalPolicy.
+	Signal int `json:"signal,omitempty"`
 	// Exited indicates whether the debugged process has exited.
 	Exited     bool `json:"exited"`
 	ExitStatus int  `json:"exitStatus"`
@@ -39,6 +68,10 @@ type Breakpoint struct {
 	ID int `json:"id"`
 	// User defined name of the breakpoint
 	Name string `json:"name"`
+	// Group is the name shared by the breakpoints created together by
+	// CreateBreakpoints, they can be cleared together with
+	// ClearBreakpointGroup.
+	Group string `json:"group,omitempty"`
 	// Addr is the address of the breakpoint.
 	Addr uint64 `json:"addr"`
 	// File is the source file for the breakpoint.
@@ -56,6 +89,12 @@ type Breakpoint struct {
 	Tracepoint bool `json:"continue"`
 	// retrieve goroutine information
 	Goroutine bool `json:"goroutine"`
+	// GoroutineID, if not zero, is the only goroutine the breakpoint stops
+	// on, hits by other goroutines are not counted and do not stop.
+	GoroutineID int `json:"goroutineID,omitempty"`
+	// ThreadID, if not zero, is the only thread the breakpoint stops on,
+	// both ThreadID and Cond must be satisfied.
+	ThreadID int `json:"threadID,omitempty"`
 	// number of stack frames to retrieve
 	Stacktrace int `json:"stacktrace"`
 	// expressions to evaluate
@@ -68,6 +107,67 @@ type Breakpoint struct {
 	HitCount map[string]uint64 `json:"hitCount"`
 	// number of times a breakpoint has been reached
 	TotalHitCount uint64 `json:"totalHitCount"`
+	// number of times the breakpoint will be reached without stopping,
+	// it is decremented every time the breakpoint is reached
+	Ignore int `json:"ignore"`
+	// HitCond is a condition on TotalHitCount, an operator followed by an
+	// integer expression such as ">= 5" or "% 2 == 0". Once Cond is
+	// satisfied the breakpoint stops only if HitCond is also true.
+	HitCond string `json:"hitCond,omitempty"`
+	// Aggregate is a numeric expression evaluated every time a tracepoint
+	// is reached, its value is accumulated in the statistics returned by
+	// TracepointStats and the tracepoint does not stop the process.
+	Aggregate string `json:"aggregate,omitempty"`
+	// LogMessage is a message rendered every time a tracepoint is reached,
+	// expressions between braces are replaced by their value. The
+	// tracepoint does not stop the process, the message is returned in the
+	// TracepointResults of the next DebuggerState.
+	LogMessage string `json:"logMessage,omitempty"`
+	// LogToFile, if not empty, is the path of a file on the machine running
+	// the debugger where LogMessage is appended, one line every time the
+	// tracepoint is reached, instead of stopping the process.
+	// The file is truncated when the process is restarted.
+	LogToFile string `json:"logToFile,omitempty"`
+	// SkipPrologue, when set on a breakpoint created on FunctionName
+	// without a line offset, decides whether the breakpoint is placed after
+	// the prologue of the function (the default) or on its entry point.
+	SkipPrologue *bool `json:"skipPrologue,omitempty"`
+	// ActiveAfter, if not zero, is how long the process must have been
+	// running, since it was started or attached to, before the breakpoint
+	// becomes active. Until then reaching the breakpoint has no effect.
+	// The time the process spends stopped in the debugger is not counted.
+	ActiveAfter time.Duration `json:"activeAfter,omitempty"`
+	// ActiveUntil, if not zero, is how long the process must have been
+	// running for the breakpoint to stop being active.
+	ActiveUntil time.Duration `json:"activeUntil,omitempty"`
+	// Anchored, when set on a new breakpoint, records its location as a
+	// statement of its function instead of an address, when the process is
+	// restarted the breakpoint is moved to the same statement of the
+	// function even if its line number changed after a rebuild.
+	Anchored bool `json:"anchored,omitempty"`
+	// AnchorStatement is the index of the statement within FunctionName
+	// where an anchored breakpoint is set.
+	AnchorStatement int `json:"anchorStatement,omitempty"`
+	// WatchExpr, if not empty, is the expression watched by a hardware
+	// watchpoint, Addr is the address of the watched memory.
+	WatchExpr string `json:"watchExpr,omitempty"`
+	// WatchKind is the kind of memory access a watchpoint stops on.
+	WatchKind WatchKind `json:"watchKind,omitempty"`
+	// Template is the name of a breakpoint template, defined with
+	// DefineBreakpointTemplate, used by CreateBreakpoint to fill the
+	// properties of the breakpoint that are not set.
+	Template string `json:"template,omitempty"`
+	// CondEvalTime is the total time spent evaluating Cond.
+	CondEvalTime time.Duration `json:"condEvalTime"`
+	// CaptureTime is the total time spent retrieving the goroutine,
+	// stacktrace, variables, arguments and locals requested by the
+	// breakpoint.
+	CaptureTime time.Duration `json:"captureTime"`
+	// InlinedCallSites is filled when FunctionName refers to a function
+	// that only exists in inlined form, a breakpoint is created at each one
+	// of these locations. Amending or clearing this breakpoint amends or
+	// clears all of them.
+	InlinedCallSites []Location `json:"inlinedCallSites,omitempty"`
 }
 
 func ValidBreakpointName(name string) error {
@@ -96,6 +196,12 @@ type Thread struct {
 	Line int `json:"line"`
 	// Function is function information at the program counter. May be nil.
 	Function *Function `json:"function,omitempty"`
+	// PCSymbol is the program counter as the name of the function
+	// followed by the offset from its entry point, i.e. main.main+0x2a.
+	PCSymbol string `json:"pcSymbol,omitempty"`
+	// Name is the name the operating system assigned to the thread, if
+	// available.
+	Name string `json:"name,omitempty"`
 
 	// ID of the goroutine running on this thread
 	GoroutineID int `json:"goroutineID"`
@@ -111,12 +217,43 @@ type Location struct {
 	File     string    `json:"file"`
 	Line     int       `json:"line"`
 	Function *Function `json:"function,omitempty"`
+	// Source is the text of Line, only filled in by FindLocationVerbose.
+	Source string `json:"source,omitempty"`
+}
+
+// SourceLine is a numbered line of a source file.
+type SourceLine struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+	// Current is set for the line the selected goroutine is stopped at,
+	// in the selected frame.
+	Current bool `json:"current,omitempty"`
 }
 
 type Stackframe struct {
 	Location
 	Locals    []Variable
 	Arguments []Variable
+	// Defers are the deferred calls registered by this frame that have not
+	// run yet, most recent first. They are only returned when requested.
+	Defers []Defer
+}
+
+// Defer is a deferred function call pending on a stack frame.
+type Defer struct {
+	// DeferredLoc is the entry point of the deferred function.
+	DeferredLoc Location `json:"deferredLoc"`
+	// DeferLoc is the location of the defer statement.
+	DeferLoc Location `json:"deferLoc"`
+	// SP is the stack pointer of the frame that deferred the call.
+	SP uint64 `json:"sp"`
+	// Arguments of the deferred call, only loaded when local variables and
+	// arguments are requested too. Binaries built with go1.17 or later
+	// never store arguments with deferred calls.
+	Arguments []Variable `json:"arguments,omitempty"`
+	// Unreadable is set if the deferred call could not be read, for
+	// example because it is an open-coded defer.
+	Unreadable string `json:"unreadable,omitempty"`
 }
 
 func (frame *Stackframe) Var(name string) *Variable {
@@ -133,6 +270,39 @@ func (frame *Stackframe) Var(name string) *Variable {
 	return nil
 }
 
+// DiffStacks compares the stacktraces a and b, starting from their
+// outermost frame, and returns the index, counted from the outermost
+// frame, of the first frame where they call a different function.
+// If one stacktrace is a prefix of the other the length of the shortest
+// one is returned. Both stacktraces must be complete for the comparison to
+// be meaningful.
+func DiffStacks(a, b []Stackframe) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		fa, fb := a[len(a)-1-i].Function, b[len(b)-1-i].Function
+		if fa == nil || fb == nil || fa.Name != fb.Name {
+			return i
+		}
+	}
+	return n
+}
+
+// Method describes a method of a type.
+type Method struct {
+	// Name is the name of the method.
+	Name string `json:"name"`
+	// Receiver is the type of the receiver, the type or a pointer to it.
+	Receiver string `json:"receiver"`
+	// Signature is the type of the method, excluding the receiver, for
+	// example "func(n int) (string, error)".
+	Signature string `json:"signature"`
+	// Entry is the entry point of the method.
+	Entry uint64 `json:"entry"`
+}
+
 // Function represents thread-scoped function information.
 type Function struct {
 	// Name is the function name.
@@ -160,6 +330,9 @@ type Variable struct {
 	//Strings have their length capped at proc.maxArrayValues, use Len for the real length of a string
 	//Function variables will store the name of the function in this field
 	Value string `json:"value"`
+	// ConstName is the name of the constants of the type of an integer
+	// variable that have the same value, separated by '/', if any.
+	ConstName string `json:"constName,omitempty"`
 
 	// Number of elements in an array or a slice, number of keys for a map, number of struct members for a struct, length of strings
 	Len int64 `json:"len"`
@@ -175,6 +348,13 @@ type Variable struct {
 
 	// Unreadable addresses will have this field set
 	Unreadable string `json:"unreadable"`
+
+	// Line where a local variable was declared, only set when listing
+	// the local variables of all lexical blocks
+	DeclLine int64 `json:"declLine,omitempty"`
+	// Shadowed is set for local variables hidden by a variable with the
+	// same name declared in an inner lexical block
+	Shadowed bool `json:"shadowed,omitempty"`
 }
 
 // LoadConfig describes how to load values from target's memory
@@ -183,12 +363,20 @@ type LoadConfig struct {
 	FollowPointers bool
 	// MaxVariableRecurse is how far to recurse when evaluating nested types.
 	MaxVariableRecurse int
-	// MaxStringLen is the maximum number of bytes read from a string
+	// MaxStringLen is the maximum number of bytes read from a string, -1
+	// will read the whole string, up to 1MB.
 	MaxStringLen int
-	// MaxArrayValues is the maximum number of elements read from an array, a slice or a map.
+	// MaxArrayValues is the maximum number of elements read from an array, a
+	// slice or a map, -1 will read all elements, up to about a million.
+	// Reading very long strings or very large arrays is slow and produces
+	// large responses, -1 should only be used to read a single value.
 	MaxArrayValues int
 	// MaxStructFields is the maximum number of fields read from a struct, -1 will read all fields.
 	MaxStructFields int
+	// Format, if not empty, changes how numeric values are printed, it
+	// must be one of FormatHex, FormatBinary, FormatChar or
+	// FormatScientific and applies to all the children of a variable.
+	Format string
 }
 
 // Goroutine represents the information relevant to Delve from the runtime's
@@ -204,6 +392,41 @@ type Goroutine struct {
 	GoStatementLoc Location `json:"goStatementLoc"`
 	// ID of the associated thread for running goroutines
 	ThreadID int `json:"threadID"`
+	// Address of the runtime.g struct of the goroutine
+	Addr uint64 `json:"addr"`
+	// Status is the scheduling status of the goroutine, one of the
+	// GoroutineXxx constants.
+	Status string `json:"status"`
+	// WaitReason is the reason a waiting goroutine was parked.
+	WaitReason string `json:"waitReason,omitempty"`
+	// Labels are the pprof labels of the goroutine, the map is empty if it
+	// has none.
+	Labels map[string]string `json:"labels"`
+}
+
+// Scheduling status of a goroutine.
+const (
+	GoroutineIdle      = "idle"
+	GoroutineRunnable  = "runnable"
+	GoroutineRunning   = "running"
+	GoroutineSyscall   = "syscall"
+	GoroutineWaiting   = "waiting"
+	GoroutineDead      = "dead"
+	GoroutineCopystack = "copystack"
+	GoroutineUnknown   = "unknown"
+)
+
+// GoroutineFilter selects the goroutines returned by ListGoroutines, the
+// zero value selects all goroutines.
+type GoroutineFilter struct {
+	// Status, if not empty, selects the goroutines with this Status.
+	Status string `json:"status,omitempty"`
+	// Function, if not empty, is a regular expression matched against the
+	// name of the function at the top of the stack of the goroutine.
+	Function string `json:"function,omitempty"`
+	// Label, if not empty, selects the goroutines with a pprof label. It is
+	// either a key, matching any value, or key=value.
+	Label string `json:"label,omitempty"`
 }
 
 // DebuggerCommand is a command which changes the debugger's execution state.
@@ -216,6 +439,8 @@ type DebuggerCommand struct {
 	// GoroutineID is used to specify which thread to use with the SwitchGoroutine
 	// command.
 	GoroutineID int `json:"goroutineID,omitempty"`
+	// FunctionName is the function entered by the StepInto command.
+	FunctionName string `json:"functionName,omitempty"`
 }
 
 // Informations about the current breakpoint
@@ -225,6 +450,7 @@ type BreakpointInfo struct {
 	Variables  []Variable   `json:"variables,omitempty"`
 	Arguments  []Variable   `json:"arguments,omitempty"`
 	Locals     []Variable   `json:"locals,omitempty"`
+	LogMessage string       `json:"logMessage,omitempty"`
 }
 
 type EvalScope struct {
@@ -235,14 +461,26 @@ type EvalScope struct {
 const (
 	// Continue resumes process execution.
 	Continue = "continue"
+	// ContinueUntilGoroutineChange resumes process execution until a
+	// goroutine other than the selected one starts running.
+	ContinueUntilGoroutineChange = "continueUntilGoroutineChange"
 	// Step continues to next source line, entering function calls.
 	Step = "step"
 	// StepOut continues to the return address of the current function
 	StepOut = "stepOut"
+	// StepInto continues to the next source line, entering only calls to
+	// the function specified by FunctionName.
+	StepInto = "stepInto"
 	// SingleStep continues for exactly 1 cpu instruction.
 	StepInstruction = "stepInstruction"
 	// Next continues to the next source line, not entering function calls.
 	Next = "next"
+	// StepBack is reserved for running the process backward to the
+	// previous source line. No backend supports it yet, it always fails.
+	StepBack = "stepBack"
+	// ReverseContinue is reserved for running the process backward until a
+	// breakpoint is reached. No backend supports it yet, it always fails.
+	ReverseContinue = "reverseContinue"
 	// SwitchThread switches the debugger's current thread context.
 	SwitchThread = "switchThread"
 	// SwitchGoroutine switches the debugger's current thread context to the thread running the specified goroutine
@@ -251,11 +489,32 @@ const (
 	Halt = "halt"
 )
 
+const (
+	// ChanSend selects send operations in BreakOnChannelOp.
+	ChanSend = "send"
+	// ChanRecv selects receive operations in BreakOnChannelOp.
+	ChanRecv = "recv"
+)
+
+// WatchKind is the kind of memory access that triggers a watchpoint.
+type WatchKind uint8
+
+const (
+	// WatchRead stops on reads of the watched memory. On amd64 writes
+	// also trigger watchpoints of this kind.
+	WatchRead WatchKind = WatchKind(proc.WatchRead)
+	// WatchWrite stops on writes to the watched memory.
+	WatchWrite WatchKind = WatchKind(proc.WatchWrite)
+	// WatchReadWrite stops on both reads and writes.
+	WatchReadWrite WatchKind = WatchRead | WatchWrite
+)
+
 type AssemblyFlavour int
 
 const (
 	GNUFlavour   = AssemblyFlavour(proc.GNUFlavour)
 	IntelFlavour = AssemblyFlavour(proc.IntelFlavour)
+	ATTFlavour   = AssemblyFlavour(proc.ATTFlavour)
 )
 
 // AsmInstruction represents one assembly instruction at some address
@@ -272,10 +531,206 @@ type AsmInstruction struct {
 	Breakpoint bool
 	// In AtPC is true this is the instruction the current thread is stopped at
 	AtPC bool
+	// ResolvedTarget is the absolute address of the target of a relative
+	// jump or call or of a RIP relative memory operand, 0 if the
+	// instruction has no PC relative operand.
+	ResolvedTarget uint64
 }
 
 type AsmInstructions []AsmInstruction
 
+// SyncObject is the decoded internal state of a sync.WaitGroup, sync.Once
+// or sync.Cond.
+type SyncObject struct {
+	// Type is the name of the type of the object.
+	Type string `json:"type"`
+	// Counter is the counter of a sync.WaitGroup.
+	Counter int64 `json:"counter"`
+	// Waiters is the number of goroutines blocked in the Wait method of a
+	// sync.WaitGroup or sync.Cond.
+	Waiters int64 `json:"waiters"`
+	// Done is true if the function of a sync.Once has been executed.
+	Done bool `json:"done"`
+}
+
+// StateSnapshot is the state of a stopped process as seen by the
+// debugger, as written by ExportState and read by ImportState.
+type StateSnapshot struct {
+	Pid   int            `json:"pid"`
+	State *DebuggerState `json:"state"`
+	// Registers maps the ID of each thread to its registers.
+	Registers map[int]Registers `json:"registers"`
+	// Goroutines contains the stacktrace of every goroutine, the frames of
+	// the selected goroutine include their arguments and local variables.
+	Goroutines []GoroutineSnapshot `json:"goroutines"`
+}
+
+// GoroutineSnapshot is a goroutine and its stacktrace.
+type GoroutineSnapshot struct {
+	Goroutine  *Goroutine   `json:"goroutine"`
+	Stacktrace []Stackframe `json:"stacktrace"`
+	// StacktraceError is the error reading the stacktrace, if any.
+	StacktraceError string `json:"stacktraceError,omitempty"`
+}
+
+// TracepointStats are the statistics of the values of the Aggregate
+// expression of a tracepoint.
+type TracepointStats struct {
+	// Count is the number of values collected.
+	Count uint64  `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Sum   float64 `json:"sum"`
+	// Histogram maps the lower bound of each bucket to the number of values
+	// in it. Buckets are powers of two, values whose absolute value is less
+	// than one are counted in bucket 0 and negative values in buckets with
+	// a negative lower bound.
+	Histogram map[int64]uint64 `json:"histogram"`
+}
+
+// TracepointResult is a message logged by a tracepoint with a LogMessage.
+type TracepointResult struct {
+	// BreakpointID is the ID of the tracepoint.
+	BreakpointID int    `json:"breakpointID"`
+	GoroutineID  int    `json:"goroutineID"`
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	// Message is the LogMessage of the tracepoint with its expressions
+	// replaced by their values.
+	Message string `json:"message"`
+}
+
+// OutputEvent is output written by the process to its standard output or
+// error.
+type OutputEvent struct {
+	// Stream is OutputStdout or OutputStderr.
+	Stream string `json:"stream"`
+	Data   string `json:"data"`
+}
+
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+const (
+	// StopManual means that the process was stopped by Halt.
+	StopManual = "manual"
+	// StopBreakpoint means that the process reached a breakpoint or a
+	// watchpoint.
+	StopBreakpoint = "breakpoint"
+	// StopExited means that the process exited.
+	StopExited = "exited"
+	// StopSignal means that the process was stopped by a signal or trap
+	// not caused by the debugger, for example a call to runtime.Breakpoint.
+	StopSignal = "signal"
+	// StopNext means that the process reached the location where a next,
+	// step or step out operation that was interrupted, or ContinueTo, was
+	// meant to stop.
+	StopNext = "next"
+)
+
+// Register is the name and value of a CPU register.
+type Register struct {
+	Name  string
+	Value uint64
+	// Changed is true if the value of the register is different from
+	// what it was the last time the process was resumed, it is only set
+	// for the thread that was current at that time.
+	Changed bool
+	// Flags is the value of each status flag, only set for the flags
+	// register.
+	Flags map[string]bool `json:"flags,omitempty"`
+}
+
+// Registers is the list of CPU registers of a thread.
+type Registers []Register
+
+// String returns a representation of the registers, one per line.
+// Registers that changed since the last time the process was resumed
+// are marked with an asterisk.
+func (regs Registers) String() string {
+	var buf bytes.Buffer
+	for _, reg := range regs {
+		changed := ""
+		if reg.Changed {
+			changed = " *"
+		}
+		flags := ""
+		if reg.Flags != nil {
+			var set []string
+			for _, flag := range proc.CPUFlags {
+				if reg.Flags[flag.Name] {
+					set = append(set, flag.Name)
+				}
+			}
+			flags = " [" + strings.Join(set, " ") + "]"
+		}
+		fmt.Fprintf(&buf, "%8s = %0#16x%s%s\n", reg.Name, reg.Value, flags, changed)
+	}
+	return buf.String()
+}
+
+// MemWord is a word of memory read from the target process.
+type MemWord struct {
+	// Addr is the address of the word.
+	Addr uint64 `json:"addr"`
+	// Value is the value of the word.
+	Value uint64 `json:"value"`
+	// PointerKind is what the value points to, if it is a pointer: one of
+	// "code", "data", "heap" or the empty string.
+	PointerKind string `json:"pointerKind,omitempty"`
+	// Symbol is the function or package variable the value points into,
+	// followed by the offset from its start.
+	Symbol string `json:"symbol,omitempty"`
+}
+
+// Reference is a word of memory, in a goroutine stack or in a package
+// variable, that contains a pointer to some address.
+type Reference struct {
+	// Addr is the address of the word containing the pointer.
+	Addr uint64 `json:"addr"`
+	// GoroutineID is the goroutine whose stack contains the pointer, 0 if
+	// the pointer is inside a package variable.
+	GoroutineID int `json:"goroutineID,omitempty"`
+	// Symbol is the package variable containing the pointer, followed by
+	// the offset from its start.
+	Symbol string `json:"symbol,omitempty"`
+}
+
+// RuntimeConfig is the configuration of the Go runtime of the target.
+type RuntimeConfig struct {
+	// GOMAXPROCS is the number of Ps used by the scheduler.
+	GOMAXPROCS int64 `json:"gomaxprocs"`
+	// GCPercent is the value of GOGC, negative if the garbage collector
+	// is disabled.
+	GCPercent int64 `json:"gcPercent"`
+	// Debug maps GODEBUG settings, as parsed by the runtime, to their value.
+	Debug map[string]int64 `json:"debug"`
+}
+
+// ModuleInfo is the build information embedded in the executable.
+type ModuleInfo struct {
+	// GoVersion is the version of Go that produced the executable.
+	GoVersion string `json:"goVersion"`
+	// BuildID is the Go build ID of the executable.
+	BuildID string `json:"buildID"`
+	// Path is the package path of the main package.
+	Path string `json:"path"`
+	// Main is the main module.
+	Main Module `json:"main"`
+	// Deps are the module dependencies.
+	Deps []Module `json:"deps"`
+}
+
+// Module describes a module used to build the executable.
+type Module struct {
+	Path    string  `json:"path"`
+	Version string  `json:"version"`
+	Sum     string  `json:"sum"`
+	Replace *Module `json:"replace,omitempty"`
+}
+
 type GetVersionIn struct {
 }
 
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"time"
+
 	"github.com/derekparker/delve/service/api"
 )
 
@@ -15,25 +17,48 @@ type Client interface {
 
 	// Restarts program.
 	Restart() error
+	// RestartWith restarts program with new arguments, following the
+	// executable, and environment. Nil args or env keep the previous ones.
+	RestartWith(args, env []string) error
 
 	// GetState returns the current debugger state.
 	GetState() (*api.DebuggerState, error)
 
 	// Continue resumes process execution.
 	Continue() <-chan *api.DebuggerState
+	// ContinueUntilGoroutineChange resumes process execution until a goroutine other than the selected one starts running.
+	ContinueUntilGoroutineChange() <-chan *api.DebuggerState
+	// ContinueTo resumes process execution until the location loc is reached or the process stops for another reason.
+	ContinueTo(loc string) (*api.DebuggerState, error)
+	// ContinueWithSignal resumes process execution delivering sig, instead of the signal it was stopped by, to the current thread.
+	ContinueWithSignal(sig int) (*api.DebuggerState, error)
+	// SetSignalPolicy sets whether the process stops when it receives sig and whether sig is delivered to it.
+	SetSignalPolicy(sig int, stop, pass bool) error
 	// Next continues to the next source line, not entering function calls.
 	Next() (*api.DebuggerState, error)
 	// Step continues to the next source line, entering function calls.
 	Step() (*api.DebuggerState, error)
+	// StepInto continues to the next source line, entering only the calls to funcName made by the current line.
+	StepInto(scope api.EvalScope, funcName string) (*api.DebuggerState, error)
 	// StepOut continues to the return address of the current function
 	StepOut() (*api.DebuggerState, error)
+	// StepBack is reserved for running the process backward to the previous source line, it is not supported by any backend yet.
+	StepBack() (*api.DebuggerState, error)
+	// ReverseContinue is reserved for running the process backward to the previous breakpoint hit, it is not supported by any backend yet.
+	ReverseContinue() (*api.DebuggerState, error)
 
 	// SingleStep will step a single cpu instruction.
 	StepInstruction() (*api.DebuggerState, error)
+	// ContinueRecordingLines single steps the selected goroutine until a breakpoint is
+	// reached or maxInstructions instructions are executed, returning the source lines executed.
+	ContinueRecordingLines(maxInstructions int) (*api.DebuggerState, []api.Location, error)
 	// SwitchThread switches the current thread context.
 	SwitchThread(threadID int) (*api.DebuggerState, error)
 	// SwitchGoroutine switches the current goroutine (and the current thread as well)
 	SwitchGoroutine(goroutineID int) (*api.DebuggerState, error)
+	// SwitchFrame selects a frame of the current goroutine, the frames of
+	// the scopes of the current goroutine become relative to it.
+	SwitchFrame(frame int) (*api.DebuggerState, error)
 	// Halt suspends the process.
 	Halt() (*api.DebuggerState, error)
 
@@ -43,15 +68,27 @@ type Client interface {
 	GetBreakpointByName(name string) (*api.Breakpoint, error)
 	// CreateBreakpoint creates a new breakpoint.
 	CreateBreakpoint(*api.Breakpoint) (*api.Breakpoint, error)
+	// CreateBreakpoints creates all the breakpoints, or none of them if one fails, as members of group.
+	CreateBreakpoints(breakpoints []*api.Breakpoint, group string) ([]*api.Breakpoint, error)
+	// CreatePackageTracepoints creates a tracepoint at the entry of every function matching filter, failing if more than max functions match, and returns the functions that were skipped.
+	CreatePackageTracepoints(filter string, max int) ([]*api.Breakpoint, []string, error)
+	// DefineBreakpointTemplate defines a template used to fill the unset properties of breakpoints created with Template set to name.
+	DefineBreakpointTemplate(name string, template api.Breakpoint) error
 	// ListBreakpoints gets all breakpoints.
 	ListBreakpoints() ([]*api.Breakpoint, error)
 	// ClearBreakpoint deletes a breakpoint by ID.
 	ClearBreakpoint(id int) (*api.Breakpoint, error)
 	// ClearBreakpointByName deletes a breakpoint by name
 	ClearBreakpointByName(name string) (*api.Breakpoint, error)
+	// ClearBreakpointGroup deletes all the breakpoints of a group created by CreateBreakpoints.
+	ClearBreakpointGroup(group string) ([]*api.Breakpoint, error)
 	// Allows user to update an existing breakpoint for example to change the information
 	// retrieved when the breakpoint is hit or to change, add or remove the break condition
 	AmendBreakpoint(*api.Breakpoint) error
+	// PendingBreakpointsAhead returns a best effort guess of the breakpoints goroutine gid will reach next.
+	PendingBreakpointsAhead(gid int) ([]*api.Breakpoint, error)
+	// TracepointStats returns the statistics collected by the Aggregate expression of a tracepoint.
+	TracepointStats(id int) (*api.TracepointStats, error)
 	// Cancels a Next or Step call that was interrupted by a manual stop or by another breakpoint
 	CancelNext() error
 
@@ -62,36 +99,115 @@ type Client interface {
 
 	// ListPackageVariables lists all package variables in the context of the current thread.
 	ListPackageVariables(filter string, cfg api.LoadConfig) ([]api.Variable, error)
+	// GetLoadConfig returns the load configuration used by the server when a call passes a zero LoadConfig.
+	GetLoadConfig() (api.LoadConfig, error)
+	// SetLoadConfig changes the load configuration used by the server when a call passes a zero LoadConfig.
+	SetLoadConfig(cfg api.LoadConfig) error
 	// EvalVariable returns a variable in the context of the current thread.
 	EvalVariable(scope api.EvalScope, symbol string, cfg api.LoadConfig) (*api.Variable, error)
+	// EvalVariableTimeout is like EvalVariable but aborts the evaluation if it takes longer than timeout.
+	EvalVariableTimeout(scope api.EvalScope, symbol string, cfg api.LoadConfig, timeout time.Duration) (*api.Variable, error)
+	// EvalAcrossGoroutines evaluates expr in the topmost frame of every goroutine, by goroutine ID,
+	// skipping goroutines where it uses names not visible in that frame. Other errors set Unreadable.
+	EvalAcrossGoroutines(expr string, cfg api.LoadConfig) (map[int]*api.Variable, error)
+	// EvalPair evaluates exprA in scopeA and exprB in scopeB in a single call, errors are reported separately for each expression.
+	EvalPair(scopeA api.EvalScope, exprA string, scopeB api.EvalScope, exprB string, cfg api.LoadConfig) (a, b *api.Variable, errA, errB error)
+
+	// UnwrapError returns the chain of errors wrapped by the error expr, starting with expr itself.
+	UnwrapError(scope api.EvalScope, expr string, cfg api.LoadConfig) ([]api.Variable, error)
+
+	// SyncObjectInfo returns the decoded state of the sync.WaitGroup, sync.Once or sync.Cond expr.
+	SyncObjectInfo(scope api.EvalScope, expr string) (*api.SyncObject, error)
+
+	// VariableAddress returns the address and size of the memory location of expr.
+	VariableAddress(scope api.EvalScope, expr string) (addr uint64, size int64, err error)
 
 	// SetVariable sets the value of a variable
 	SetVariable(scope api.EvalScope, symbol, value string) error
 
 	// ListSources lists all source files in the process matching filter.
 	ListSources(filter string) ([]string, error)
+	// ListSource returns the lines of file, read by the server, from line-context to line+context.
+	ListSource(file string, line, context int) ([]api.SourceLine, error)
 	// ListFunctions lists all functions in the process matching filter.
 	ListFunctions(filter string) ([]string, error)
 	// ListTypes lists all types in the process matching filter.
 	ListTypes(filter string) ([]string, error)
-	// ListLocals lists all local variables in scope.
-	ListLocalVariables(scope api.EvalScope, cfg api.LoadConfig) ([]api.Variable, error)
-	// ListFunctionArgs lists all arguments to the current function.
-	ListFunctionArgs(scope api.EvalScope, cfg api.LoadConfig) ([]api.Variable, error)
+	// ListMethods lists the methods of typeName and of pointers to typeName.
+	ListMethods(typeName string) ([]api.Method, error)
+	// ListLocals lists all local variables in scope whose name matches the regexp filter, an empty filter matches all of them.
+	ListLocalVariables(scope api.EvalScope, filter string, cfg api.LoadConfig) ([]api.Variable, error)
+	// ListLocalVariablesAllBlocks lists the local variables of all lexical blocks containing the current line, including shadowed ones.
+	ListLocalVariablesAllBlocks(scope api.EvalScope, cfg api.LoadConfig) ([]api.Variable, error)
+	// ListFunctionArgs lists all arguments to the current function whose name matches the regexp filter, an empty filter matches all of them.
+	ListFunctionArgs(scope api.EvalScope, filter string, cfg api.LoadConfig) ([]api.Variable, error)
+	// CallFrameValues returns the receiver of the current function, nil if it is not a method, and its other arguments.
+	CallFrameValues(scope api.EvalScope, cfg api.LoadConfig) (*api.Variable, []api.Variable, error)
 	// ListRegisters lists registers and their values.
 	ListRegisters() (string, error)
-
-	// ListGoroutines lists all goroutines.
-	ListGoroutines() ([]*api.Goroutine, error)
+	// ListRegisterValues returns the registers of the current thread, marking the ones that changed since the process was last resumed.
+	ListRegisterValues() (api.Registers, error)
+	// ListThreadRegisters lists the registers of every thread, by thread ID.
+	ListThreadRegisters() (map[int]api.Registers, error)
+	// SetRegister sets a register of the specified thread, -1 for the current thread.
+	SetRegister(threadID int, name, value string) error
+
+	// ListGoroutines lists the goroutines sorted by ID, skipping the first
+	// start ones and returning at most count goroutines if count is greater
+	// than zero, and returns the total number of goroutines.
+	ListGoroutines(start, count int) ([]*api.Goroutine, int, error)
+	// ListGoroutinesWithFilter is like ListGoroutines but only lists the goroutines selected by filter.
+	ListGoroutinesWithFilter(filter api.GoroutineFilter, start, count int) ([]*api.Goroutine, int, error)
+	// GoroutineByAddress returns the goroutine whose runtime.g struct is stored at addr.
+	GoroutineByAddress(addr uint64) (*api.Goroutine, error)
+	// GoroutineStackInfo returns the stack bounds of goroutine gid and how many bytes of it are in use.
+	GoroutineStackInfo(gid int) (lo, hi, used uint64, err error)
+	// BlockingChain returns the chain of goroutines that goroutine gid is waiting for, through channel operations.
+	BlockingChain(gid int) ([]api.Goroutine, error)
 
 	// Returns stacktrace
 	Stacktrace(int, int, *api.LoadConfig) ([]api.Stackframe, error)
+	// StacktraceDefers is like Stacktrace but also returns the deferred calls pending on each frame.
+	StacktraceDefers(goroutineID, depth int, cfg *api.LoadConfig) ([]api.Stackframe, error)
+
+	// ExamineMemory reads count words of wordSize bytes starting at addr, optionally symbolizing them as pointers.
+	ExamineMemory(addr uint64, count, wordSize int, asPointers bool) ([]api.MemWord, error)
+	// ReadMemory reads length bytes starting at addr, if the range crosses unmapped memory the readable prefix is returned with an error.
+	ReadMemory(addr uint64, length int) ([]byte, error)
+	// WriteMemory writes data starting at addr and returns the number of bytes written.
+	WriteMemory(addr uint64, data []byte) (int, error)
+
+	// DirectRootReferences returns the words of goroutine stacks and package variables that contain a pointer to addr.
+	DirectRootReferences(addr uint64) ([]api.Reference, error)
+
+	// RuntimeConfig returns GOMAXPROCS, the GC percent and the GODEBUG settings of the target.
+	RuntimeConfig() (*api.RuntimeConfig, error)
+
+	// ModuleInfo returns the Go module information and build ID embedded in the executable.
+	ModuleInfo() (*api.ModuleInfo, error)
+
+	// ExportState writes a JSON snapshot of the registers, goroutine stacks and current locals of the process to path, on the machine running the client, see api.ImportState.
+	ExportState(path string) error
+	// DiffStacks returns the stacktraces of two goroutines and the index, counted from the outermost frame, of the first frame where they differ.
+	DiffStacks(goroutineA, goroutineB int) (int, []api.Stackframe, []api.Stackframe, error)
+	// BreakOnChannelOp sets breakpoints that stop when a goroutine sends to (op == api.ChanSend) or receives from (op == api.ChanRecv) the channel expr, an empty op selects both.
+	BreakOnChannelOp(scope api.EvalScope, expr string, op string) ([]*api.Breakpoint, error)
+	// CreateWatchpoint sets a hardware watchpoint on the memory of expr that stops on the accesses selected by kind.
+	CreateWatchpoint(scope api.EvalScope, expr string, kind api.WatchKind) (*api.Breakpoint, error)
+	// GCPercent returns the garbage collection target percentage of the process, a negative value means that the garbage collector is disabled.
+	GCPercent() (int, error)
+	// ForceGC runs a garbage collection in the process.
+	ForceGC() error
+	// SetGCPercent sets the garbage collection target percentage of the process and returns the previous value.
+	SetGCPercent(pct int) (int, error)
+	// ActiveFunctions returns, for each function on the stack of any goroutine, the number of goroutines that have it on their stack.
+	ActiveFunctions() (map[string]int, error)
 
 	// Returns whether we attached to a running process or not
 	AttachedToExistingProcess() bool
 
 	// Returns concrete location information described by a location expression
-	// loc ::= <filename>:<line> | <function>[:<line>] | /<regex>/ | (+|-)<offset> | <line> | *<address>
+	// loc ::= <filename>:<line> | <function>[:<line>] | <function>:+<offset> | /<regex>/ | (+|-)<offset> | <line> | *<address>
 	// * <filename> can be the full path of a file or just a suffix
 	// * <function> ::= <package>.<receiver type>.<name> | <package>.(*<receiver type>).<name> | <receiver type>.<name> | <package>.<name> | (*<receiver type>).<name> | <name>
 	// * <function> must be unambiguous
@@ -102,9 +218,15 @@ type Client interface {
 	// * *<address> returns the location corresponding to the specified address
 	// NOTE: this function does not actually set breakpoints.
 	FindLocation(scope api.EvalScope, loc string) ([]api.Location, error)
+	// FindLocationVerbose is like FindLocation but also returns the text of the source line of each location.
+	FindLocationVerbose(scope api.EvalScope, loc string) ([]api.Location, error)
 
 	// Disassemble code between startPC and endPC
 	DisassembleRange(scope api.EvalScope, startPC, endPC uint64, flavour api.AssemblyFlavour) (api.AsmInstructions, error)
 	// Disassemble code of the function containing PC
 	DisassemblePC(scope api.EvalScope, pc uint64, flavour api.AssemblyFlavour) (api.AsmInstructions, error)
+	// Disassemble only the call, jump and return instructions between startPC and endPC, or of the function containing startPC if endPC is 0
+	DisassembleBranches(scope api.EvalScope, startPC, endPC uint64, flavour api.AssemblyFlavour) (api.AsmInstructions, error)
+	// Disassemble code of the function called funcName
+	DisassembleFunction(scope api.EvalScope, funcName string, flavour api.AssemblyFlavour) (api.AsmInstructions, error)
 }
@@ -8,6 +8,8 @@ import "net"
 // Only one of ProcessArgs or AttachPid should be specified. If ProcessArgs is
 // provided, a new process will be launched. Otherwise, the debugger will try
 // to attach to an existing process with AttachPid.
+// If CoreFile is specified the core file is examined instead and the first
+// element of ProcessArgs is the executable that produced it.
 type Config struct {
 	// Listener is used to serve requests.
 	Listener net.Listener
@@ -20,6 +22,25 @@ type Config struct {
 	// AttachPid is the PID of an existing process to which the debugger should
 	// attach.
 	AttachPid int
+	// CoreFile is the path of a core file to examine. The process of a core
+	// file can not be resumed or modified.
+	CoreFile string
+	// StopAtEntry stops a newly launched process at the start of the
+	// initialization of the main package, after the runtime has been
+	// initialized but before any user code is executed.
+	StopAtEntry bool
+	// PTY launches the new process with a pseudo-terminal as its standard
+	// input, output and error, for programs that behave differently when
+	// their output is not a terminal. Only supported on linux.
+	PTY bool
+	// Backend selects the backend used to control the process, the only
+	// one available is "native", which is also the default.
+	Backend string
+	// RedirectOutput collects the standard output and error of the process
+	// instead of writing them to the ones of delve, they are returned in
+	// the Output field of the states of the debugger. Only supported on
+	// linux and not together with PTY.
+	RedirectOutput bool
 	// AcceptMulti configures the server to accept multiple connection.
 	// Note that the server API is not reentrant and clients will have to coordinate.
 	AcceptMulti bool
@@ -1,16 +1,22 @@
 package debugger
 
 import (
+	"bufio"
 	"debug/gosym"
+	"encoding/binary"
 	"errors"
 	"fmt"
-	"go/parser"
+	"go/ast"
+	"io"
 	"log"
-	"path/filepath"
+	"os"
 	"regexp"
-	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/derekparker/delve/proc"
 	"github.com/derekparker/delve/service/api"
@@ -28,6 +34,44 @@ type Debugger struct {
 	config       *Config
 	processMutex sync.Mutex
 	process      *proc.Process
+	// prevRegisters holds the registers of the current thread as they were
+	// the last time the process was resumed, by thread ID.
+	prevRegisters map[int][]proc.Register
+	// tracepointStats holds the values collected by tracepoints with an
+	// Aggregate expression, indexed by breakpoint ID.
+	tracepointStats map[int]*api.TracepointStats
+	// breakpointTemplates are the templates defined by
+	// DefineBreakpointTemplate, indexed by name.
+	breakpointTemplates map[string]api.Breakpoint
+	// logFiles are the files written by tracepoints with a LogToFile path,
+	// indexed by path.
+	logFiles map[string]*logFile
+	// tracepointResults are the messages logged by tracepoints since the
+	// process was resumed.
+	tracepointResults []api.TracepointResult
+	// output collects the output of the process if RedirectOutput is set.
+	output *outputRedirect
+	// selectedFrame is the frame of the selected goroutine selected by
+	// SwitchFrame, it is reset when the process is resumed.
+	selectedFrame int
+	// haltRequested is set, atomically, by Halt and cleared when the
+	// process stops.
+	haltRequested int32
+	// stopReason and exitStatus describe the last time the process
+	// stopped, see api.DebuggerState.StopReason.
+	stopReason string
+	exitStatus int
+	// signalPolicies are the policies set by SetSignalPolicy, applied
+	// again to the new process by Restart.
+	signalPolicies map[int]proc.SignalPolicy
+	// chanBreakpoints are the breakpoints created by BreakOnChannelOp,
+	// indexed by address.
+	chanBreakpoints map[uint64]*chanBreakpoint
+	// inlinedBreakpoints are the addresses of the breakpoints created on
+	// the inlined call sites of a function, indexed by the address of the
+	// first one. The other breakpoints follow the first one when it is
+	// amended or cleared.
+	inlinedBreakpoints map[uint64][]uint64
 }
 
 // Config provides the configuration to start a Debugger.
@@ -41,20 +85,64 @@ type Config struct {
 	// WorkingDir is working directory of the new process. This field is used
 	// only when launching a new process.
 	WorkingDir string
+	// Env is the environment of the new process, if it is nil the new
+	// process inherits the environment of delve.
+	Env []string
 
 	// AttachPid is the PID of an existing process to which the debugger should
 	// attach.
 	AttachPid int
+
+	// CoreFile is the path of a core file to examine, produced by the
+	// executable ProcessArgs[0].
+	CoreFile string
+
+	// StopAtEntry stops a newly launched process at the start of the
+	// initialization of the main package.
+	StopAtEntry bool
+
+	// PTY launches the new process with a pseudo-terminal as its standard
+	// input, output and error.
+	PTY bool
+
+	// Backend selects the backend used to control the process, the only
+	// one available is "native", which is also the default.
+	Backend string
+
+	// RedirectOutput collects the standard output and error of the new
+	// process, see api.DebuggerState.Output.
+	RedirectOutput bool
 }
 
+// ErrReverseNotSupported is returned by StepBack and ReverseContinue, which
+// are reserved for backends that can execute the process in reverse. None
+// of the available backends can.
+var ErrReverseNotSupported = errors.New("reverse execution is not supported by the current backend")
+
 // New creates a new Debugger.
 func New(config *Config) (*Debugger, error) {
 	d := &Debugger{
 		config: config,
 	}
 
-	// Create the process by either attaching or launching.
-	if d.config.AttachPid > 0 {
+	switch d.config.Backend {
+	case "", "native":
+	default:
+		return nil, fmt.Errorf("unknown backend %q", d.config.Backend)
+	}
+
+	// Create the process by either opening a core file, attaching or launching.
+	if d.config.CoreFile != "" {
+		if len(d.config.ProcessArgs) == 0 {
+			return nil, errors.New("the executable of the core file must be specified")
+		}
+		log.Printf("opening core file %s of %s", d.config.CoreFile, d.config.ProcessArgs[0])
+		p, err := proc.OpenCore(d.config.CoreFile, d.config.ProcessArgs[0])
+		if err != nil {
+			return nil, fmt.Errorf("could not open core file: %s", err)
+		}
+		d.process = p
+	} else if d.config.AttachPid > 0 {
 		log.Printf("attaching to pid %d", d.config.AttachPid)
 		p, err := proc.Attach(d.config.AttachPid)
 		if err != nil {
@@ -63,7 +151,7 @@ func New(config *Config) (*Debugger, error) {
 		d.process = p
 	} else {
 		log.Printf("launching process with args: %v", d.config.ProcessArgs)
-		p, err := proc.Launch(d.config.ProcessArgs, d.config.WorkingDir)
+		p, err := d.launch()
 		if err != nil {
 			if err != proc.NotExecutableErr && err != proc.UnsupportedArchErr {
 				err = fmt.Errorf("could not launch process: %s", err)
@@ -71,10 +159,70 @@ func New(config *Config) (*Debugger, error) {
 			return nil, err
 		}
 		d.process = p
+		if d.config.StopAtEntry {
+			if err := stopAtEntry(p); err != nil {
+				p.Kill()
+				return nil, fmt.Errorf("could not stop at entry: %s", err)
+			}
+		}
 	}
 	return d, nil
 }
 
+func (d *Debugger) launch() (*proc.Process, error) {
+	if d.config.Env != nil || d.config.RedirectOutput {
+		opts := proc.LaunchOptions{Env: d.config.Env, PTY: d.config.PTY}
+		if d.config.RedirectOutput {
+			if d.config.PTY {
+				return nil, errors.New("output redirection is not supported with a pseudo-terminal")
+			}
+			if d.output == nil {
+				d.output = &outputRedirect{}
+			}
+			stdout, stderr, err := d.output.pipes()
+			if err != nil {
+				return nil, fmt.Errorf("could not redirect output: %v", err)
+			}
+			// the new process has its own copies of the write ends
+			defer stdout.Close()
+			defer stderr.Close()
+			opts.Stdout, opts.Stderr = stdout, stderr
+		}
+		return proc.LaunchWithOptions(d.config.ProcessArgs, d.config.WorkingDir, opts)
+	}
+	if d.config.PTY {
+		return proc.LaunchPTY(d.config.ProcessArgs, d.config.WorkingDir)
+	}
+	return proc.Launch(d.config.ProcessArgs, d.config.WorkingDir)
+}
+
+// entryFunctions are the functions where StopAtEntry stops, in order of
+// preference: main.init runs the initialization of all packages imported
+// by main before its own.
+var entryFunctions = []string{"main.init", "main.main"}
+
+// stopAtEntry resumes the newly launched process p until the first user
+// code is about to be executed.
+func stopAtEntry(p *proc.Process) error {
+	var (
+		addr uint64
+		err  error
+	)
+	for _, fname := range entryFunctions {
+		if addr, err = p.FindFunctionLocation(fname, true, 0); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	// breakpoints of this kind are removed automatically once reached
+	if _, err := p.SetBreakpoint(addr, proc.NextBreakpoint, nil); err != nil {
+		return err
+	}
+	return p.Continue()
+}
+
 // ProcessPid returns the PID of the process
 // the debugger is debugging.
 func (d *Debugger) ProcessPid() int {
@@ -92,7 +240,8 @@ func (d *Debugger) Detach(kill bool) error {
 }
 
 func (d *Debugger) detach(kill bool) error {
-	if d.config.AttachPid != 0 {
+	d.closeLogFiles()
+	if d.config.AttachPid != 0 || d.config.CoreFile != "" {
 		return d.process.Detach(kill)
 	}
 	return d.process.Kill()
@@ -104,6 +253,30 @@ func (d *Debugger) Restart() error {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
+	return d.restart()
+}
+
+// RestartWith is like Restart but the new process is launched with the
+// arguments args, following the executable, and the environment env.
+// If args or env are nil the ones of the previous process are kept.
+// The new arguments and environment are also used by later restarts.
+func (d *Debugger) RestartWith(args, env []string) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if args != nil {
+		d.config.ProcessArgs = append([]string{d.config.ProcessArgs[0]}, args...)
+	}
+	if env != nil {
+		d.config.Env = env
+	}
+	return d.restart()
+}
+
+func (d *Debugger) restart() error {
+	if d.config.CoreFile != "" {
+		return errors.New("can not restart the process of a core file")
+	}
 	if !d.process.Exited() {
 		if d.process.Running() {
 			d.process.Halt()
@@ -116,28 +289,66 @@ func (d *Debugger) Restart() error {
 			return err
 		}
 	}
-	p, err := proc.Launch(d.config.ProcessArgs, d.config.WorkingDir)
+	p, err := d.launch()
 	if err != nil {
 		return fmt.Errorf("could not launch process: %s", err)
 	}
+	d.prevRegisters = nil
+	d.tracepointStats = nil
+	d.selectedFrame = 0
+	d.stopReason = ""
+	atomic.StoreInt32(&d.haltRequested, 0)
+	d.closeLogFiles()
+	for sig, policy := range d.signalPolicies {
+		if err := p.SetSignalPolicy(sig, policy); err != nil {
+			return err
+		}
+	}
+	if d.config.StopAtEntry {
+		if err := stopAtEntry(p); err != nil {
+			p.Kill()
+			return fmt.Errorf("could not stop at entry: %s", err)
+		}
+	}
 	for _, oldBp := range d.breakpoints() {
-		if oldBp.ID < 0 {
+		if oldBp.ID < 0 || oldBp.WatchExpr != "" {
 			continue
 		}
-		newBp, err := p.SetBreakpoint(oldBp.Addr, proc.UserBreakpoint, nil)
+		if cbp := d.chanBreakpoints[oldBp.Addr]; cbp != nil && cbp.id == oldBp.ID {
+			// the condition refers to channels of the old process
+			continue
+		}
+		addr := oldBp.Addr
+		if oldBp.Anchored {
+			addr, err = p.FindFunctionStatement(oldBp.FunctionName, oldBp.AnchorStatement)
+			if err != nil {
+				return fmt.Errorf("could not move anchored breakpoint %d: %v", oldBp.ID, err)
+			}
+		}
+		newBp, err := p.SetBreakpoint(addr, proc.UserBreakpoint, nil)
 		if err != nil {
 			return err
 		}
+		if oldBp.Anchored {
+			newBp.AnchorFunction, newBp.AnchorStatement = oldBp.FunctionName, oldBp.AnchorStatement
+		}
 		if err := copyBreakpointInfo(newBp, oldBp); err != nil {
 			return err
 		}
+		if newBp.LogToFile != "" {
+			if _, err := d.logFile(newBp.LogToFile); err != nil {
+				return fmt.Errorf("could not create log file of breakpoint %d: %v", newBp.ID, err)
+			}
+		}
 	}
+	d.chanBreakpoints = nil
 	d.process = p
 	return nil
 }
 
 // State returns the current state of the debugger.
-func (d *Debugger) State() (*api.DebuggerState, error) {
+func (d *Debugger) State() (state *api.DebuggerState, err error) {
+	defer func() { d.addOutput(state) }()
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 	return d.state()
@@ -159,6 +370,9 @@ func (d *Debugger) state() (*api.DebuggerState, error) {
 
 	state = &api.DebuggerState{
 		SelectedGoroutine: goroutine,
+		SelectedFrame:     d.selectedFrame,
+		StopReason:        d.stopReason,
+		Signal:            d.process.CurrentThread.Signal,
 		Exited:            d.process.Exited(),
 	}
 
@@ -177,6 +391,14 @@ func (d *Debugger) state() (*api.DebuggerState, error) {
 		}
 	}
 
+	for _, bp := range d.process.WatchOutOfScope {
+		state.WatchOutOfScope = append(state.WatchOutOfScope, api.ConvertBreakpoint(bp))
+	}
+	d.process.WatchOutOfScope = nil
+
+	state.TracepointResults = d.tracepointResults
+	d.tracepointResults = nil
+
 	return state, nil
 }
 
@@ -184,13 +406,145 @@ func (d *Debugger) state() (*api.DebuggerState, error) {
 func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
+	createdBp, err := d.createBreakpoint(requestedBp)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("created breakpoint: %#v", createdBp)
+	return createdBp, nil
+}
+
+// CreateBreakpoints creates all the requested breakpoints, with their
+// Group set to group, or none of them: if one of them can not be created
+// the ones already created are cleared.
+func (d *Debugger) CreateBreakpoints(requestedBps []*api.Breakpoint, group string) ([]*api.Breakpoint, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if group == "" {
+		return nil, errors.New("breakpoint group name can not be empty")
+	}
+	if d.hasBreakpointGroup(group) {
+		return nil, fmt.Errorf("breakpoint group %s already exists", group)
+	}
+
+	created := make([]*api.Breakpoint, 0, len(requestedBps))
+	for _, requestedBp := range requestedBps {
+		requestedBp.Group = group
+		bp, err := d.createBreakpoint(requestedBp)
+		if err != nil {
+			if _, err1 := d.clearBreakpointGroup(group); err1 != nil {
+				err = fmt.Errorf("error while creating breakpoint: %v, additionally the breakpoint group could not be properly rolled back: %v", err, err1)
+			}
+			return nil, err
+		}
+		created = append(created, bp)
+	}
+	log.Printf("created breakpoint group %s: %d breakpoints", group, len(created))
+	return created, nil
+}
+
+// CreatePackageTracepoints creates a tracepoint, without stacktrace or
+// variables, at the entry of every function whose name matches filter. The
+// tracepoints are members of the group "trace " + filter.
+// If more than max functions match, and max is positive, no tracepoint is
+// created and an error is returned. Functions that already have a
+// breakpoint at their entry are skipped, functions whose entry can not be
+// found are skipped and returned, if any other tracepoint can not be
+// created the ones already created are cleared.
+func (d *Debugger) CreatePackageTracepoints(filter string, max int) ([]*api.Breakpoint, []string, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	funcs, err := regexFilterFuncs(filter, d.process.Funcs())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(funcs) == 0 {
+		return nil, nil, fmt.Errorf("no function matches %s", filter)
+	}
+	if max > 0 && len(funcs) > max {
+		return nil, nil, fmt.Errorf("%d functions match %s, more than the maximum of %d", len(funcs), filter, max)
+	}
+	group := "trace " + filter
+	if d.hasBreakpointGroup(group) {
+		return nil, nil, fmt.Errorf("breakpoint group %s already exists", group)
+	}
+
+	created := make([]*api.Breakpoint, 0, len(funcs))
+	var skipped []string
+	for _, fn := range funcs {
+		addr, err := d.process.FindFunctionLocation(fn, true, 0)
+		if err != nil {
+			skipped = append(skipped, fn)
+			continue
+		}
+		bp, err := d.createBreakpoint(&api.Breakpoint{Addr: addr, Tracepoint: true, Group: group})
+		if err != nil {
+			if _, exists := err.(proc.BreakpointExistsError); exists {
+				continue
+			}
+			if _, err1 := d.clearBreakpointGroup(group); err1 != nil {
+				err = fmt.Errorf("error while creating tracepoint on %s: %v, additionally the breakpoint group could not be properly rolled back: %v", fn, err, err1)
+			}
+			return nil, nil, err
+		}
+		created = append(created, bp)
+	}
+	log.Printf("created breakpoint group %s: %d tracepoints, %d functions skipped", group, len(created), len(skipped))
+	return created, skipped, nil
+}
+
+// ClearBreakpointGroup clears all the breakpoints of group.
+func (d *Debugger) ClearBreakpointGroup(group string) ([]*api.Breakpoint, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if group == "" || !d.hasBreakpointGroup(group) {
+		return nil, fmt.Errorf("no breakpoint group %s", group)
+	}
+	return d.clearBreakpointGroup(group)
+}
+
+func (d *Debugger) hasBreakpointGroup(group string) bool {
+	for _, bp := range d.process.Breakpoints {
+		if bp.Group == group {
+			return true
+		}
+	}
+	return false
+}
 
+func (d *Debugger) clearBreakpointGroup(group string) ([]*api.Breakpoint, error) {
+	var cleared []*api.Breakpoint
+	for addr, bp := range d.process.Breakpoints {
+		if bp.Group != group {
+			continue
+		}
+		if _, err := d.process.ClearBreakpoint(addr); err != nil {
+			return cleared, fmt.Errorf("Can't clear breakpoint @%x: %s", addr, err)
+		}
+		delete(d.tracepointStats, bp.ID)
+		delete(d.inlinedBreakpoints, addr)
+		cleared = append(cleared, api.ConvertBreakpoint(bp))
+	}
+	log.Printf("cleared breakpoint group %s: %d breakpoints", group, len(cleared))
+	return cleared, nil
+}
+
+func (d *Debugger) createBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
 	var (
 		createdBp *api.Breakpoint
 		addr      uint64
 		err       error
 	)
 
+	if requestedBp.Template != "" {
+		if requestedBp, err = d.applyBreakpointTemplate(requestedBp); err != nil {
+			return nil, err
+		}
+	}
+
 	if requestedBp.Name != "" {
 		if err = api.ValidBreakpointName(requestedBp.Name); err != nil {
 			return nil, err
@@ -202,23 +556,31 @@ func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoin
 
 	switch {
 	case len(requestedBp.File) > 0:
-		fileName := requestedBp.File
-		if runtime.GOOS == "windows" {
-			// Accept fileName which is case-insensitive and slash-insensitive match
-			fileNameNormalized := strings.ToLower(filepath.ToSlash(fileName))
-			for symFile := range d.process.Sources() {
-				if fileNameNormalized == strings.ToLower(filepath.ToSlash(symFile)) {
-					fileName = symFile
-					break
-				}
-			}
+		var fileName string
+		fileName, err = d.resolveSourceFile(requestedBp.File, requestedBp.Line)
+		if err == nil {
+			addr, err = d.process.FindFileLocation(fileName, requestedBp.Line)
 		}
-		addr, err = d.process.FindFileLocation(fileName, requestedBp.Line)
 	case len(requestedBp.FunctionName) > 0:
-		if requestedBp.Line >= 0 {
+		skipPrologue := requestedBp.Line < 0
+		if requestedBp.SkipPrologue != nil {
+			if requestedBp.Line > 0 {
+				return nil, errors.New("SkipPrologue can not be used with a line offset")
+			}
+			skipPrologue = *requestedBp.SkipPrologue
+		}
+		if skipPrologue {
+			addr, err = d.process.FindFunctionLocation(requestedBp.FunctionName, true, 0)
+		} else if requestedBp.Line >= 0 {
 			addr, err = d.process.FindFunctionLocation(requestedBp.FunctionName, false, requestedBp.Line)
 		} else {
-			addr, err = d.process.FindFunctionLocation(requestedBp.FunctionName, true, 0)
+			addr, err = d.process.FindFunctionLocation(requestedBp.FunctionName, false, 0)
+		}
+		if err != nil && requestedBp.Line <= 0 {
+			// the function could be one that only exists in inlined form
+			if pcs, err1 := d.process.FindInlinedCallSites(requestedBp.FunctionName); err1 == nil {
+				return d.createInlinedBreakpoints(requestedBp, pcs)
+			}
 		}
 	default:
 		addr = requestedBp.Addr
@@ -232,17 +594,171 @@ func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoin
 	if err != nil {
 		return nil, err
 	}
-	if err := copyBreakpointInfo(bp, requestedBp); err != nil {
+	err = copyBreakpointInfo(bp, requestedBp)
+	if err == nil && requestedBp.Anchored {
+		bp.AnchorFunction, bp.AnchorStatement, err = d.process.StatementIndex(bp.Addr)
+	}
+	if err != nil {
 		if _, err1 := d.process.ClearBreakpoint(bp.Addr); err1 != nil {
 			err = fmt.Errorf("error while creating breakpoint: %v, additionally the breakpoint could not be properly rolled back: %v", err, err1)
 		}
 		return nil, err
 	}
 	createdBp = api.ConvertBreakpoint(bp)
-	log.Printf("created breakpoint: %#v", createdBp)
 	return createdBp, nil
 }
 
+// DefineBreakpointTemplate defines a breakpoint template called name,
+// replacing any previous template with the same name. Only the properties
+// of the template that determine what happens when the breakpoint is
+// reached are used, its location and name are ignored.
+func (d *Debugger) DefineBreakpointTemplate(name string, template *api.Breakpoint) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if name == "" {
+		return errors.New("breakpoint template name can not be empty")
+	}
+	if template.Template != "" {
+		return errors.New("breakpoint templates can not use other templates")
+	}
+	// validate the template by copying it into a breakpoint that is not set
+	if err := copyBreakpointInfo(&proc.Breakpoint{}, template); err != nil {
+		return err
+	}
+	if d.breakpointTemplates == nil {
+		d.breakpointTemplates = make(map[string]api.Breakpoint)
+	}
+	d.breakpointTemplates[name] = *template
+	return nil
+}
+
+// applyBreakpointTemplate returns a copy of requestedBp where the
+// properties that are not set are copied from the template named by
+// requestedBp.Template.
+func (d *Debugger) applyBreakpointTemplate(requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
+	template, ok := d.breakpointTemplates[requestedBp.Template]
+	if !ok {
+		return nil, fmt.Errorf("no breakpoint template named %s", requestedBp.Template)
+	}
+	bp := *requestedBp
+	if bp.Cond == "" {
+		bp.Cond = template.Cond
+	}
+	if !bp.Tracepoint {
+		bp.Tracepoint = template.Tracepoint
+	}
+	if !bp.Goroutine {
+		bp.Goroutine = template.Goroutine
+	}
+	if bp.Stacktrace == 0 {
+		bp.Stacktrace = template.Stacktrace
+	}
+	if len(bp.Variables) == 0 {
+		bp.Variables = template.Variables
+	}
+	if bp.LoadArgs == nil {
+		bp.LoadArgs = template.LoadArgs
+	}
+	if bp.LoadLocals == nil {
+		bp.LoadLocals = template.LoadLocals
+	}
+	if bp.Ignore == 0 {
+		bp.Ignore = template.Ignore
+	}
+	if bp.Aggregate == "" {
+		bp.Aggregate = template.Aggregate
+	}
+	if bp.HitCond == "" {
+		bp.HitCond = template.HitCond
+	}
+	if bp.LogMessage == "" && bp.LogToFile == "" {
+		bp.LogMessage = template.LogMessage
+		bp.LogToFile = template.LogToFile
+	}
+	if bp.GoroutineID == 0 {
+		bp.GoroutineID = template.GoroutineID
+	}
+	if bp.ThreadID == 0 {
+		bp.ThreadID = template.ThreadID
+	}
+	if bp.ActiveAfter == 0 {
+		bp.ActiveAfter = template.ActiveAfter
+	}
+	if bp.ActiveUntil == 0 {
+		bp.ActiveUntil = template.ActiveUntil
+	}
+	return &bp, nil
+}
+
+// resolveSourceFile returns the full path of the source file fileName
+// refers to. If fileName is not the full path of a source file it is
+// matched against the ending of every source file, if more than one file
+// matches an AmbiguousLocationError listing all of them is returned.
+func (d *Debugger) resolveSourceFile(fileName string, line int) (string, error) {
+	sources := d.process.Sources()
+	if _, ok := sources[fileName]; ok {
+		return fileName, nil
+	}
+	var candidates []string
+	for symFile := range sources {
+		if partialPathMatch(fileName, symFile) {
+			candidates = append(candidates, symFile)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return fileName, nil
+	case 1:
+		return candidates[0], nil
+	default:
+		sort.Strings(candidates)
+		return "", AmbiguousLocationError{Location: fmt.Sprintf("%s:%d", fileName, line), CandidatesString: candidates}
+	}
+}
+
+// createInlinedBreakpoints creates a breakpoint at each one of the
+// inlined call sites pcs, all with the same properties as requestedBp.
+// The first breakpoint is returned, InlinedCallSites lists all the
+// instrumented locations and the other breakpoints are amended and cleared
+// together with it.
+func (d *Debugger) createInlinedBreakpoints(requestedBp *api.Breakpoint, pcs []uint64) (*api.Breakpoint, error) {
+	var created []*proc.Breakpoint
+	rollback := func(err error) error {
+		for _, bp := range created {
+			if _, err1 := d.process.ClearBreakpoint(bp.Addr); err1 != nil {
+				return fmt.Errorf("error while creating breakpoint: %v, additionally the breakpoint could not be properly rolled back: %v", err, err1)
+			}
+		}
+		return err
+	}
+
+	for i, pc := range pcs {
+		bp, err := d.process.SetBreakpoint(pc, proc.UserBreakpoint, nil)
+		if err != nil {
+			return nil, rollback(err)
+		}
+		created = append(created, bp)
+		if err := copyBreakpointInfo(bp, requestedBp); err != nil {
+			return nil, rollback(err)
+		}
+		if i > 0 {
+			// breakpoint names must be unique, only the first one keeps it
+			bp.Name = ""
+		}
+	}
+
+	addrs := make([]uint64, len(created))
+	for i, bp := range created {
+		addrs[i] = bp.Addr
+	}
+	if d.inlinedBreakpoints == nil {
+		d.inlinedBreakpoints = make(map[uint64][]uint64)
+	}
+	d.inlinedBreakpoints[created[0].Addr] = addrs
+	return d.convertBreakpoint(created[0]), nil
+}
+
 func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
@@ -254,7 +770,40 @@ func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
 	if err := api.ValidBreakpointName(amend.Name); err != nil {
 		return err
 	}
-	return copyBreakpointInfo(original, amend)
+	if err := copyBreakpointInfo(original, amend); err != nil {
+		return err
+	}
+	for _, addr := range d.inlinedBreakpoints[original.Addr] {
+		bp := d.process.Breakpoints[addr]
+		if bp == nil || bp == original {
+			continue
+		}
+		if err := copyBreakpointInfo(bp, amend); err != nil {
+			return err
+		}
+		bp.Name = ""
+	}
+	return nil
+}
+
+// convertBreakpoint converts bp, filling InlinedCallSites if bp is the
+// first of the breakpoints created on the inlined call sites of a
+// function.
+func (d *Debugger) convertBreakpoint(bp *proc.Breakpoint) *api.Breakpoint {
+	r := api.ConvertBreakpoint(bp)
+	for _, addr := range d.inlinedBreakpoints[bp.Addr] {
+		ibp := d.process.Breakpoints[addr]
+		if ibp == nil {
+			continue
+		}
+		r.InlinedCallSites = append(r.InlinedCallSites, api.Location{
+			PC:       ibp.Addr,
+			File:     ibp.File,
+			Line:     ibp.Line,
+			Function: &api.Function{Name: ibp.FunctionName},
+		})
+	}
+	return r
 }
 
 func (d *Debugger) CancelNext() error {
@@ -262,18 +811,71 @@ func (d *Debugger) CancelNext() error {
 }
 
 func copyBreakpointInfo(bp *proc.Breakpoint, requested *api.Breakpoint) (err error) {
+	// validate everything before changing bp so that a failed amend leaves
+	// the breakpoint as it was
+	if requested.Ignore < 0 {
+		return fmt.Errorf("invalid ignore count %d", requested.Ignore)
+	}
+	if requested.GoroutineID < 0 {
+		return fmt.Errorf("invalid goroutine ID %d", requested.GoroutineID)
+	}
+	if requested.ThreadID < 0 {
+		return fmt.Errorf("invalid thread ID %d", requested.ThreadID)
+	}
+	if requested.ActiveAfter < 0 || requested.ActiveUntil < 0 {
+		return errors.New("invalid negative activation time")
+	}
+	if requested.ActiveUntil > 0 && requested.ActiveUntil <= requested.ActiveAfter {
+		return errors.New("breakpoint would never be active, ActiveUntil must be after ActiveAfter")
+	}
+	if requested.Aggregate != "" {
+		if !requested.Tracepoint {
+			return errors.New("aggregate expressions can only be set on tracepoints")
+		}
+		if _, err := proc.ParseExpr(requested.Aggregate); err != nil {
+			return fmt.Errorf("invalid aggregate expression: %v", err)
+		}
+	}
+	if requested.LogMessage != "" || requested.LogToFile != "" {
+		if !requested.Tracepoint {
+			return errors.New("log messages can only be set on tracepoints")
+		}
+		if requested.LogMessage == "" {
+			return errors.New("LogToFile requires a log message")
+		}
+		if err := checkLogMessage(requested.LogMessage); err != nil {
+			return err
+		}
+	}
+	var cond ast.Expr
+	if requested.Cond != "" {
+		if cond, err = proc.ParseExpr(requested.Cond); err != nil {
+			return err
+		}
+	}
+	// SetHitCond does not change bp if the hit condition is invalid
+	if err := bp.SetHitCond(requested.HitCond); err != nil {
+		return err
+	}
+
 	bp.Name = requested.Name
+	bp.Group = requested.Group
 	bp.Tracepoint = requested.Tracepoint
 	bp.Goroutine = requested.Goroutine
+	bp.GoroutineID = requested.GoroutineID
+	bp.ThreadID = requested.ThreadID
 	bp.Stacktrace = requested.Stacktrace
 	bp.Variables = requested.Variables
 	bp.LoadArgs = api.LoadConfigToProc(requested.LoadArgs)
 	bp.LoadLocals = api.LoadConfigToProc(requested.LoadLocals)
-	bp.Cond = nil
-	if requested.Cond != "" {
-		bp.Cond, err = parser.ParseExpr(requested.Cond)
-	}
-	return err
+	bp.Ignore = requested.Ignore
+	bp.ActiveAfter = requested.ActiveAfter
+	bp.ActiveUntil = requested.ActiveUntil
+	bp.Aggregate = requested.Aggregate
+	bp.LogMessage = requested.LogMessage
+	bp.LogToFile = requested.LogToFile
+	bp.Cond = cond
+	return nil
 }
 
 // ClearBreakpoint clears a breakpoint.
@@ -287,6 +889,20 @@ func (d *Debugger) ClearBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint
 		return nil, fmt.Errorf("Can't clear breakpoint @%x: %s", requestedBp.Addr, err)
 	}
 	clearedBp = api.ConvertBreakpoint(bp)
+	delete(d.tracepointStats, bp.ID)
+	if addrs, ok := d.inlinedBreakpoints[bp.Addr]; ok {
+		delete(d.inlinedBreakpoints, bp.Addr)
+		for _, addr := range addrs {
+			ibp, ok := d.process.Breakpoints[addr]
+			if addr == bp.Addr || !ok {
+				continue
+			}
+			if _, err := d.process.ClearBreakpoint(addr); err != nil {
+				return nil, fmt.Errorf("Can't clear inlined breakpoint @%x: %s", addr, err)
+			}
+			delete(d.tracepointStats, ibp.ID)
+		}
+	}
 	log.Printf("cleared breakpoint: %#v", clearedBp)
 	return clearedBp, err
 }
@@ -304,7 +920,7 @@ func (d *Debugger) breakpoints() []*api.Breakpoint {
 		if bp.Internal() {
 			continue
 		}
-		bps = append(bps, api.ConvertBreakpoint(bp))
+		bps = append(bps, d.convertBreakpoint(bp))
 	}
 	return bps
 }
@@ -318,7 +934,55 @@ func (d *Debugger) FindBreakpoint(id int) *api.Breakpoint {
 	if bp == nil {
 		return nil
 	}
-	return api.ConvertBreakpoint(bp)
+	return d.convertBreakpoint(bp)
+}
+
+// PendingBreakpointsAhead returns a guess of the breakpoints goroutine gid
+// will reach next, see proc.(*Process).BreakpointsAhead.
+func (d *Debugger) PendingBreakpointsAhead(gid int) ([]*api.Breakpoint, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	g, err := d.process.FindGoroutine(gid)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, errors.New("no selected goroutine")
+	}
+	bps, err := d.process.BreakpointsAhead(g)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]*api.Breakpoint, 0, len(bps))
+	for _, bp := range bps {
+		r = append(r, api.ConvertBreakpoint(bp))
+	}
+	return r, nil
+}
+
+// TracepointStats returns the statistics collected by the Aggregate
+// expression of breakpoint id.
+func (d *Debugger) TracepointStats(id int) (*api.TracepointStats, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	bp := d.findBreakpoint(id)
+	if bp == nil {
+		return nil, fmt.Errorf("no breakpoint with ID %d", id)
+	}
+	if bp.Aggregate == "" {
+		return nil, fmt.Errorf("breakpoint %d does not aggregate values", id)
+	}
+	r := &api.TracepointStats{Histogram: map[int64]uint64{}}
+	if stats := d.tracepointStats[id]; stats != nil {
+		*r = *stats
+		r.Histogram = make(map[int64]uint64, len(stats.Histogram))
+		for k, n := range stats.Histogram {
+			r.Histogram[k] = n
+		}
+	}
+	return r, nil
 }
 
 func (d *Debugger) findBreakpoint(id int) *proc.Breakpoint {
@@ -379,39 +1043,39 @@ func (d *Debugger) FindThread(id int) (*api.Thread, error) {
 }
 
 // Command handles commands which control the debugger lifecycle
-func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, error) {
-	var err error
+func (d *Debugger) Command(command *api.DebuggerCommand) (state *api.DebuggerState, err error) {
+	defer func() { d.addOutput(state) }()
 
 	if command.Name == api.Halt {
 		// RequestManualStop does not invoke any ptrace syscalls, so it's safe to
 		// access the process directly.
 		log.Print("halting")
+		atomic.StoreInt32(&d.haltRequested, 1)
 		err = d.process.RequestManualStop()
 	}
 
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
+	nexting := d.nextInProgress()
 	switch command.Name {
-	case api.Continue:
-		log.Print("continuing")
-		err = d.process.Continue()
-		if err != nil {
-			if exitedErr, exited := err.(proc.ProcessExitedError); exited {
-				state := &api.DebuggerState{}
-				state.Exited = true
-				state.ExitStatus = exitedErr.Status
-				state.Err = errors.New(exitedErr.Error())
-				return state, nil
-			}
-			return nil, err
-		}
-		state, stateErr := d.state()
-		if stateErr != nil {
-			return state, stateErr
+	case api.Continue, api.ContinueUntilGoroutineChange, api.Next, api.Step, api.StepInto, api.StepInstruction, api.StepOut:
+		d.selectedFrame = 0
+		d.saveRegisters()
+	case api.SwitchThread, api.SwitchGoroutine:
+		d.selectedFrame = 0
+	}
+
+	switch command.Name {
+	case api.Continue, api.ContinueUntilGoroutineChange:
+		if command.Name == api.Continue {
+			log.Print("continuing")
+			err = d.continueAggregating()
+		} else {
+			log.Print("continuing until goroutine change")
+			err = d.process.ContinueUntilGoroutineChange()
 		}
-		err = d.collectBreakpointInformation(state)
-		return state, err
+		return d.continueState(err, nexting)
 
 	case api.Next:
 		log.Print("nexting")
@@ -419,6 +1083,9 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 	case api.Step:
 		log.Print("stepping")
 		err = d.process.Step()
+	case api.StepInto:
+		log.Printf("stepping into %s", command.FunctionName)
+		err = d.process.StepInto(command.FunctionName)
 	case api.StepInstruction:
 		log.Print("single stepping")
 		err = d.process.StepInstruction()
@@ -431,15 +1098,188 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 	case api.SwitchGoroutine:
 		log.Printf("switching to goroutine %d", command.GoroutineID)
 		err = d.process.SwitchGoroutine(command.GoroutineID)
+	case api.StepBack, api.ReverseContinue:
+		// none of the available backends can execute in reverse
+		err = ErrReverseNotSupported
 	case api.Halt:
-		// RequestManualStop already called
+		// RequestManualStop already called, the state reports whether the
+		// process stopped because of it, because of something else that
+		// happened first or because it exited
+		if d.process.Exited() {
+			return d.exitedState(), nil
+		}
+	}
+
+	switch command.Name {
+	case api.Next, api.Step, api.StepInto, api.StepInstruction, api.StepOut:
+		d.setStopReason(err, true, false)
 	}
+
 	if err != nil {
 		return nil, err
 	}
 	return d.state()
 }
 
+// continueState returns the state of the process after it was continued,
+// err is the error returned by the continue operation and nexting whether
+// internal breakpoints were set when it started.
+func (d *Debugger) continueState(err error, nexting bool) (*api.DebuggerState, error) {
+	d.setStopReason(err, false, nexting)
+	return d.stoppedState(err)
+}
+
+// stoppedState returns the state of the process after it was continued
+// and its stop reason was recorded.
+func (d *Debugger) stoppedState(err error) (*api.DebuggerState, error) {
+	if err != nil {
+		if exitedErr, exited := err.(proc.ProcessExitedError); exited {
+			state := d.exitedState()
+			state.Err = errors.New(exitedErr.Error())
+			state.TracepointResults = d.tracepointResults
+			d.tracepointResults = nil
+			return state, nil
+		}
+		return nil, err
+	}
+	state, stateErr := d.state()
+	if stateErr != nil {
+		return state, stateErr
+	}
+	err = d.collectBreakpointInformation(state)
+	return state, err
+}
+
+// exitedState returns the state of the process after it exited.
+func (d *Debugger) exitedState() *api.DebuggerState {
+	return &api.DebuggerState{
+		StopReason: api.StopExited,
+		Exited:     true,
+		ExitStatus: d.exitStatus,
+	}
+}
+
+// setStopReason records why the process stopped after it was resumed, err
+// is the error returned by the operation that resumed it. If stepping is
+// set the operation was a step command and stopping where it was meant to
+// stop has no reason. If nexting is set internal breakpoints were set when
+// the process was resumed, if they were all removed the process reached
+// one of them.
+func (d *Debugger) setStopReason(err error, stepping, nexting bool) {
+	halted := atomic.SwapInt32(&d.haltRequested, 0) != 0
+	if exitedErr, exited := err.(proc.ProcessExitedError); exited {
+		d.stopReason, d.exitStatus = api.StopExited, exitedErr.Status
+		return
+	}
+	th := d.process.CurrentThread
+	switch {
+	case err != nil || th == nil:
+		d.stopReason = ""
+	case th.CurrentBreakpoint != nil && th.BreakpointConditionMet && !th.CurrentBreakpoint.Internal():
+		d.stopReason = api.StopBreakpoint
+	case halted:
+		d.stopReason = api.StopManual
+	case nexting && !d.nextInProgress():
+		d.stopReason = api.StopNext
+	case stepping || th.CurrentBreakpoint != nil:
+		d.stopReason = ""
+	default:
+		d.stopReason = api.StopSignal
+	}
+}
+
+// nextInProgress returns true if any internal breakpoint is set, either
+// because of a next, step or step out operation that was interrupted or
+// because of ContinueTo.
+func (d *Debugger) nextInProgress() bool {
+	for _, bp := range d.process.Breakpoints {
+		if bp.Internal() {
+			return true
+		}
+	}
+	return false
+}
+
+// ContinueTo continues the process until it reaches locStr, resolved as
+// FindLocation does in the scope of the current goroutine. The temporary
+// breakpoints set on the location are internal and they are removed once
+// the process stops, whatever the reason.
+func (d *Debugger) ContinueTo(locStr string) (state *api.DebuggerState, err error) {
+	defer func() { d.addOutput(state) }()
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if d.process.Exited() {
+		return nil, &proc.ProcessExitedError{}
+	}
+	if d.nextInProgress() {
+		return nil, errors.New("can not continue to a location while next is in progress")
+	}
+	locs, err := d.findLocation(api.EvalScope{-1, 0}, locStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(locs) == 0 {
+		return nil, fmt.Errorf("location %q not found", locStr)
+	}
+
+	for _, loc := range locs {
+		if _, err := d.process.SetBreakpoint(loc.PC, proc.NextBreakpoint, nil); err != nil {
+			if _, exists := err.(proc.BreakpointExistsError); !exists {
+				d.process.ClearInternalBreakpoints()
+				return nil, err
+			}
+		}
+	}
+
+	log.Printf("continuing to %s", locStr)
+	d.selectedFrame = 0
+	d.saveRegisters()
+	err = d.continueAggregating()
+	d.setStopReason(err, false, true)
+	if !d.process.Exited() {
+		if cerr := d.process.ClearInternalBreakpoints(); err == nil {
+			err = cerr
+		}
+	}
+	return d.stoppedState(err)
+}
+
+// ContinueWithSignal resumes the process like Continue, delivering sig to
+// the current thread instead of the signal it was stopped by. A sig of 0
+// discards the signal.
+func (d *Debugger) ContinueWithSignal(sig int) (state *api.DebuggerState, err error) {
+	defer func() { d.addOutput(state) }()
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if err := d.process.DeliverSignal(sig); err != nil {
+		return nil, err
+	}
+	log.Printf("continuing with signal %d", sig)
+	nexting := d.nextInProgress()
+	d.selectedFrame = 0
+	d.saveRegisters()
+	return d.continueState(d.continueAggregating(), nexting)
+}
+
+// SetSignalPolicy sets whether the process is stopped when it receives the
+// signal sig and whether the signal is then delivered to it.
+func (d *Debugger) SetSignalPolicy(sig int, stop, pass bool) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	policy := proc.SignalPolicy{Stop: stop, Pass: pass}
+	if err := d.process.SetSignalPolicy(sig, policy); err != nil {
+		return err
+	}
+	if d.signalPolicies == nil {
+		d.signalPolicies = make(map[int]proc.SignalPolicy)
+	}
+	d.signalPolicies[sig] = policy
+	return nil
+}
+
 func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error {
 	if state == nil {
 		return nil
@@ -450,60 +1290,81 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 			continue
 		}
 
-		bp := state.Threads[i].Breakpoint
-		bpi := &api.BreakpointInfo{}
-		state.Threads[i].BreakpointInfo = bpi
-
-		if bp.Goroutine {
-			g, err := d.process.CurrentThread.GetG()
-			if err != nil {
-				return err
-			}
-			bpi.Goroutine = api.ConvertGoroutine(g)
+		start := time.Now()
+		err := d.collectThreadBreakpointInformation(state.Threads[i])
+		if bp, ok := d.process.Breakpoints[state.Threads[i].Breakpoint.Addr]; ok {
+			bp.CaptureTime += time.Since(start)
 		}
-
-		if bp.Stacktrace > 0 {
-			rawlocs, err := d.process.CurrentThread.Stacktrace(bp.Stacktrace)
-			if err != nil {
-				return err
-			}
-			bpi.Stacktrace, err = d.convertStacktrace(rawlocs, nil)
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
-		s, err := d.process.Threads[state.Threads[i].ID].Scope()
+// collectThreadBreakpointInformation retrieves the information requested
+// by the breakpoint thread th is stopped at.
+func (d *Debugger) collectThreadBreakpointInformation(th *api.Thread) error {
+	bp := th.Breakpoint
+	bpi := &api.BreakpointInfo{}
+	th.BreakpointInfo = bpi
+
+	if bp.Goroutine {
+		g, err := d.process.CurrentThread.GetG()
 		if err != nil {
 			return err
 		}
+		bpi.Goroutine = api.ConvertGoroutine(g)
+	}
 
-		if len(bp.Variables) > 0 {
-			bpi.Variables = make([]api.Variable, len(bp.Variables))
+	if bp.Stacktrace > 0 {
+		rawlocs, err := d.process.CurrentThread.Stacktrace(bp.Stacktrace)
+		if err != nil {
+			return err
 		}
-		for i := range bp.Variables {
-			v, err := s.EvalVariable(bp.Variables[i], proc.LoadConfig{true, 1, 64, 64, -1})
-			if err != nil {
-				return err
-			}
-			bpi.Variables[i] = *api.ConvertVar(v)
+		bpi.Stacktrace, err = d.convertStacktrace(rawlocs, nil)
+		if err != nil {
+			return err
 		}
-		if bp.LoadArgs != nil {
-			if vars, err := s.FunctionArguments(*api.LoadConfigToProc(bp.LoadArgs)); err == nil {
-				bpi.Arguments = convertVars(vars)
-			}
+	}
+
+	s, err := d.process.Threads[th.ID].Scope()
+	if err != nil {
+		return err
+	}
+
+	if bp.LogMessage != "" {
+		bpi.LogMessage = renderLogMessage(s, bp.LogMessage)
+	}
+
+	if len(bp.Variables) > 0 {
+		bpi.Variables = make([]api.Variable, len(bp.Variables))
+	}
+	for i := range bp.Variables {
+		v, err := s.EvalVariable(bp.Variables[i], proc.LoadConfig{true, 1, 64, 64, -1})
+		if err != nil {
+			return err
 		}
-		if bp.LoadLocals != nil {
-			if locals, err := s.LocalVariables(*api.LoadConfigToProc(bp.LoadLocals)); err == nil {
-				bpi.Locals = convertVars(locals)
-			}
+		bpi.Variables[i] = *api.ConvertVar(v)
+	}
+	if bp.LoadArgs != nil {
+		if vars, err := s.FunctionArguments(*api.LoadConfigToProc(bp.LoadArgs)); err == nil {
+			bpi.Arguments = convertVars(vars)
+		}
+	}
+	if bp.LoadLocals != nil {
+		if locals, err := s.LocalVariables(*api.LoadConfigToProc(bp.LoadLocals)); err == nil {
+			bpi.Locals = convertVars(locals)
 		}
 	}
 
 	return nil
 }
 
-// Sources returns a list of the source files for target binary.
+// Sources returns a sorted list of the source files for target binary,
+// collected from the symbol table and the DWARF line tables, whose full
+// path matches filter.
 func (d *Debugger) Sources(filter string) ([]string, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
@@ -513,15 +1374,77 @@ func (d *Debugger) Sources(filter string) ([]string, error) {
 		return nil, fmt.Errorf("invalid filter argument: %s", err.Error())
 	}
 
+	seen := make(map[string]bool)
 	files := []string{}
 	for f := range d.process.Sources() {
 		if regex.Match([]byte(f)) {
+			seen[f] = true
 			files = append(files, f)
 		}
 	}
+	for _, f := range d.process.LineTableSources() {
+		if !seen[f] && regex.Match([]byte(f)) {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
 	return files, nil
 }
 
+// ListSource returns the lines of the source file file from line-context
+// to line+context, read from the filesystem of the debugger.
+// The file must be one of the source files of the process.
+func (d *Debugger) ListSource(file string, line, context int) ([]api.SourceLine, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if line < 1 || context < 0 {
+		return nil, fmt.Errorf("invalid line %d or context %d", line, context)
+	}
+	if !d.isSource(file) {
+		return nil, fmt.Errorf("%s is not a source file of the program", file)
+	}
+
+	curline := 0
+	if !d.process.Exited() {
+		if s, err := d.convertEvalScope(api.EvalScope{-1, 0}); err == nil {
+			if f, l, _ := d.process.PCToLine(s.PC); f == file {
+				curline = l
+			}
+		}
+	}
+
+	text, err := readLines(file)
+	if err != nil {
+		return nil, err
+	}
+	if line > len(text) {
+		return nil, fmt.Errorf("line %d is past the end of %s", line, file)
+	}
+
+	var lines []api.SourceLine
+	for i := line - context; i <= line+context && i <= len(text); i++ {
+		if i >= 1 {
+			lines = append(lines, api.SourceLine{Line: i, Text: text[i-1], Current: i == curline})
+		}
+	}
+	return lines, nil
+}
+
+// isSource returns true if file is one of the source files of the process.
+func (d *Debugger) isSource(file string) bool {
+	if _, ok := d.process.Sources()[file]; ok {
+		return true
+	}
+	for _, f := range d.process.LineTableSources() {
+		if f == file {
+			return true
+		}
+	}
+	return false
+}
+
 // Functions returns a list of functions in the target process.
 func (d *Debugger) Functions(filter string) ([]string, error) {
 	d.processMutex.Lock()
@@ -530,6 +1453,23 @@ func (d *Debugger) Functions(filter string) ([]string, error) {
 	return regexFilterFuncs(filter, d.process.Funcs())
 }
 
+// ListMethods returns the methods of typeName, with typeName or a pointer
+// to it as receiver.
+func (d *Debugger) ListMethods(typeName string) ([]api.Method, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	methods, err := d.process.TypeMethods(typeName)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]api.Method, len(methods))
+	for i := range methods {
+		r[i] = api.ConvertMethod(&methods[i])
+	}
+	return r, nil
+}
+
 func (d *Debugger) Types(filter string) ([]string, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
@@ -601,20 +1541,116 @@ func (d *Debugger) PackageVariables(threadID int, filter string, cfg proc.LoadCo
 	return vars, err
 }
 
-// Registers returns string representation of the CPU registers.
-func (d *Debugger) Registers(threadID int) (string, error) {
+// Registers returns the CPU registers of the specified thread, registers
+// that changed since the last time the process was resumed are marked.
+func (d *Debugger) Registers(threadID int) (api.Registers, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
 	thread, found := d.process.Threads[threadID]
 	if !found {
-		return "", fmt.Errorf("couldn't find thread %d", threadID)
+		return nil, fmt.Errorf("couldn't find thread %d", threadID)
 	}
 	regs, err := thread.Registers()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	return api.ConvertRegisters(regs.Slice(), d.prevRegisters[threadID]), nil
+}
+
+// ThreadRegisters returns the registers of every thread, by thread ID, as
+// returned by Registers.
+func (d *Debugger) ThreadRegisters() (map[int]api.Registers, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if d.process.Exited() {
+		return nil, &proc.ProcessExitedError{}
+	}
+	r := make(map[int]api.Registers, len(d.process.Threads))
+	for id, thread := range d.process.Threads {
+		regs, err := thread.Registers()
+		if err != nil {
+			return nil, fmt.Errorf("could not read registers of thread %d: %v", id, err)
+		}
+		r[id] = api.ConvertRegisters(regs.Slice(), d.prevRegisters[id])
+	}
+	return r, nil
+}
+
+// SetRegister sets the register called name of the specified thread, or
+// of the current thread if threadID is -1, to value. The value is parsed
+// as a decimal, hexadecimal (0x prefix) or octal (0 prefix) number.
+func (d *Debugger) SetRegister(threadID int, name, value string) error {
+	n, err := strconv.ParseUint(value, 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid register value %q: %v", value, err)
+	}
+
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	thread := d.process.CurrentThread
+	if threadID != -1 {
+		var found bool
+		thread, found = d.process.Threads[threadID]
+		if !found {
+			return fmt.Errorf("couldn't find thread %d", threadID)
+		}
+	}
+	if err := thread.SetRegister(name, n); err != nil {
+		if err == proc.UnknownRegisterError {
+			return fmt.Errorf("unknown register %s", name)
+		}
+		return err
+	}
+	return nil
+}
+
+// SwitchFrame selects frame of the selected goroutine: the frames of the
+// scopes with GoroutineID -1 become relative to it, so that EvalScope{-1, 0}
+// evaluates in the selected frame. The selection is reset when the process
+// is resumed or another goroutine or thread is selected.
+func (d *Debugger) SwitchFrame(frame int) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if frame < 0 {
+		return fmt.Errorf("invalid frame %d", frame)
+	}
+	if _, err := d.process.ConvertEvalScope(-1, frame); err != nil {
+		return err
+	}
+	d.selectedFrame = frame
+	return nil
+}
+
+// scopeFrame returns the frame of scope, relative to the frame selected by
+// SwitchFrame if scope is in the selected goroutine. Scopes that specify
+// a goroutine explicitly are never relative to the selected frame.
+func (d *Debugger) scopeFrame(scope api.EvalScope) int {
+	if scope.GoroutineID == -1 {
+		return scope.Frame + d.selectedFrame
+	}
+	return scope.Frame
+}
+
+func (d *Debugger) convertEvalScope(scope api.EvalScope) (*proc.EvalScope, error) {
+	return d.process.ConvertEvalScope(scope.GoroutineID, d.scopeFrame(scope))
+}
+
+// saveRegisters records the registers of the current thread so that they
+// can be compared with their values once the process stops again. Reading
+// the registers of every thread on every resume would be too expensive.
+func (d *Debugger) saveRegisters() {
+	d.prevRegisters = make(map[int][]proc.Register, 1)
+	thread := d.process.CurrentThread
+	if thread == nil {
+		return
+	}
+	if regs, err := thread.Registers(); err == nil {
+		d.prevRegisters[thread.ID] = regs.Slice()
 	}
-	return regs.String(), err
 }
 
 func convertVars(pv []*proc.Variable) []api.Variable {
@@ -625,120 +1661,588 @@ func convertVars(pv []*proc.Variable) []api.Variable {
 	return vars
 }
 
-// LocalVariables returns a list of the local variables.
-func (d *Debugger) LocalVariables(scope api.EvalScope, cfg proc.LoadConfig) ([]api.Variable, error) {
+// LocalVariables returns a list of the local variables whose name matches
+// the regular expression filter.
+func (d *Debugger) LocalVariables(scope api.EvalScope, filter string, cfg proc.LoadConfig) ([]api.Variable, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := d.process.ConvertEvalScope(scope.GoroutineID, scope.Frame)
+	regex, err := compileVariableFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	s, err := d.convertEvalScope(scope)
 	if err != nil {
 		return nil, err
 	}
-	pv, err := s.LocalVariables(cfg)
+	pv, err := s.LocalVariablesMatching(regex, cfg)
 	if err != nil {
 		return nil, err
 	}
 	return convertVars(pv), err
 }
 
-// FunctionArguments returns the arguments to the current function.
-func (d *Debugger) FunctionArguments(scope api.EvalScope, cfg proc.LoadConfig) ([]api.Variable, error) {
+// LocalVariablesAllBlocks returns a list of the local variables of all
+// lexical blocks containing the current line, including shadowed ones,
+// whose name matches the regular expression filter.
+func (d *Debugger) LocalVariablesAllBlocks(scope api.EvalScope, filter string, cfg proc.LoadConfig) ([]api.Variable, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := d.process.ConvertEvalScope(scope.GoroutineID, scope.Frame)
+	regex, err := compileVariableFilter(filter)
 	if err != nil {
 		return nil, err
 	}
-	pv, err := s.FunctionArguments(cfg)
+	s, err := d.convertEvalScope(scope)
 	if err != nil {
 		return nil, err
 	}
-	return convertVars(pv), nil
+	pv, err := s.LocalVariablesAllBlocks(regex, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return convertVars(pv), err
 }
 
-// EvalVariableInScope will attempt to evaluate the variable represented by 'symbol'
-// in the scope provided.
-func (d *Debugger) EvalVariableInScope(scope api.EvalScope, symbol string, cfg proc.LoadConfig) (*api.Variable, error) {
+// FunctionArguments returns the arguments to the current function whose
+// name matches the regular expression filter.
+func (d *Debugger) FunctionArguments(scope api.EvalScope, filter string, cfg proc.LoadConfig) ([]api.Variable, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := d.process.ConvertEvalScope(scope.GoroutineID, scope.Frame)
+	regex, err := compileVariableFilter(filter)
 	if err != nil {
 		return nil, err
 	}
-	v, err := s.EvalVariable(symbol, cfg)
+	s, err := d.convertEvalScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	pv, err := s.FunctionArgumentsMatching(regex, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return api.ConvertVar(v), err
+	return convertVars(pv), nil
 }
 
-// SetVariableInScope will set the value of the variable represented by
-// 'symbol' to the value given, in the given scope.
-func (d *Debugger) SetVariableInScope(scope api.EvalScope, symbol, value string) error {
-	d.processMutex.Lock()
-	defer d.processMutex.Unlock()
-
-	s, err := d.process.ConvertEvalScope(scope.GoroutineID, scope.Frame)
+// compileVariableFilter compiles the regular expression used to filter
+// variables by name, an empty filter matches all variables and returns nil.
+func compileVariableFilter(filter string) (*regexp.Regexp, error) {
+	if filter == "" {
+		return nil, nil
+	}
+	regex, err := regexp.Compile(filter)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("invalid filter argument: %s", err.Error())
 	}
-	return s.SetVariable(symbol, value)
+	return regex, nil
 }
 
-// Goroutines will return a list of goroutines in the target process.
-func (d *Debugger) Goroutines() ([]*api.Goroutine, error) {
+// FunctionReceiver returns the receiver of the function of the scope, nil
+// if the function is not a method, and its other arguments.
+func (d *Debugger) FunctionReceiver(scope api.EvalScope, cfg proc.LoadConfig) (*api.Variable, []api.Variable, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	goroutines := []*api.Goroutine{}
-	gs, err := d.process.GoroutinesInfo()
+	s, err := d.convertEvalScope(scope)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	for _, g := range gs {
-		goroutines = append(goroutines, api.ConvertGoroutine(g))
+	recv, args, err := s.FunctionReceiver(cfg)
+	if err != nil {
+		return nil, nil, err
 	}
-	return goroutines, err
+	var r *api.Variable
+	if recv != nil {
+		r = api.ConvertVar(recv)
+	}
+	return r, convertVars(args), nil
 }
 
-// Stacktrace returns a list of Stackframes for the given goroutine. The
-// length of the returned list will be min(stack_len, depth).
-// If 'full' is true, then local vars, function args, etc will be returned as well.
-func (d *Debugger) Stacktrace(goroutineID, depth int, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
+// EvalVariableInScope will attempt to evaluate the variable represented by 'symbol'
+// in the scope provided.
+// If timeout is not zero the evaluation is aborted when it takes longer
+// than timeout.
+func (d *Debugger) EvalVariableInScope(scope api.EvalScope, symbol string, cfg proc.LoadConfig, timeout time.Duration) (*api.Variable, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+	return d.evalVariableInScope(scope, symbol, cfg, timeout)
+}
+
+// EvalPair evaluates exprA in scopeA and exprB in scopeB while holding the
+// process lock, so that both values are read from the same stop.
+// The evaluation of each expression succeeds or fails independently.
+func (d *Debugger) EvalPair(scopeA api.EvalScope, exprA string, scopeB api.EvalScope, exprB string, cfg proc.LoadConfig) (a, b *api.Variable, errA, errB error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	var rawlocs []proc.Stackframe
+	a, errA = d.evalVariableInScope(scopeA, exprA, cfg, 0)
+	b, errB = d.evalVariableInScope(scopeB, exprB, cfg, 0)
+	return a, b, errA, errB
+}
 
-	g, err := d.process.FindGoroutine(goroutineID)
+func (d *Debugger) evalVariableInScope(scope api.EvalScope, symbol string, cfg proc.LoadConfig, timeout time.Duration) (*api.Variable, error) {
+	s, err := d.convertEvalScope(scope)
 	if err != nil {
 		return nil, err
 	}
-
-	if g == nil {
-		rawlocs, err = d.process.CurrentThread.Stacktrace(depth)
-	} else {
-		rawlocs, err = g.Stacktrace(depth)
+	if timeout > 0 {
+		s.Deadline = time.Now().Add(timeout)
 	}
+	v, err := s.EvalVariable(symbol, cfg)
 	if err != nil {
 		return nil, err
 	}
-
-	return d.convertStacktrace(rawlocs, cfg)
+	return api.ConvertVar(v), nil
 }
 
-func (d *Debugger) convertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
-	locations := make([]api.Stackframe, 0, len(rawlocs))
-	for i := range rawlocs {
-		frame := api.Stackframe{Location: api.ConvertLocation(rawlocs[i].Call)}
-		if cfg != nil {
-			var err error
-			scope := rawlocs[i].Scope(d.process.CurrentThread)
-			locals, err := scope.LocalVariables(*cfg)
-			if err != nil {
-				return nil, err
+// EvalAcrossGoroutines evaluates expr in the topmost frame of every
+// goroutine. The goroutines where expr uses names that are not visible in
+// that frame, or whose stack can not be read, are skipped. The other
+// evaluation errors are returned in errs, by goroutine ID.
+func (d *Debugger) EvalAcrossGoroutines(expr string, cfg proc.LoadConfig) (vars map[int]*api.Variable, errs map[int]error, err error) {
+	if _, err := proc.ParseExpr(expr); err != nil {
+		return nil, nil, err
+	}
+
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	gs, err := d.process.GoroutinesInfo()
+	if err != nil {
+		return nil, nil, err
+	}
+	vars = make(map[int]*api.Variable)
+	errs = make(map[int]error)
+	for _, g := range gs {
+		s, err := d.process.ConvertEvalScope(g.ID, 0)
+		if err != nil {
+			continue
+		}
+		v, err := s.EvalVariable(expr, cfg)
+		if err != nil {
+			if _, unresolved := err.(proc.UnresolvedSymbolError); !unresolved {
+				errs[g.ID] = err
+			}
+			continue
+		}
+		vars[g.ID] = api.ConvertVar(v)
+	}
+	return vars, errs, nil
+}
+
+// UnwrapError evaluates 'expr' in the scope provided and returns the chain
+// of errors it wraps, starting with the value of 'expr'.
+func (d *Debugger) UnwrapError(scope api.EvalScope, expr string, cfg proc.LoadConfig) ([]api.Variable, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	s, err := d.convertEvalScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := s.UnwrapError(expr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return convertVars(chain), nil
+}
+
+// SyncObjectInfo evaluates 'expr' in the scope provided and decodes the
+// internal state of the sync.WaitGroup, sync.Once or sync.Cond it refers to.
+func (d *Debugger) SyncObjectInfo(scope api.EvalScope, expr string) (*api.SyncObject, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	s, err := d.convertEvalScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := s.SyncObjectInfo(expr)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertSyncObject(obj), nil
+}
+
+// VariableAddress evaluates 'symbol' in the scope provided and returns
+// the address and size in bytes of the memory backing the result.
+func (d *Debugger) VariableAddress(scope api.EvalScope, symbol string) (uint64, int64, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	s, err := d.convertEvalScope(scope)
+	if err != nil {
+		return 0, 0, err
+	}
+	v, err := s.EvalVariable(symbol, proc.LoadConfig{})
+	if err != nil {
+		return 0, 0, err
+	}
+	if v.Addr == 0 || v.DwarfType == nil {
+		return 0, 0, fmt.Errorf("can not take address of \"%s\"", symbol)
+	}
+	return uint64(v.Addr), v.RealType.Size(), nil
+}
+
+// SetVariableInScope will set the value of the variable represented by
+// 'symbol' to the value given, in the given scope.
+func (d *Debugger) SetVariableInScope(scope api.EvalScope, symbol, value string) error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	s, err := d.convertEvalScope(scope)
+	if err != nil {
+		return err
+	}
+	return s.SetVariable(symbol, value)
+}
+
+// Goroutines will return a list of goroutines in the target process.
+func (d *Debugger) Goroutines() ([]*api.Goroutine, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	goroutines := []*api.Goroutine{}
+	gs, err := d.process.GoroutinesInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gs {
+		goroutines = append(goroutines, api.ConvertGoroutine(g))
+	}
+	return goroutines, err
+}
+
+// FilterGoroutines returns the goroutines selected by filter, sorted by
+// ID, starting from the start-th one. At most count goroutines are
+// returned if count is greater than zero. The total number of goroutines
+// selected by filter is also returned.
+func (d *Debugger) FilterGoroutines(filter api.GoroutineFilter, start, count int) ([]*api.Goroutine, int, error) {
+	var fnregex *regexp.Regexp
+	if filter.Function != "" {
+		var err error
+		fnregex, err = regexp.Compile(filter.Function)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid function filter: %v", err)
+		}
+	}
+	if start < 0 {
+		return nil, 0, fmt.Errorf("invalid start %d", start)
+	}
+
+	gs, err := d.Goroutines()
+	if err != nil {
+		return nil, 0, err
+	}
+	goroutines := []*api.Goroutine{}
+	for _, g := range gs {
+		if filter.Status != "" && g.Status != filter.Status {
+			continue
+		}
+		if fnregex != nil && (g.CurrentLoc.Function == nil || !fnregex.MatchString(g.CurrentLoc.Function.Name)) {
+			continue
+		}
+		if filter.Label != "" && !matchGoroutineLabel(g, filter.Label) {
+			continue
+		}
+		goroutines = append(goroutines, g)
+	}
+	sort.Sort(goroutinesByID(goroutines))
+
+	total := len(goroutines)
+	if start > total {
+		start = total
+	}
+	goroutines = goroutines[start:]
+	if count > 0 && count < len(goroutines) {
+		goroutines = goroutines[:count]
+	}
+	return goroutines, total, nil
+}
+
+// matchGoroutineLabel returns true if g has the label described by
+// label, either a key or key=value.
+func matchGoroutineLabel(g *api.Goroutine, label string) bool {
+	if i := strings.Index(label, "="); i >= 0 {
+		v, ok := g.Labels[label[:i]]
+		return ok && v == label[i+1:]
+	}
+	_, ok := g.Labels[label]
+	return ok
+}
+
+type goroutinesByID []*api.Goroutine
+
+func (s goroutinesByID) Len() int           { return len(s) }
+func (s goroutinesByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s goroutinesByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+
+// GoroutineByAddress returns the goroutine whose runtime.g struct is
+// stored at the specified address.
+func (d *Debugger) GoroutineByAddress(addr uint64) (*api.Goroutine, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	g, err := d.process.FindGoroutineByAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertGoroutine(g), nil
+}
+
+// DirectRootReferences returns the words of goroutine stacks and package
+// variables that contain a pointer to addr, see
+// proc.(*Process).FindDirectRootReferences.
+func (d *Debugger) DirectRootReferences(addr uint64) ([]api.Reference, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	refs, err := d.process.FindDirectRootReferences(addr)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]api.Reference, 0, len(refs))
+	for _, ref := range refs {
+		r = append(r, api.ConvertReference(ref))
+	}
+	return r, nil
+}
+
+// GoroutineStackInfo returns the bounds of the stack of goroutine gid and
+// the number of bytes of it currently in use.
+func (d *Debugger) GoroutineStackInfo(gid int) (lo, hi, used uint64, err error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	g, err := d.process.FindGoroutine(gid)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if g == nil {
+		return 0, 0, 0, errors.New("no selected goroutine")
+	}
+	return g.StackUsage()
+}
+
+// BlockingChain returns the chain of goroutines that goroutine gid is
+// waiting for, see proc.(*Process).BlockingChain.
+func (d *Debugger) BlockingChain(gid int) ([]api.Goroutine, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	gs, err := d.process.BlockingChain(gid)
+	if err != nil {
+		return nil, err
+	}
+	chain := make([]api.Goroutine, 0, len(gs))
+	for _, g := range gs {
+		chain = append(chain, *api.ConvertGoroutine(g))
+	}
+	return chain, nil
+}
+
+// ContinueRecordingLines single steps the selected goroutine until it
+// reaches a breakpoint or maxInstructions instructions are executed, and
+// returns the new state along with the source lines that were executed.
+func (d *Debugger) ContinueRecordingLines(maxInstructions int) (state *api.DebuggerState, lines []api.Location, err error) {
+	defer func() { d.addOutput(state) }()
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if maxInstructions <= 0 {
+		return nil, nil, fmt.Errorf("invalid instruction count %d", maxInstructions)
+	}
+
+	log.Printf("continuing recording lines")
+	d.selectedFrame = 0
+	d.saveRegisters()
+	plines, err := d.process.ContinueRecordingLines(maxInstructions)
+	d.setStopReason(err, true, false)
+	lines = make([]api.Location, 0, len(plines))
+	for _, l := range plines {
+		lines = append(lines, api.ConvertLocation(l))
+	}
+	if err != nil {
+		if exitedErr, exited := err.(proc.ProcessExitedError); exited {
+			state = d.exitedState()
+			state.Err = errors.New(exitedErr.Error())
+			return state, lines, nil
+		}
+		return nil, nil, err
+	}
+	state, err = d.state()
+	if err != nil {
+		return state, lines, err
+	}
+	err = d.collectBreakpointInformation(state)
+	return state, lines, err
+}
+
+// maxExamineMemoryCount is the maximum number of words read by a single
+// call to ExamineMemory.
+const maxExamineMemoryCount = 1 << 16
+
+// ExamineMemory reads count words of wordSize bytes starting at addr. If
+// asPointers is set each word is also classified as a pointer to code,
+// package variables or the heap.
+func (d *Debugger) ExamineMemory(addr uint64, count, wordSize int, asPointers bool) ([]api.MemWord, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	switch wordSize {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("invalid word size %d", wordSize)
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid count %d", count)
+	}
+	if count > maxExamineMemoryCount {
+		return nil, fmt.Errorf("count %d too large, at most %d words can be examined at once", count, maxExamineMemoryCount)
+	}
+	mem, err := d.process.ReadMemory(addr, count*wordSize)
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([]api.MemWord, count)
+	vals := make([]uint64, count)
+	for i := range words {
+		b := mem[i*wordSize : (i+1)*wordSize]
+		switch wordSize {
+		case 1:
+			vals[i] = uint64(b[0])
+		case 2:
+			vals[i] = uint64(binary.LittleEndian.Uint16(b))
+		case 4:
+			vals[i] = uint64(binary.LittleEndian.Uint32(b))
+		case 8:
+			vals[i] = binary.LittleEndian.Uint64(b)
+		}
+		words[i] = api.MemWord{Addr: addr + uint64(i*wordSize), Value: vals[i]}
+	}
+	if asPointers {
+		for i, pt := range d.process.ClassifyPointers(vals) {
+			api.ConvertPointerTarget(&words[i], pt)
+		}
+	}
+	return words, nil
+}
+
+// ReadMemory reads length bytes of the target's memory starting at addr.
+// If the range crosses unmapped memory the readable prefix is returned
+// along with a *proc.PartialReadError.
+func (d *Debugger) ReadMemory(addr uint64, length int) ([]byte, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if length <= 0 || length > proc.MaxReadMemorySize {
+		return nil, fmt.Errorf("invalid length %d, at most %d bytes can be read at once", length, proc.MaxReadMemorySize)
+	}
+	return d.process.ReadMemory(addr, length)
+}
+
+// WriteMemory writes data to the target's memory starting at addr and
+// returns the number of bytes written.
+func (d *Debugger) WriteMemory(addr uint64, data []byte) (int, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if len(data) == 0 {
+		return 0, nil
+	}
+	return d.process.WriteMemory(addr, data)
+}
+
+// RuntimeConfig returns the configuration of the Go runtime of the target.
+func (d *Debugger) RuntimeConfig() (*api.RuntimeConfig, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	cfg, err := d.process.RuntimeConfig()
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertRuntimeConfig(cfg), nil
+}
+
+// ModuleInfo returns the module information and build ID embedded in the
+// executable.
+func (d *Debugger) ModuleInfo() (*api.ModuleInfo, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	bi, err := d.process.BuildInfo()
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertBuildInfo(bi, d.process.BuildID()), nil
+}
+
+// Stacktrace returns a list of Stackframes for the given goroutine. The
+// length of the returned list will be min(stack_len, depth).
+// If 'full' is true, then local vars, function args, etc will be returned as well.
+// If readDefers is true the deferred calls of each frame are returned too.
+func (d *Debugger) Stacktrace(goroutineID, depth int, readDefers bool, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+	return d.stacktrace(goroutineID, depth, readDefers, cfg)
+}
+
+func (d *Debugger) stacktrace(goroutineID, depth int, readDefers bool, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
+	var rawlocs []proc.Stackframe
+
+	g, err := d.process.FindGoroutine(goroutineID)
+	if err != nil {
+		return nil, err
+	}
+
+	if g == nil {
+		rawlocs, err = d.process.CurrentThread.Stacktrace(depth)
+	} else {
+		rawlocs, err = g.Stacktrace(depth)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if readDefers && g != nil {
+		if err := g.ReadDefers(rawlocs); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.convertStacktrace(rawlocs, cfg)
+}
+
+// DiffStacks returns the stacktraces of goroutines gidA and gidB and the
+// index, counted from the outermost frame, of the first frame where they
+// differ, see api.DiffStacks.
+func (d *Debugger) DiffStacks(gidA, gidB int) (int, []api.Stackframe, []api.Stackframe, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	a, err := d.stacktrace(gidA, activeFunctionsStackDepth, false, nil)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	b, err := d.stacktrace(gidB, activeFunctionsStackDepth, false, nil)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return api.DiffStacks(a, b), a, b, nil
+}
+
+func (d *Debugger) convertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
+	locations := make([]api.Stackframe, 0, len(rawlocs))
+	for i := range rawlocs {
+		frame := api.Stackframe{Location: api.ConvertLocation(rawlocs[i].Call)}
+		if cfg != nil {
+			var err error
+			scope := rawlocs[i].Scope(d.process.CurrentThread)
+			locals, err := scope.LocalVariables(*cfg)
+			if err != nil {
+				return nil, err
 			}
 			arguments, err := scope.FunctionArguments(*cfg)
 			if err != nil {
@@ -748,23 +2252,373 @@ func (d *Debugger) convertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadCo
 			frame.Locals = convertVars(locals)
 			frame.Arguments = convertVars(arguments)
 		}
+		for _, defr := range rawlocs[i].Defers {
+			frame.Defers = append(frame.Defers, d.convertDefer(defr, cfg))
+		}
 		locations = append(locations, frame)
 	}
 
 	return locations, nil
 }
 
+func (d *Debugger) convertDefer(defr *proc.Defer, cfg *proc.LoadConfig) api.Defer {
+	r := api.Defer{SP: defr.SP}
+	file, line, fn := d.process.PCToLine(defr.DeferredPC)
+	r.DeferredLoc = api.ConvertLocation(proc.Location{PC: defr.DeferredPC, File: file, Line: line, Fn: fn})
+	file, line, fn = d.process.PCToLine(defr.DeferPC)
+	r.DeferLoc = api.ConvertLocation(proc.Location{PC: defr.DeferPC, File: file, Line: line, Fn: fn})
+	if defr.Unreadable != nil {
+		r.Unreadable = defr.Unreadable.Error()
+		return r
+	}
+	if cfg != nil {
+		args, err := defr.Arguments(d.process.CurrentThread, *cfg)
+		if err != nil {
+			r.Unreadable = err.Error()
+		}
+		r.Arguments = convertVars(args)
+	}
+	return r
+}
+
+// exportStackDepth is the maximum number of frames of each goroutine
+// written by ExportState.
+const exportStackDepth = 50
+
+// ExportState returns a snapshot of the stopped process containing the
+// registers of all threads, the stacktraces of all goroutines and the
+// arguments and local variables of the frames of the selected goroutine.
+// Goroutines whose stacktrace can not be read are included with the
+// error.
+func (d *Debugger) ExportState() (*api.StateSnapshot, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	state, err := d.state()
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &api.StateSnapshot{
+		Pid:       d.process.Pid,
+		State:     state,
+		Registers: make(map[int]api.Registers, len(d.process.Threads)),
+	}
+
+	for id, thread := range d.process.Threads {
+		regs, err := thread.Registers()
+		if err != nil {
+			return nil, fmt.Errorf("could not read registers of thread %d: %v", id, err)
+		}
+		snapshot.Registers[id] = api.ConvertRegisters(regs.Slice(), nil)
+	}
+
+	gs, err := d.process.GoroutinesInfo()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &proc.LoadConfig{true, 1, 64, 64, -1}
+	for _, g := range gs {
+		var frameCfg *proc.LoadConfig
+		if d.process.SelectedGoroutine != nil && g.ID == d.process.SelectedGoroutine.ID {
+			frameCfg = cfg
+		}
+		gsnap := api.GoroutineSnapshot{Goroutine: api.ConvertGoroutine(g)}
+		rawlocs, err := g.Stacktrace(exportStackDepth)
+		if err == nil {
+			gsnap.Stacktrace, err = d.convertStacktrace(rawlocs, frameCfg)
+		}
+		if err != nil {
+			gsnap.StacktraceError = err.Error()
+		}
+		snapshot.Goroutines = append(snapshot.Goroutines, gsnap)
+	}
+	return snapshot, nil
+}
+
+// GCPercent returns the garbage collection target percentage of the
+// process, a negative value means that the garbage collector is disabled.
+func (d *Debugger) GCPercent() (int, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+	return d.process.GCPercent()
+}
+
+// ForceGC runs a garbage collection in the process.
+func (d *Debugger) ForceGC() error {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+	if err := d.process.FunctionCallsSupported(); err != nil {
+		return fmt.Errorf("ForceGC is not supported by this runtime: %v", err)
+	}
+	return d.process.ForceGC()
+}
+
+// SetGCPercent sets the garbage collection target percentage of the
+// process and returns the previous value.
+func (d *Debugger) SetGCPercent(pct int) (int, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+	if err := d.process.FunctionCallsSupported(); err != nil {
+		return 0, fmt.Errorf("SetGCPercent is not supported by this runtime: %v", err)
+	}
+	return d.process.SetGCPercent(pct)
+}
+
+// activeFunctionsStackDepth is the maximum number of frames of each
+// goroutine examined by ActiveFunctions and DiffStacks.
+const activeFunctionsStackDepth = 1024
+
+// BreakOnChannelOp creates breakpoints that stop the process when a
+// goroutine sends to, if op is api.ChanSend, or receives from, if op is
+// api.ChanRecv, the channel expr, evaluated in scope. If op is empty both
+// operations are selected.
+// The breakpoints are set on runtime.chansend and runtime.chanrecv with a
+// condition on the channel, they report the goroutine that executes the
+// operation and, for sends, the value being sent. Breakpoints on the same
+// runtime function are shared by all the channels selected, their
+// condition matches any of them.
+// Operations executed by select statements with more than one case are
+// not caught. The breakpoints are cleared by Restart, since the channels
+// they refer to belong to the old process.
+func (d *Debugger) BreakOnChannelOp(scope api.EvalScope, expr string, op string) ([]*api.Breakpoint, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	var fns []string
+	switch op {
+	case api.ChanSend:
+		fns = []string{"runtime.chansend"}
+	case api.ChanRecv:
+		fns = []string{"runtime.chanrecv"}
+	case "":
+		fns = []string{"runtime.chansend", "runtime.chanrecv"}
+	default:
+		return nil, fmt.Errorf("unknown channel operation %q", op)
+	}
+
+	s, err := d.convertEvalScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	addr, elemType, err := s.ChannelInfo(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var created, bps []*proc.Breakpoint
+	rollback := func(err error) ([]*api.Breakpoint, error) {
+		for _, bp := range created {
+			d.process.ClearBreakpoint(bp.Addr)
+		}
+		return nil, err
+	}
+	for _, fn := range fns {
+		pc, err := d.process.FindFunctionLocation(fn, true, 0)
+		if err != nil {
+			return rollback(err)
+		}
+		bp, exists := d.process.Breakpoints[pc]
+		if exists {
+			if cbp := d.chanBreakpoints[pc]; cbp == nil || cbp.id != bp.ID {
+				return rollback(fmt.Errorf("a breakpoint already exists on %s", fn))
+			}
+		} else {
+			bp, err = d.process.SetBreakpoint(pc, proc.UserBreakpoint, nil)
+			if err != nil {
+				return rollback(err)
+			}
+			created = append(created, bp)
+		}
+		bps = append(bps, bp)
+	}
+
+	if d.chanBreakpoints == nil {
+		d.chanBreakpoints = make(map[uint64]*chanBreakpoint)
+	}
+	r := make([]*api.Breakpoint, len(bps))
+	for i, bp := range bps {
+		cbp := d.chanBreakpoints[bp.Addr]
+		if cbp == nil || cbp.id != bp.ID {
+			cbp = &chanBreakpoint{id: bp.ID}
+		}
+		cbp.add(addr, elemType)
+		if err := copyBreakpointInfo(bp, cbp.request(fns[i] == "runtime.chansend")); err != nil {
+			return rollback(err)
+		}
+		d.chanBreakpoints[bp.Addr] = cbp
+		r[i] = api.ConvertBreakpoint(bp)
+	}
+	return r, nil
+}
+
+// chanBreakpoint is a breakpoint on runtime.chansend or runtime.chanrecv
+// created by BreakOnChannelOp.
+type chanBreakpoint struct {
+	id int
+	// chans are the addresses of the runtime.hchan structs of the
+	// channels selected and elemTypes the types of their elements.
+	chans     []uint64
+	elemTypes []string
+}
+
+func (cbp *chanBreakpoint) add(addr uint64, elemType string) {
+	for _, c := range cbp.chans {
+		if c == addr {
+			return
+		}
+	}
+	cbp.chans = append(cbp.chans, addr)
+	cbp.elemTypes = append(cbp.elemTypes, elemType)
+}
+
+// request returns the breakpoint information of cbp, its condition matches
+// all the channels selected. For sends the value sent is reported if all
+// channels have the same element type, its address otherwise.
+func (cbp *chanBreakpoint) request(send bool) *api.Breakpoint {
+	conds := make([]string, len(cbp.chans))
+	for i, c := range cbp.chans {
+		conds[i] = fmt.Sprintf("c == (*runtime.hchan)(%#x)", c)
+	}
+	requested := &api.Breakpoint{
+		Goroutine: true,
+		Cond:      strings.Join(conds, " || "),
+	}
+	if send {
+		sameType := true
+		for _, typ := range cbp.elemTypes {
+			if typ != cbp.elemTypes[0] {
+				sameType = false
+			}
+		}
+		if sameType {
+			requested.Variables = []string{fmt.Sprintf("*(*%s)(ep)", strconv.Quote(cbp.elemTypes[0]))}
+		} else {
+			requested.Variables = []string{"ep"}
+		}
+	}
+	return requested
+}
+
+// CreateWatchpoint sets a hardware watchpoint on the memory of expr,
+// evaluated in scope, that stops on the accesses selected by kind.
+// Watchpoints on local variables are removed when the variable goes out
+// of scope and reported in the WatchOutOfScope field of the next state.
+// Watchpoints are not preserved by Restart.
+func (d *Debugger) CreateWatchpoint(scope api.EvalScope, expr string, kind api.WatchKind) (*api.Breakpoint, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	s, err := d.convertEvalScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	bp, err := d.process.SetWatchpoint(s, expr, proc.WatchType(kind), nil)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertBreakpoint(bp), nil
+}
+
+// ActiveFunctions returns, for each function that appears on the stack of
+// at least one goroutine, the number of goroutines that have it on their
+// stack. A recursive function is counted once per goroutine.
+func (d *Debugger) ActiveFunctions() (map[string]int, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	gs, err := d.process.GoroutinesInfo()
+	if err != nil {
+		return nil, err
+	}
+	r := map[string]int{}
+	for _, g := range gs {
+		rawlocs, err := g.Stacktrace(activeFunctionsStackDepth)
+		if err != nil {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, frame := range rawlocs {
+			if frame.Call.Fn == nil || seen[frame.Call.Fn.Name] {
+				continue
+			}
+			seen[frame.Call.Fn.Name] = true
+			r[frame.Call.Fn.Name]++
+		}
+	}
+	return r, nil
+}
+
 // FindLocation will find the location specified by 'locStr'.
 func (d *Debugger) FindLocation(scope api.EvalScope, locStr string) ([]api.Location, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
+	return d.findLocation(scope, locStr)
+}
+
+// FindLocationVerbose is like FindLocation but also returns the text of
+// the source line of each location.
+func (d *Debugger) FindLocationVerbose(scope api.EvalScope, locStr string) ([]api.Location, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+	locs, err := d.findLocation(scope, locStr)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]string)
+	for i := range locs {
+		lines, ok := files[locs[i].File]
+		if !ok {
+			// a missing source file is not an error, the location is still valid
+			lines, _ = readLines(locs[i].File)
+			files[locs[i].File] = lines
+		}
+		if locs[i].Line >= 1 && locs[i].Line <= len(lines) {
+			locs[i].Source = lines[locs[i].Line-1]
+		}
+	}
+	return locs, nil
+}
+
+// readLines returns all the lines of file, without their line terminator.
+// Unlike bufio.Scanner there is no limit on the length of a line.
+func readLines(file string) ([]string, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
 
+	var lines []string
+	buf := bufio.NewReader(fh)
+	for {
+		line, err := buf.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line == "" && err == io.EOF {
+			return lines, nil
+		}
+		line = strings.TrimSuffix(line, "\n")
+		lines = append(lines, strings.TrimSuffix(line, "\r"))
+		if err == io.EOF {
+			return lines, nil
+		}
+	}
+}
+
+func (d *Debugger) findLocation(scope api.EvalScope, locStr string) ([]api.Location, error) {
 	loc, err := parseLocationSpec(locStr)
 	if err != nil {
 		return nil, err
 	}
 
-	s, _ := d.process.ConvertEvalScope(scope.GoroutineID, scope.Frame)
+	s, _ := d.convertEvalScope(scope)
+	if s != nil && d.scopeFrame(scope) > 0 {
+		// The PC of outer frames is a return address, which can belong to
+		// the line after the call, relative locations must be resolved
+		// starting from the call instruction.
+		s.PC--
+	}
 
 	locs, err := loc.Find(d, s, locStr)
 	for i := range locs {
@@ -778,10 +2632,35 @@ func (d *Debugger) FindLocation(scope api.EvalScope, locStr string) ([]api.Locat
 
 // Disassembles code between startPC and endPC
 // if endPC == 0 it will find the function containing startPC and disassemble the whole function
-func (d *Debugger) Disassemble(scope api.EvalScope, startPC, endPC uint64, flavour api.AssemblyFlavour) (api.AsmInstructions, error) {
+// if branchesOnly is set only call, jump and return instructions are returned
+func (d *Debugger) Disassemble(scope api.EvalScope, startPC, endPC uint64, flavour api.AssemblyFlavour, branchesOnly bool) (api.AsmInstructions, error) {
+	if !proc.AssemblyFlavour(flavour).Valid() {
+		return nil, fmt.Errorf("unknown assembly flavour %d", flavour)
+	}
+
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
+	return d.disassemble(scope, startPC, endPC, flavour, branchesOnly)
+}
+
+// DisassembleFunction disassembles the whole function called funcName.
+func (d *Debugger) DisassembleFunction(scope api.EvalScope, funcName string, flavour api.AssemblyFlavour, branchesOnly bool) (api.AsmInstructions, error) {
+	if !proc.AssemblyFlavour(flavour).Valid() {
+		return nil, fmt.Errorf("unknown assembly flavour %d", flavour)
+	}
+
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	entry, err := d.process.FindFunctionLocation(funcName, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	return d.disassemble(scope, entry, 0, flavour, branchesOnly)
+}
+
+func (d *Debugger) disassemble(scope api.EvalScope, startPC, endPC uint64, flavour api.AssemblyFlavour, branchesOnly bool) (api.AsmInstructions, error) {
 	if endPC == 0 {
 		_, _, fn := d.process.PCToLine(startPC)
 		if fn == nil {
@@ -794,7 +2673,7 @@ func (d *Debugger) Disassemble(scope api.EvalScope, startPC, endPC uint64, flavo
 	currentGoroutine := true
 	thread := d.process.CurrentThread
 
-	if s, err := d.process.ConvertEvalScope(scope.GoroutineID, scope.Frame); err == nil {
+	if s, err := d.convertEvalScope(scope); err == nil {
 		thread = s.Thread
 		if scope.GoroutineID != -1 {
 			g, _ := s.Thread.GetG()
@@ -808,10 +2687,13 @@ func (d *Debugger) Disassemble(scope api.EvalScope, startPC, endPC uint64, flavo
 	if err != nil {
 		return nil, err
 	}
-	disass := make(api.AsmInstructions, len(insts))
+	disass := make(api.AsmInstructions, 0, len(insts))
 
 	for i := range insts {
-		disass[i] = api.ConvertAsmInstruction(insts[i], insts[i].Text(proc.AssemblyFlavour(flavour)))
+		if branchesOnly && !insts[i].IsBranch() {
+			continue
+		}
+		disass = append(disass, api.ConvertAsmInstruction(insts[i], insts[i].Text(proc.AssemblyFlavour(flavour))))
 	}
 
 	return disass, nil
@@ -13,3 +13,8 @@ func attachErrorMessage(pid int, err error) error {
 func stopProcess(pid int) error {
 	return sys.Kill(pid, sys.SIGSTOP)
 }
+
+func pipeBuffered(fd uintptr) int {
+	// output redirection is not supported on darwin
+	return 0
+}
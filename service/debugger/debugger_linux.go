@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"syscall"
+	"unsafe"
 )
 
 func attachErrorMessage(pid int, err error) error {
@@ -33,3 +34,14 @@ func attachErrorMessage(pid int, err error) error {
 func stopProcess(pid int) error {
 	return sys.Kill(pid, sys.SIGSTOP)
 }
+
+// pipeBuffered returns the number of bytes written to the pipe fd and not
+// read yet.
+func pipeBuffered(fd uintptr) int {
+	var n int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCINQ, uintptr(unsafe.Pointer(&n)))
+	if errno != 0 {
+		return 0
+	}
+	return int(n)
+}
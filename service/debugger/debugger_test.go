@@ -0,0 +1,107 @@
+package debugger
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/derekparker/delve/proc"
+	"github.com/derekparker/delve/service/api"
+)
+
+func TestApplyBreakpointTemplate(t *testing.T) {
+	template := api.Breakpoint{
+		Cond:        "i == 1",
+		Tracepoint:  true,
+		Goroutine:   true,
+		GoroutineID: 1,
+		ThreadID:    2,
+		Stacktrace:  3,
+		Variables:   []string{"i"},
+		Ignore:      4,
+		HitCond:     "> 5",
+		Aggregate:   "i",
+		LogMessage:  "i = {i}",
+		LogToFile:   "/tmp/log",
+		ActiveAfter: time.Second,
+		ActiveUntil: time.Minute,
+	}
+	d := &Debugger{breakpointTemplates: map[string]api.Breakpoint{"t": template}}
+
+	bp, err := d.applyBreakpointTemplate(&api.Breakpoint{File: "main.go", Line: 10, Template: "t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := template
+	expected.File, expected.Line, expected.Template = "main.go", 10, "t"
+	if !reflect.DeepEqual(*bp, expected) {
+		t.Fatalf("template not applied:\n%#v\nexpected:\n%#v", bp, expected)
+	}
+
+	// properties of the breakpoint take precedence over the template
+	bp, err = d.applyBreakpointTemplate(&api.Breakpoint{Template: "t", HitCond: "== 1", LogMessage: "hit", ActiveUntil: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp.HitCond != "== 1" || bp.LogMessage != "hit" || bp.LogToFile != "" || bp.ActiveUntil != time.Hour || bp.ActiveAfter != time.Second {
+		t.Fatalf("template overrode the breakpoint: %#v", bp)
+	}
+
+	if _, err := d.applyBreakpointTemplate(&api.Breakpoint{Template: "nonexistent"}); err == nil {
+		t.Fatal("expected error applying undefined template")
+	}
+}
+
+func TestCopyBreakpointInfoInvalid(t *testing.T) {
+	bp := &proc.Breakpoint{}
+	if err := copyBreakpointInfo(bp, &api.Breakpoint{Name: "bp1", Cond: "i == 1", GoroutineID: 2, ThreadID: 3}); err != nil {
+		t.Fatal(err)
+	}
+	for _, requested := range []api.Breakpoint{
+		{Name: "bp2", GoroutineID: -1},
+		{Name: "bp2", ThreadID: -1},
+		{Name: "bp2", Cond: "i =="},
+		{Name: "bp2", HitCond: "> x"},
+		{Name: "bp2", ActiveAfter: time.Minute, ActiveUntil: time.Second},
+	} {
+		if err := copyBreakpointInfo(bp, &requested); err == nil {
+			t.Fatalf("expected error copying %#v", requested)
+		}
+		if bp.Name != "bp1" || bp.Cond == nil || bp.GoroutineID != 2 || bp.ThreadID != 3 {
+			t.Fatalf("breakpoint changed by invalid request %#v: %#v", requested, bp)
+		}
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("x", 100*1024)
+	fh, err := ioutil.TempFile("", "readlines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fh.Name())
+	fh.WriteString("first\r\n" + long + "\n\nlast")
+	fh.Close()
+
+	lines, err := readLines(fh.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"first", long, "", "last"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("wrong lines %d %q", len(lines), lines[0])
+	}
+}
+
+func TestNewUnknownBackend(t *testing.T) {
+	// rr is not implemented, it must not be accepted as a backend
+	for _, backend := range []string{"rr", "gdb"} {
+		_, err := New(&Config{ProcessArgs: []string{"nonexistent"}, Backend: backend})
+		if err == nil || !strings.Contains(err.Error(), "unknown backend") {
+			t.Fatalf("backend %q: expected unknown backend error, got %v", backend, err)
+		}
+	}
+}
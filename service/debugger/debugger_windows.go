@@ -14,3 +14,8 @@ func stopProcess(pid int) error {
 	// the process.
 	return nil
 }
+
+func pipeBuffered(fd uintptr) int {
+	// output redirection is not supported on windows
+	return 0
+}
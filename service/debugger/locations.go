@@ -24,6 +24,10 @@ type NormalLocationSpec struct {
 	Base       string
 	FuncBase   *FuncLocationSpec
 	LineOffset int
+	// BodyOffset is set if LineOffset was specified as +<offset>, it is
+	// relative to the first statement of the function instead of its
+	// declaration.
+	BodyOffset bool
 }
 
 type RegexLocationSpec struct {
@@ -121,6 +125,7 @@ func parseLocationSpecDefault(locStr, rest string) (LocationSpec, error) {
 
 	rest = v[1]
 
+	spec.BodyOffset = strings.HasPrefix(rest, "+")
 	var err error
 	spec.LineOffset, err = strconv.Atoi(rest)
 	if err != nil || spec.LineOffset < 0 {
@@ -340,21 +345,19 @@ func (loc *NormalLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr s
 		}
 	}
 
-	if loc.FuncBase != nil {
-		for _, f := range funcs {
-			if f.Sym == nil {
-				continue
-			}
-			if loc.FuncBase.Match(f.Sym) {
-				if loc.Base == f.Name {
-					// if an exact match for the function name is found use it
-					candidates = []string{f.Name}
-					break
-				}
-				if len(candidates) < maxFindLocationCandidates {
-					candidates = append(candidates, f.Name)
-				}
-			}
+	for _, f := range funcs {
+		if f.Sym == nil {
+			continue
+		}
+		if loc.Base == f.Name {
+			// if an exact match for the function name is found use it, even
+			// if the name can not be parsed as a function location (for
+			// example nested closures or package paths containing dots)
+			candidates = []string{f.Name}
+			break
+		}
+		if loc.FuncBase != nil && loc.FuncBase.Match(f.Sym) && len(candidates) < maxFindLocationCandidates {
+			candidates = append(candidates, f.Name)
 		}
 	}
 
@@ -366,7 +369,16 @@ func (loc *NormalLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr s
 			if loc.LineOffset < 0 {
 				return nil, fmt.Errorf("Malformed breakpoint location, no line offset specified")
 			}
+			if loc.BodyOffset {
+				return nil, fmt.Errorf("Malformed breakpoint location, relative line offsets can only be used with functions")
+			}
 			addr, err = d.process.FindFileLocation(candidates[0], loc.LineOffset)
+		} else if loc.BodyOffset {
+			addr, err = d.process.FindFunctionLocation(candidates[0], true, 0)
+			if err == nil {
+				file, line, _ := d.process.PCToLine(addr)
+				addr, err = d.process.FindFileLocation(file, line+loc.LineOffset)
+			}
 		} else {
 			if loc.LineOffset < 0 {
 				addr, err = d.process.FindFunctionLocation(candidates[0], true, 0)
@@ -380,6 +392,17 @@ func (loc *NormalLocationSpec) Find(d *Debugger, scope *proc.EvalScope, locStr s
 		return []api.Location{{PC: addr}}, nil
 
 	case 0:
+		if loc.FuncBase != nil && loc.LineOffset < 0 {
+			// functions that are always inlined do not appear in the symbol
+			// table, return every inlined instance instead
+			if pcs, err := d.process.FindInlinedCallSites(loc.Base); err == nil {
+				r := make([]api.Location, len(pcs))
+				for i := range pcs {
+					r[i] = api.Location{PC: pcs[i]}
+				}
+				return r, nil
+			}
+		}
 		return nil, fmt.Errorf("Location \"%s\" not found", locStr)
 	default:
 		return nil, AmbiguousLocationError{Location: locStr, CandidatesString: candidates}
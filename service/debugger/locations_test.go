@@ -28,6 +28,10 @@ func assertNormalLocationSpec(t *testing.T, locstr string, tgt NormalLocationSpe
 		t.Fatalf("Location %q: expected 'LineOffset' %d got %d", locstr, tgt.LineOffset, nls.LineOffset)
 	}
 
+	if nls.BodyOffset != tgt.BodyOffset {
+		t.Fatalf("Location %q: expected 'BodyOffset' %v got %v", locstr, tgt.BodyOffset, nls.BodyOffset)
+	}
+
 	if tgt.FuncBase == nil {
 		return
 	}
@@ -43,26 +47,27 @@ func assertNormalLocationSpec(t *testing.T, locstr string, tgt NormalLocationSpe
 
 func TestFunctionLocationParsing(t *testing.T) {
 	// Function locations, simple package names, no line offset
-	assertNormalLocationSpec(t, "proc.(*Process).Continue", NormalLocationSpec{"proc.(*Process).Continue", &FuncLocationSpec{PackageName: "proc", ReceiverName: "Process", BaseName: "Continue"}, -1})
-	assertNormalLocationSpec(t, "proc.Process.Continue", NormalLocationSpec{"proc.Process.Continue", &FuncLocationSpec{PackageName: "proc", ReceiverName: "Process", BaseName: "Continue"}, -1})
-	assertNormalLocationSpec(t, "proc.Continue", NormalLocationSpec{"proc.Continue", &FuncLocationSpec{PackageOrReceiverName: "proc", BaseName: "Continue"}, -1})
-	assertNormalLocationSpec(t, "(*Process).Continue", NormalLocationSpec{"(*Process).Continue", &FuncLocationSpec{ReceiverName: "Process", BaseName: "Continue"}, -1})
-	assertNormalLocationSpec(t, "Continue", NormalLocationSpec{"Continue", &FuncLocationSpec{BaseName: "Continue"}, -1})
+	assertNormalLocationSpec(t, "proc.(*Process).Continue", NormalLocationSpec{"proc.(*Process).Continue", &FuncLocationSpec{PackageName: "proc", ReceiverName: "Process", BaseName: "Continue"}, -1, false})
+	assertNormalLocationSpec(t, "proc.Process.Continue", NormalLocationSpec{"proc.Process.Continue", &FuncLocationSpec{PackageName: "proc", ReceiverName: "Process", BaseName: "Continue"}, -1, false})
+	assertNormalLocationSpec(t, "proc.Continue", NormalLocationSpec{"proc.Continue", &FuncLocationSpec{PackageOrReceiverName: "proc", BaseName: "Continue"}, -1, false})
+	assertNormalLocationSpec(t, "(*Process).Continue", NormalLocationSpec{"(*Process).Continue", &FuncLocationSpec{ReceiverName: "Process", BaseName: "Continue"}, -1, false})
+	assertNormalLocationSpec(t, "Continue", NormalLocationSpec{"Continue", &FuncLocationSpec{BaseName: "Continue"}, -1, false})
 
 	// Function locations, simple package names, line offsets
-	assertNormalLocationSpec(t, "proc.(*Process).Continue:10", NormalLocationSpec{"proc.(*Process).Continue", &FuncLocationSpec{PackageName: "proc", ReceiverName: "Process", BaseName: "Continue"}, 10})
-	assertNormalLocationSpec(t, "proc.Process.Continue:10", NormalLocationSpec{"proc.Process.Continue", &FuncLocationSpec{PackageName: "proc", ReceiverName: "Process", BaseName: "Continue"}, 10})
-	assertNormalLocationSpec(t, "proc.Continue:10", NormalLocationSpec{"proc.Continue", &FuncLocationSpec{PackageOrReceiverName: "proc", BaseName: "Continue"}, 10})
-	assertNormalLocationSpec(t, "(*Process).Continue:10", NormalLocationSpec{"(*Process).Continue", &FuncLocationSpec{ReceiverName: "Process", BaseName: "Continue"}, 10})
-	assertNormalLocationSpec(t, "Continue:10", NormalLocationSpec{"Continue", &FuncLocationSpec{BaseName: "Continue"}, 10})
+	assertNormalLocationSpec(t, "proc.(*Process).Continue:10", NormalLocationSpec{"proc.(*Process).Continue", &FuncLocationSpec{PackageName: "proc", ReceiverName: "Process", BaseName: "Continue"}, 10, false})
+	assertNormalLocationSpec(t, "proc.Process.Continue:10", NormalLocationSpec{"proc.Process.Continue", &FuncLocationSpec{PackageName: "proc", ReceiverName: "Process", BaseName: "Continue"}, 10, false})
+	assertNormalLocationSpec(t, "proc.Continue:10", NormalLocationSpec{"proc.Continue", &FuncLocationSpec{PackageOrReceiverName: "proc", BaseName: "Continue"}, 10, false})
+	assertNormalLocationSpec(t, "(*Process).Continue:10", NormalLocationSpec{"(*Process).Continue", &FuncLocationSpec{ReceiverName: "Process", BaseName: "Continue"}, 10, false})
+	assertNormalLocationSpec(t, "Continue:10", NormalLocationSpec{"Continue", &FuncLocationSpec{BaseName: "Continue"}, 10, false})
+	assertNormalLocationSpec(t, "Continue:+10", NormalLocationSpec{"Continue", &FuncLocationSpec{BaseName: "Continue"}, 10, true})
 
 	// Function locations, package paths, no line offsets
-	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.(*Process).Continue", NormalLocationSpec{"github.com/derekparker/delve/proc.(*Process).Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", ReceiverName: "Process", BaseName: "Continue"}, -1})
-	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.Process.Continue", NormalLocationSpec{"github.com/derekparker/delve/proc.Process.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", ReceiverName: "Process", BaseName: "Continue"}, -1})
-	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.Continue", NormalLocationSpec{"github.com/derekparker/delve/proc.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", BaseName: "Continue"}, -1})
+	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.(*Process).Continue", NormalLocationSpec{"github.com/derekparker/delve/proc.(*Process).Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", ReceiverName: "Process", BaseName: "Continue"}, -1, false})
+	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.Process.Continue", NormalLocationSpec{"github.com/derekparker/delve/proc.Process.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", ReceiverName: "Process", BaseName: "Continue"}, -1, false})
+	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.Continue", NormalLocationSpec{"github.com/derekparker/delve/proc.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", BaseName: "Continue"}, -1, false})
 
 	// Function locations, package paths, line offsets
-	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.(*Process).Continue:10", NormalLocationSpec{"github.com/derekparker/delve/proc.(*Process).Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", ReceiverName: "Process", BaseName: "Continue"}, 10})
-	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.Process.Continue:10", NormalLocationSpec{"github.com/derekparker/delve/proc.Process.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", ReceiverName: "Process", BaseName: "Continue"}, 10})
-	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.Continue:10", NormalLocationSpec{"github.com/derekparker/delve/proc.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", BaseName: "Continue"}, 10})
+	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.(*Process).Continue:10", NormalLocationSpec{"github.com/derekparker/delve/proc.(*Process).Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", ReceiverName: "Process", BaseName: "Continue"}, 10, false})
+	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.Process.Continue:10", NormalLocationSpec{"github.com/derekparker/delve/proc.Process.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", ReceiverName: "Process", BaseName: "Continue"}, 10, false})
+	assertNormalLocationSpec(t, "github.com/derekparker/delve/proc.Continue:10", NormalLocationSpec{"github.com/derekparker/delve/proc.Continue", &FuncLocationSpec{PackageName: "github.com/derekparker/delve/proc", BaseName: "Continue"}, 10, false})
 }
@@ -0,0 +1,134 @@
+package debugger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/derekparker/delve/proc"
+	"github.com/derekparker/delve/service/api"
+)
+
+// logFile is a file written by tracepoints with a LogToFile path.
+type logFile struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+// logFile returns the file at path, creating or truncating it the first
+// time it is used since the process was started.
+func (d *Debugger) logFile(path string) (*logFile, error) {
+	if lf, ok := d.logFiles[path]; ok {
+		return lf, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if d.logFiles == nil {
+		d.logFiles = make(map[string]*logFile)
+	}
+	lf := &logFile{f: f, w: bufio.NewWriter(f)}
+	d.logFiles[path] = lf
+	return lf, nil
+}
+
+// flushLogFiles writes the buffered log messages to their files.
+func (d *Debugger) flushLogFiles() error {
+	for path, lf := range d.logFiles {
+		if err := lf.w.Flush(); err != nil {
+			return fmt.Errorf("could not write log file %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// closeLogFiles flushes and closes all log files, they will be truncated
+// the next time they are used.
+func (d *Debugger) closeLogFiles() error {
+	err := d.flushLogFiles()
+	for _, lf := range d.logFiles {
+		lf.f.Close()
+	}
+	d.logFiles = nil
+	return err
+}
+
+// writeLogMessage appends the LogMessage of bp, rendered in scope s, to
+// its LogToFile.
+func (d *Debugger) writeLogMessage(s *proc.EvalScope, bp *proc.Breakpoint) error {
+	lf, err := d.logFile(bp.LogToFile)
+	if err != nil {
+		return fmt.Errorf("could not open log file of breakpoint %d: %v", bp.ID, err)
+	}
+	lf.w.WriteString(renderLogMessage(s, bp.LogMessage))
+	if err := lf.w.WriteByte('\n'); err != nil {
+		return fmt.Errorf("could not write log file of breakpoint %d: %v", bp.ID, err)
+	}
+	return nil
+}
+
+// renderLogMessage replaces every expression between braces in msg with
+// its value, evaluated in scope s.
+func renderLogMessage(s *proc.EvalScope, msg string) string {
+	var buf bytes.Buffer
+	for {
+		start := strings.Index(msg, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(msg[start:], "}")
+		if end < 0 {
+			break
+		}
+		end += start
+		buf.WriteString(msg[:start])
+		v, err := s.EvalVariable(msg[start+1:end], proc.LoadConfig{true, 1, 64, 64, -1})
+		if err != nil {
+			fmt.Fprintf(&buf, "<%v>", err)
+		} else {
+			buf.WriteString(api.ConvertVar(v).SinglelineString())
+		}
+		msg = msg[end+1:]
+	}
+	buf.WriteString(msg)
+	return buf.String()
+}
+
+// checkLogMessage returns an error if one of the expressions of msg can
+// not be parsed.
+func checkLogMessage(msg string) error {
+	for {
+		start := strings.Index(msg, "{")
+		if start < 0 {
+			return nil
+		}
+		end := strings.Index(msg[start:], "}")
+		if end < 0 {
+			return fmt.Errorf("unterminated expression in log message: %s", msg[start:])
+		}
+		end += start
+		if _, err := proc.ParseExpr(msg[start+1 : end]); err != nil {
+			return fmt.Errorf("invalid expression in log message %q: %v", msg[start+1:end], err)
+		}
+		msg = msg[end+1:]
+	}
+}
+
+// addTracepointResult appends the LogMessage of bp, rendered in scope s of
+// thread th, to d.tracepointResults.
+func (d *Debugger) addTracepointResult(th *proc.Thread, s *proc.EvalScope, bp *proc.Breakpoint) {
+	r := api.TracepointResult{
+		BreakpointID: bp.ID,
+		Message:      renderLogMessage(s, bp.LogMessage),
+	}
+	if loc, err := th.Location(); err == nil {
+		r.File, r.Line = loc.File, loc.Line
+	}
+	if g, err := th.GetG(); err == nil && g != nil {
+		r.GoroutineID = g.ID
+	}
+	d.tracepointResults = append(d.tracepointResults, r)
+}
@@ -0,0 +1,138 @@
+package debugger
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/derekparker/delve/service/api"
+)
+
+const (
+	// maxOutputBuffered is the maximum number of bytes of output kept until
+	// a client reads them, the oldest output is dropped first.
+	maxOutputBuffered = 1 << 20
+	// outputSyncTimeout is the maximum time waited for the output written
+	// by the process before it stopped to be read from the pipes.
+	outputSyncTimeout = 100 * time.Millisecond
+)
+
+// outputRedirect collects the standard output and error of the process,
+// written to the pipes returned by pipes.
+// The pipes are read continuously, the process is never blocked by a
+// client that does not read its output.
+type outputRedirect struct {
+	mu     sync.Mutex
+	events []api.OutputEvent
+	size   int
+	// readers are the file descriptors of the read ends of the pipes
+	// still open.
+	readers map[*os.File]uintptr
+}
+
+// pipes returns the write ends of two new pipes to be used as the
+// standard output and error of a new process. The caller must close them
+// once the process has been started.
+func (o *outputRedirect) pipes() (stdout, stderr *os.File, err error) {
+	outr, stdout, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	errr, stderr, err := os.Pipe()
+	if err != nil {
+		outr.Close()
+		stdout.Close()
+		return nil, nil, err
+	}
+	o.mu.Lock()
+	if o.readers == nil {
+		o.readers = make(map[*os.File]uintptr)
+	}
+	// Fd puts the files in blocking mode, it must be called before they
+	// are read
+	o.readers[outr] = outr.Fd()
+	o.readers[errr] = errr.Fd()
+	o.mu.Unlock()
+	go o.read(api.OutputStdout, outr)
+	go o.read(api.OutputStderr, errr)
+	return stdout, stderr, nil
+}
+
+// read appends the data read from r to the output of stream until the
+// write end of the pipe is closed by the process.
+func (o *outputRedirect) read(stream string, r *os.File) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			o.append(stream, string(buf[:n]))
+		}
+		if err != nil {
+			break
+		}
+	}
+	o.mu.Lock()
+	delete(o.readers, r)
+	o.mu.Unlock()
+	r.Close()
+}
+
+func (o *outputRedirect) append(stream, data string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if n := len(o.events); n > 0 && o.events[n-1].Stream == stream {
+		o.events[n-1].Data += data
+	} else {
+		o.events = append(o.events, api.OutputEvent{Stream: stream, Data: data})
+	}
+	o.size += len(data)
+	for o.size > maxOutputBuffered {
+		drop := o.size - maxOutputBuffered
+		if drop >= len(o.events[0].Data) {
+			o.size -= len(o.events[0].Data)
+			o.events = o.events[1:]
+		} else {
+			o.events[0].Data = o.events[0].Data[drop:]
+			o.size -= drop
+		}
+	}
+}
+
+// addOutput sets the Output of state, if not nil, to the output collected
+// since the previous state. It waits for the pipes to be drained and must
+// be called after processMutex is released.
+func (d *Debugger) addOutput(state *api.DebuggerState) {
+	if d.output == nil || state == nil {
+		return
+	}
+	state.Output = d.output.take()
+}
+
+// take returns the output collected since the previous call.
+// The output written by the process before it stopped is still in the
+// pipes when it is reported as stopped, take waits for it to be read so
+// that it is returned with the stop.
+func (o *outputRedirect) take() []api.OutputEvent {
+	deadline := time.Now().Add(outputSyncTimeout)
+	for time.Now().Before(deadline) && o.pending() {
+		time.Sleep(time.Millisecond)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	events := o.events
+	o.events, o.size = nil, 0
+	return events
+}
+
+// pending returns true if some output has been written to the pipes but
+// not read yet.
+func (o *outputRedirect) pending() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, fd := range o.readers {
+		if pipeBuffered(fd) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package debugger
+
+import (
+	"fmt"
+	"go/constant"
+	"math"
+	"sort"
+
+	"github.com/derekparker/delve/proc"
+	"github.com/derekparker/delve/service/api"
+)
+
+// continueAggregating resumes the process until it stops for a reason
+// other than reaching tracepoints with an Aggregate expression or a
+// LogMessage. Every time one of them is reached the value of the
+// expression is accumulated in d.tracepointStats and the log message is
+// appended to its LogToFile or to d.tracepointResults.
+func (d *Debugger) continueAggregating() error {
+	for {
+		if err := d.process.Continue(); err != nil {
+			d.flushLogFiles()
+			return err
+		}
+		resume, err := d.collectTracepointStats()
+		if err != nil || !resume {
+			if ferr := d.flushLogFiles(); err == nil {
+				err = ferr
+			}
+			return err
+		}
+	}
+}
+
+// collectTracepointStats records the value of the Aggregate expression,
+// and logs the LogMessage, of every tracepoint the threads are stopped
+// at. It returns true if the process only stopped because of aggregating
+// or logging tracepoints. Data is recorded even when some other thread
+// stopped for a different reason, so that no hit is lost. Threads are
+// visited in order of ID so that log messages are deterministic.
+func (d *Debugger) collectTracepointStats() (bool, error) {
+	ids := make([]int, 0, len(d.process.Threads))
+	for id := range d.process.Threads {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	aggregated, resume := false, true
+	for _, id := range ids {
+		th := d.process.Threads[id]
+		bp := th.CurrentBreakpoint
+		if bp == nil || !th.BreakpointConditionMet {
+			continue
+		}
+		if !bp.Tracepoint || (bp.Aggregate == "" && bp.LogMessage == "") {
+			resume = false
+			continue
+		}
+		aggregated = true
+		if err := d.collectTracepointHit(th, bp); err != nil {
+			return false, err
+		}
+	}
+	return aggregated && resume, nil
+}
+
+// collectTracepointHit records the Aggregate expression and logs the
+// LogMessage of bp, which thread th is stopped at.
+func (d *Debugger) collectTracepointHit(th *proc.Thread, bp *proc.Breakpoint) error {
+	s, err := th.Scope()
+	if err != nil {
+		return err
+	}
+	if bp.LogToFile != "" {
+		if err := d.writeLogMessage(s, bp); err != nil {
+			return err
+		}
+	} else if bp.LogMessage != "" {
+		d.addTracepointResult(th, s, bp)
+	}
+	if bp.Aggregate == "" {
+		return nil
+	}
+	v, err := s.EvalVariable(bp.Aggregate, proc.LoadConfig{})
+	if err != nil {
+		return fmt.Errorf("could not evaluate aggregate expression of breakpoint %d: %v", bp.ID, err)
+	}
+	x, err := numericValue(v)
+	if err != nil {
+		return fmt.Errorf("could not evaluate aggregate expression of breakpoint %d: %v", bp.ID, err)
+	}
+	stats := d.tracepointStats[bp.ID]
+	if stats == nil {
+		if d.tracepointStats == nil {
+			d.tracepointStats = make(map[int]*api.TracepointStats)
+		}
+		stats = &api.TracepointStats{Histogram: make(map[int64]uint64)}
+		d.tracepointStats[bp.ID] = stats
+	}
+	addTracepointSample(stats, x)
+	return nil
+}
+
+func numericValue(v *proc.Variable) (float64, error) {
+	if v.Unreadable != nil {
+		return 0, v.Unreadable
+	}
+	if v.Value == nil {
+		return 0, fmt.Errorf("%s is not a number", v.TypeString())
+	}
+	switch v.Value.Kind() {
+	case constant.Int, constant.Float:
+		x, _ := constant.Float64Val(constant.ToFloat(v.Value))
+		return x, nil
+	default:
+		return 0, fmt.Errorf("%s is not a number", v.TypeString())
+	}
+}
+
+func addTracepointSample(stats *api.TracepointStats, x float64) {
+	if stats.Count == 0 || x < stats.Min {
+		stats.Min = x
+	}
+	if stats.Count == 0 || x > stats.Max {
+		stats.Max = x
+	}
+	stats.Count++
+	stats.Sum += x
+	stats.Histogram[histogramBucket(x)]++
+}
+
+// histogramBucket returns the lower bound of the power of two bucket
+// containing x, or its opposite for negative values.
+func histogramBucket(x float64) int64 {
+	a := math.Abs(x)
+	if a < 1 || math.IsNaN(a) {
+		return 0
+	}
+	exp := math.Floor(math.Log2(a))
+	if exp > 62 {
+		exp = 62
+	}
+	b := int64(1) << uint(exp)
+	if x < 0 {
+		return -b
+	}
+	return b
+}
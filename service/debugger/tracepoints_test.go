@@ -0,0 +1,36 @@
+package debugger
+
+import (
+	"testing"
+
+	"github.com/derekparker/delve/service/api"
+)
+
+func TestHistogramBucket(t *testing.T) {
+	tc := []struct {
+		x      float64
+		bucket int64
+	}{
+		{0, 0}, {0.5, 0}, {-0.5, 0},
+		{1, 1}, {1.5, 1}, {2, 2}, {3, 2}, {4, 4}, {1000, 512},
+		{-1, -1}, {-3, -2}, {-1000, -512},
+	}
+	for _, c := range tc {
+		if b := histogramBucket(c.x); b != c.bucket {
+			t.Errorf("histogramBucket(%g) = %d, expected %d", c.x, b, c.bucket)
+		}
+	}
+}
+
+func TestAddTracepointSample(t *testing.T) {
+	stats := &api.TracepointStats{Histogram: map[int64]uint64{}}
+	for _, x := range []float64{3, -1, 10, 2} {
+		addTracepointSample(stats, x)
+	}
+	if stats.Count != 4 || stats.Min != -1 || stats.Max != 10 || stats.Sum != 14 {
+		t.Fatalf("wrong statistics %#v", stats)
+	}
+	if stats.Histogram[2] != 2 || stats.Histogram[-1] != 1 || stats.Histogram[8] != 1 {
+		t.Fatalf("wrong histogram %v", stats.Histogram)
+	}
+}
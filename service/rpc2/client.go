@@ -1,10 +1,14 @@
 package rpc2
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/rpc"
 	"net/rpc/jsonrpc"
+	"time"
 
 	"github.com/derekparker/delve/service"
 	"github.com/derekparker/delve/service/api"
@@ -47,6 +51,11 @@ func (c *RPCClient) Restart() error {
 	return c.call("Restart", RestartIn{}, out)
 }
 
+func (c *RPCClient) RestartWith(args, env []string) error {
+	out := new(RestartWithOut)
+	return c.call("RestartWith", RestartWithIn{args, env}, out)
+}
+
 func (c *RPCClient) GetState() (*api.DebuggerState, error) {
 	var out StateOut
 	err := c.call("State", StateIn{}, &out)
@@ -54,11 +63,42 @@ func (c *RPCClient) GetState() (*api.DebuggerState, error) {
 }
 
 func (c *RPCClient) Continue() <-chan *api.DebuggerState {
+	return c.continueCommand(api.Continue)
+}
+
+func (c *RPCClient) ContinueUntilGoroutineChange() <-chan *api.DebuggerState {
+	return c.continueCommand(api.ContinueUntilGoroutineChange)
+}
+
+func (c *RPCClient) ContinueTo(loc string) (*api.DebuggerState, error) {
+	var out ContinueToOut
+	err := c.call("ContinueTo", ContinueToIn{loc}, &out)
+	if out.State.Exited {
+		out.State.Err = fmt.Errorf("Process %d has exited with status %d", c.ProcessPid(), out.State.ExitStatus)
+	}
+	return &out.State, err
+}
+
+func (c *RPCClient) ContinueWithSignal(sig int) (*api.DebuggerState, error) {
+	var out ContinueWithSignalOut
+	err := c.call("ContinueWithSignal", ContinueWithSignalIn{sig}, &out)
+	if out.State.Exited {
+		out.State.Err = fmt.Errorf("Process %d has exited with status %d", c.ProcessPid(), out.State.ExitStatus)
+	}
+	return &out.State, err
+}
+
+func (c *RPCClient) SetSignalPolicy(sig int, stop, pass bool) error {
+	var out SetSignalPolicyOut
+	return c.call("SetSignalPolicy", SetSignalPolicyIn{sig, stop, pass}, &out)
+}
+
+func (c *RPCClient) continueCommand(name string) <-chan *api.DebuggerState {
 	ch := make(chan *api.DebuggerState)
 	go func() {
 		for {
 			out := new(CommandOut)
-			err := c.call("Command", &api.DebuggerCommand{Name: api.Continue}, &out)
+			err := c.call("Command", &api.DebuggerCommand{Name: name}, &out)
 			state := out.State
 			if err != nil {
 				state.Err = err
@@ -103,12 +143,38 @@ func (c *RPCClient) Step() (*api.DebuggerState, error) {
 	return &out.State, err
 }
 
+func (c *RPCClient) StepInto(scope api.EvalScope, funcName string) (*api.DebuggerState, error) {
+	if scope.Frame != 0 {
+		return nil, errors.New("can only step into calls of the topmost frame")
+	}
+	if scope.GoroutineID > 0 {
+		if _, err := c.SwitchGoroutine(scope.GoroutineID); err != nil {
+			return nil, err
+		}
+	}
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepInto, FunctionName: funcName}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) StepOut() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", &api.DebuggerCommand{ Name: api.StepOut}, &out)
 	return &out.State, err
 }
 
+func (c *RPCClient) StepBack() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepBack}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) ReverseContinue() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseContinue}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) StepInstruction() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.StepInstruction}, &out)
@@ -135,6 +201,12 @@ func (c *RPCClient) SwitchGoroutine(goroutineID int) (*api.DebuggerState, error)
 	return &out.State, err
 }
 
+func (c *RPCClient) SwitchFrame(frame int) (*api.DebuggerState, error) {
+	var out SwitchFrameOut
+	err := c.call("SwitchFrame", SwitchFrameIn{frame}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) Halt() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.Halt}, &out)
@@ -159,6 +231,33 @@ func (c *RPCClient) CreateBreakpoint(breakPoint *api.Breakpoint) (*api.Breakpoin
 	return &out.Breakpoint, err
 }
 
+func (c *RPCClient) CreateBreakpoints(breakpoints []*api.Breakpoint, group string) ([]*api.Breakpoint, error) {
+	var out CreateBreakpointsOut
+	in := CreateBreakpointsIn{Breakpoints: make([]api.Breakpoint, len(breakpoints)), Group: group}
+	for i := range breakpoints {
+		in.Breakpoints[i] = *breakpoints[i]
+	}
+	err := c.call("CreateBreakpoints", in, &out)
+	return out.Breakpoints, err
+}
+
+func (c *RPCClient) CreatePackageTracepoints(filter string, max int) ([]*api.Breakpoint, []string, error) {
+	var out CreatePackageTracepointsOut
+	err := c.call("CreatePackageTracepoints", CreatePackageTracepointsIn{filter, max}, &out)
+	return out.Breakpoints, out.Skipped, err
+}
+
+func (c *RPCClient) PendingBreakpointsAhead(gid int) ([]*api.Breakpoint, error) {
+	var out PendingBreakpointsAheadOut
+	err := c.call("PendingBreakpointsAhead", PendingBreakpointsAheadIn{gid}, &out)
+	return out.Breakpoints, err
+}
+
+func (c *RPCClient) DefineBreakpointTemplate(name string, template api.Breakpoint) error {
+	var out DefineBreakpointTemplateOut
+	return c.call("DefineBreakpointTemplate", DefineBreakpointTemplateIn{name, template}, &out)
+}
+
 func (c *RPCClient) ListBreakpoints() ([]*api.Breakpoint, error) {
 	var out ListBreakpointsOut
 	err := c.call("ListBreakpoints", ListBreakpointsIn{}, &out)
@@ -177,6 +276,12 @@ func (c *RPCClient) ClearBreakpointByName(name string) (*api.Breakpoint, error)
 	return out.Breakpoint, err
 }
 
+func (c *RPCClient) ClearBreakpointGroup(group string) ([]*api.Breakpoint, error) {
+	var out ClearBreakpointGroupOut
+	err := c.call("ClearBreakpointGroup", ClearBreakpointGroupIn{group}, &out)
+	return out.Breakpoints, err
+}
+
 func (c *RPCClient) AmendBreakpoint(bp *api.Breakpoint) error {
 	out := new(AmendBreakpointOut)
 	err := c.call("AmendBreakpoint", AmendBreakpointIn{*bp}, out)
@@ -202,10 +307,54 @@ func (c *RPCClient) GetThread(id int) (*api.Thread, error) {
 
 func (c *RPCClient) EvalVariable(scope api.EvalScope, expr string, cfg api.LoadConfig) (*api.Variable, error) {
 	var out EvalOut
-	err := c.call("Eval", EvalIn{scope, expr, &cfg}, &out)
+	err := c.call("Eval", EvalIn{scope, expr, &cfg, 0}, &out)
 	return out.Variable, err
 }
 
+func (c *RPCClient) EvalVariableTimeout(scope api.EvalScope, expr string, cfg api.LoadConfig, timeout time.Duration) (*api.Variable, error) {
+	var out EvalOut
+	err := c.call("Eval", EvalIn{scope, expr, &cfg, timeout}, &out)
+	return out.Variable, err
+}
+
+func (c *RPCClient) EvalAcrossGoroutines(expr string, cfg api.LoadConfig) (map[int]*api.Variable, error) {
+	var out EvalAcrossGoroutinesOut
+	err := c.call("EvalAcrossGoroutines", EvalAcrossGoroutinesIn{expr, &cfg}, &out)
+	return out.Variables, err
+}
+
+func (c *RPCClient) EvalPair(scopeA api.EvalScope, exprA string, scopeB api.EvalScope, exprB string, cfg api.LoadConfig) (a, b *api.Variable, errA, errB error) {
+	var out EvalPairOut
+	if err := c.call("EvalPair", EvalPairIn{scopeA, exprA, scopeB, exprB, &cfg}, &out); err != nil {
+		return nil, nil, err, err
+	}
+	if out.ErrA != "" {
+		errA = errors.New(out.ErrA)
+	}
+	if out.ErrB != "" {
+		errB = errors.New(out.ErrB)
+	}
+	return out.A, out.B, errA, errB
+}
+
+func (c *RPCClient) VariableAddress(scope api.EvalScope, expr string) (uint64, int64, error) {
+	var out VariableAddressOut
+	err := c.call("VariableAddress", VariableAddressIn{scope, expr}, &out)
+	return out.Addr, out.Size, err
+}
+
+func (c *RPCClient) UnwrapError(scope api.EvalScope, expr string, cfg api.LoadConfig) ([]api.Variable, error) {
+	var out UnwrapErrorOut
+	err := c.call("UnwrapError", UnwrapErrorIn{scope, expr, &cfg}, &out)
+	return out.Errors, err
+}
+
+func (c *RPCClient) SyncObjectInfo(scope api.EvalScope, expr string) (*api.SyncObject, error) {
+	var out SyncObjectInfoOut
+	err := c.call("SyncObjectInfo", SyncObjectInfoIn{scope, expr}, &out)
+	return out.Object, err
+}
+
 func (c *RPCClient) SetVariable(scope api.EvalScope, symbol, value string) error {
 	out := new(SetOut)
 	return c.call("Set", SetIn{scope, symbol, value}, out)
@@ -217,6 +366,12 @@ func (c *RPCClient) ListSources(filter string) ([]string, error) {
 	return sources.Sources, err
 }
 
+func (c *RPCClient) ListSource(file string, line, context int) ([]api.SourceLine, error) {
+	var out ListSourceOut
+	err := c.call("ListSource", ListSourceIn{file, line, context}, &out)
+	return out.Lines, err
+}
+
 func (c *RPCClient) ListFunctions(filter string) ([]string, error) {
 	funcs := new(ListFunctionsOut)
 	err := c.call("ListFunctions", ListFunctionsIn{filter}, funcs)
@@ -229,15 +384,27 @@ func (c *RPCClient) ListTypes(filter string) ([]string, error) {
 	return types.Types, err
 }
 
+func (c *RPCClient) ListMethods(typeName string) ([]api.Method, error) {
+	var out ListMethodsOut
+	err := c.call("ListMethods", ListMethodsIn{typeName}, &out)
+	return out.Methods, err
+}
+
 func (c *RPCClient) ListPackageVariables(filter string, cfg api.LoadConfig) ([]api.Variable, error) {
 	var out ListPackageVarsOut
 	err := c.call("ListPackageVars", ListPackageVarsIn{filter, cfg}, &out)
 	return out.Variables, err
 }
 
-func (c *RPCClient) ListLocalVariables(scope api.EvalScope, cfg api.LoadConfig) ([]api.Variable, error) {
+func (c *RPCClient) ListLocalVariables(scope api.EvalScope, filter string, cfg api.LoadConfig) ([]api.Variable, error) {
 	var out ListLocalVarsOut
-	err := c.call("ListLocalVars", ListLocalVarsIn{scope, cfg}, &out)
+	err := c.call("ListLocalVars", ListLocalVarsIn{scope, cfg, false, filter}, &out)
+	return out.Variables, err
+}
+
+func (c *RPCClient) ListLocalVariablesAllBlocks(scope api.EvalScope, cfg api.LoadConfig) ([]api.Variable, error) {
+	var out ListLocalVarsOut
+	err := c.call("ListLocalVars", ListLocalVarsIn{scope, cfg, true, ""}, &out)
 	return out.Variables, err
 }
 
@@ -247,21 +414,123 @@ func (c *RPCClient) ListRegisters() (string, error) {
 	return out.Registers, err
 }
 
-func (c *RPCClient) ListFunctionArgs(scope api.EvalScope, cfg api.LoadConfig) ([]api.Variable, error) {
+func (c *RPCClient) ListRegisterValues() (api.Registers, error) {
+	out := new(ListRegistersOut)
+	err := c.call("ListRegisters", ListRegistersIn{}, out)
+	return out.Regs, err
+}
+
+func (c *RPCClient) ListThreadRegisters() (map[int]api.Registers, error) {
+	out := new(ListThreadRegistersOut)
+	err := c.call("ListThreadRegisters", ListThreadRegistersIn{}, out)
+	return out.Regs, err
+}
+
+func (c *RPCClient) SetRegister(threadID int, name, value string) error {
+	out := new(SetRegisterOut)
+	return c.call("SetRegister", SetRegisterIn{threadID, name, value}, out)
+}
+
+func (c *RPCClient) ListFunctionArgs(scope api.EvalScope, filter string, cfg api.LoadConfig) ([]api.Variable, error) {
 	var out ListFunctionArgsOut
-	err := c.call("ListFunctionArgs", ListFunctionArgsIn{scope, cfg}, &out)
+	err := c.call("ListFunctionArgs", ListFunctionArgsIn{scope, cfg, filter}, &out)
 	return out.Args, err
 }
 
-func (c *RPCClient) ListGoroutines() ([]*api.Goroutine, error) {
+func (c *RPCClient) CallFrameValues(scope api.EvalScope, cfg api.LoadConfig) (*api.Variable, []api.Variable, error) {
+	var out CallFrameValuesOut
+	err := c.call("CallFrameValues", CallFrameValuesIn{scope, cfg}, &out)
+	return out.Receiver, out.Args, err
+}
+
+func (c *RPCClient) ListGoroutines(start, count int) ([]*api.Goroutine, int, error) {
+	return c.ListGoroutinesWithFilter(api.GoroutineFilter{}, start, count)
+}
+
+func (c *RPCClient) ListGoroutinesWithFilter(filter api.GoroutineFilter, start, count int) ([]*api.Goroutine, int, error) {
 	var out ListGoroutinesOut
-	err := c.call("ListGoroutines", ListGoroutinesIn{}, &out)
-	return out.Goroutines, err
+	err := c.call("ListGoroutines", ListGoroutinesIn{start, count, filter}, &out)
+	return out.Goroutines, out.Total, err
+}
+
+func (c *RPCClient) GoroutineByAddress(addr uint64) (*api.Goroutine, error) {
+	var out GoroutineByAddressOut
+	err := c.call("GoroutineByAddress", GoroutineByAddressIn{addr}, &out)
+	return out.Goroutine, err
+}
+
+func (c *RPCClient) TracepointStats(id int) (*api.TracepointStats, error) {
+	var out TracepointStatsOut
+	err := c.call("TracepointStats", TracepointStatsIn{id}, &out)
+	return out.Stats, err
+}
+
+func (c *RPCClient) DirectRootReferences(addr uint64) ([]api.Reference, error) {
+	var out DirectRootReferencesOut
+	err := c.call("DirectRootReferences", DirectRootReferencesIn{addr}, &out)
+	return out.References, err
+}
+
+func (c *RPCClient) BlockingChain(gid int) ([]api.Goroutine, error) {
+	var out BlockingChainOut
+	err := c.call("BlockingChain", BlockingChainIn{gid}, &out)
+	return out.Chain, err
+}
+
+func (c *RPCClient) GoroutineStackInfo(gid int) (lo, hi, used uint64, err error) {
+	var out GoroutineStackInfoOut
+	err = c.call("GoroutineStackInfo", GoroutineStackInfoIn{gid}, &out)
+	return out.Lo, out.Hi, out.Used, err
+}
+
+func (c *RPCClient) ContinueRecordingLines(maxInstructions int) (*api.DebuggerState, []api.Location, error) {
+	var out ContinueRecordingLinesOut
+	err := c.call("ContinueRecordingLines", ContinueRecordingLinesIn{maxInstructions}, &out)
+	return &out.State, out.Lines, err
+}
+
+func (c *RPCClient) ExamineMemory(addr uint64, count, wordSize int, asPointers bool) ([]api.MemWord, error) {
+	var out ExamineMemoryOut
+	err := c.call("ExamineMemory", ExamineMemoryIn{addr, count, wordSize, asPointers}, &out)
+	return out.Words, err
+}
+
+func (c *RPCClient) ReadMemory(addr uint64, length int) ([]byte, error) {
+	var out ReadMemoryOut
+	err := c.call("ReadMemory", ReadMemoryIn{addr, length}, &out)
+	if err == nil && out.PartialError != "" {
+		err = errors.New(out.PartialError)
+	}
+	return out.Mem, err
+}
+
+func (c *RPCClient) WriteMemory(addr uint64, data []byte) (int, error) {
+	var out WriteMemoryOut
+	err := c.call("WriteMemory", WriteMemoryIn{addr, data}, &out)
+	return out.Written, err
+}
+
+func (c *RPCClient) RuntimeConfig() (*api.RuntimeConfig, error) {
+	var out RuntimeConfigOut
+	err := c.call("RuntimeConfig", RuntimeConfigIn{}, &out)
+	return out.Config, err
+}
+
+func (c *RPCClient) ModuleInfo() (*api.ModuleInfo, error) {
+	var out ModuleInfoOut
+	err := c.call("ModuleInfo", ModuleInfoIn{}, &out)
+	return out.Info, err
 }
 
 func (c *RPCClient) Stacktrace(goroutineId, depth int, cfg *api.LoadConfig) ([]api.Stackframe, error) {
 	var out StacktraceOut
-	err := c.call("Stacktrace", StacktraceIn{goroutineId, depth, false, cfg}, &out)
+	err := c.call("Stacktrace", StacktraceIn{goroutineId, depth, false, cfg, false}, &out)
+	return out.Locations, err
+}
+
+func (c *RPCClient) StacktraceDefers(goroutineID, depth int, cfg *api.LoadConfig) ([]api.Stackframe, error) {
+	var out StacktraceOut
+	err := c.call("Stacktrace", StacktraceIn{goroutineID, depth, false, cfg, true}, &out)
 	return out.Locations, err
 }
 
@@ -273,21 +542,43 @@ func (c *RPCClient) AttachedToExistingProcess() bool {
 
 func (c *RPCClient) FindLocation(scope api.EvalScope, loc string) ([]api.Location, error) {
 	var out FindLocationOut
-	err := c.call("FindLocation", FindLocationIn{scope, loc}, &out)
+	err := c.call("FindLocation", FindLocationIn{scope, loc, false}, &out)
+	return out.Locations, err
+}
+
+func (c *RPCClient) FindLocationVerbose(scope api.EvalScope, loc string) ([]api.Location, error) {
+	var out FindLocationOut
+	err := c.call("FindLocation", FindLocationIn{scope, loc, true}, &out)
 	return out.Locations, err
 }
 
 // Disassemble code between startPC and endPC
 func (c *RPCClient) DisassembleRange(scope api.EvalScope, startPC, endPC uint64, flavour api.AssemblyFlavour) (api.AsmInstructions, error) {
 	var out DisassembleOut
-	err := c.call("Disassemble", DisassembleIn{scope, startPC, endPC, flavour}, &out)
+	err := c.call("Disassemble", DisassembleIn{scope, startPC, endPC, flavour, false, ""}, &out)
 	return out.Disassemble, err
 }
 
 // Disassemble function containing pc
 func (c *RPCClient) DisassemblePC(scope api.EvalScope, pc uint64, flavour api.AssemblyFlavour) (api.AsmInstructions, error) {
 	var out DisassembleOut
-	err := c.call("Disassemble", DisassembleIn{scope, pc, 0, flavour}, &out)
+	err := c.call("Disassemble", DisassembleIn{scope, pc, 0, flavour, false, ""}, &out)
+	return out.Disassemble, err
+}
+
+// DisassembleBranches returns the call, jump and return instructions
+// between startPC and endPC or, if endPC is 0, of the function containing
+// startPC.
+func (c *RPCClient) DisassembleBranches(scope api.EvalScope, startPC, endPC uint64, flavour api.AssemblyFlavour) (api.AsmInstructions, error) {
+	var out DisassembleOut
+	err := c.call("Disassemble", DisassembleIn{scope, startPC, endPC, flavour, true, ""}, &out)
+	return out.Disassemble, err
+}
+
+// DisassembleFunction disassembles the function called funcName
+func (c *RPCClient) DisassembleFunction(scope api.EvalScope, funcName string, flavour api.AssemblyFlavour) (api.AsmInstructions, error) {
+	var out DisassembleOut
+	err := c.call("Disassemble", DisassembleIn{scope, 0, 0, flavour, false, funcName}, &out)
 	return out.Disassemble, err
 }
 
@@ -298,3 +589,67 @@ func (c *RPCClient) url(path string) string {
 func (c *RPCClient) call(method string, args, reply interface{}) error {
 	return c.client.Call("RPCServer."+method, args, reply)
 }
+
+func (c *RPCClient) ExportState(path string) error {
+	var out ExportStateOut
+	if err := c.call("ExportState", ExportStateIn{}, &out); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(out.Snapshot, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf, 0644)
+}
+
+func (c *RPCClient) DiffStacks(goroutineA, goroutineB int) (int, []api.Stackframe, []api.Stackframe, error) {
+	var out DiffStacksOut
+	err := c.call("DiffStacks", DiffStacksIn{goroutineA, goroutineB}, &out)
+	return out.Index, out.A, out.B, err
+}
+
+func (c *RPCClient) BreakOnChannelOp(scope api.EvalScope, expr string, op string) ([]*api.Breakpoint, error) {
+	var out BreakOnChannelOpOut
+	err := c.call("BreakOnChannelOp", BreakOnChannelOpIn{scope, expr, op}, &out)
+	return out.Breakpoints, err
+}
+
+func (c *RPCClient) CreateWatchpoint(scope api.EvalScope, expr string, kind api.WatchKind) (*api.Breakpoint, error) {
+	var out CreateWatchpointOut
+	err := c.call("CreateWatchpoint", CreateWatchpointIn{scope, expr, kind}, &out)
+	return out.Breakpoint, err
+}
+
+func (c *RPCClient) GCPercent() (int, error) {
+	var out GCPercentOut
+	err := c.call("GCPercent", GCPercentIn{}, &out)
+	return out.Percent, err
+}
+
+func (c *RPCClient) ForceGC() error {
+	var out ForceGCOut
+	return c.call("ForceGC", ForceGCIn{}, &out)
+}
+
+func (c *RPCClient) SetGCPercent(pct int) (int, error) {
+	var out SetGCPercentOut
+	err := c.call("SetGCPercent", SetGCPercentIn{pct}, &out)
+	return out.Previous, err
+}
+
+func (c *RPCClient) ActiveFunctions() (map[string]int, error) {
+	var out ActiveFunctionsOut
+	err := c.call("ActiveFunctions", ActiveFunctionsIn{}, &out)
+	return out.Functions, err
+}
+
+func (c *RPCClient) GetLoadConfig() (api.LoadConfig, error) {
+	var out GetLoadConfigOut
+	err := c.call("GetLoadConfig", GetLoadConfigIn{}, &out)
+	return out.Cfg, err
+}
+
+func (c *RPCClient) SetLoadConfig(cfg api.LoadConfig) error {
+	var out SetLoadConfigOut
+	return c.call("SetLoadConfig", SetLoadConfigIn{cfg}, &out)
+}
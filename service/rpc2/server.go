@@ -3,6 +3,8 @@ package rpc2
 import (
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/derekparker/delve/service"
 	"github.com/derekparker/delve/service/api"
@@ -14,10 +16,27 @@ type RPCServer struct {
 	config *service.Config
 	// debugger is a debugger service.
 	debugger *debugger.Debugger
+
+	// loadConfig is used by the calls that receive a nil or zero
+	// LoadConfig, see SetLoadConfig.
+	loadConfig   api.LoadConfig
+	loadConfigMu sync.Mutex
 }
 
 func NewServer(config *service.Config, debugger *debugger.Debugger) *RPCServer {
-	return &RPCServer{config, debugger}
+	return &RPCServer{config: config, debugger: debugger, loadConfig: api.LoadConfig{true, 1, 64, 64, -1, ""}}
+}
+
+// defaultLoadConfig returns cfg, or the default load configuration if cfg
+// is nil or the zero value.
+func (s *RPCServer) defaultLoadConfig(cfg *api.LoadConfig) *api.LoadConfig {
+	if cfg != nil && *cfg != (api.LoadConfig{}) {
+		return cfg
+	}
+	s.loadConfigMu.Lock()
+	defer s.loadConfigMu.Unlock()
+	r := s.loadConfig
+	return &r
 }
 
 type ProcessPidIn struct {
@@ -59,6 +78,27 @@ func (s *RPCServer) Restart(arg RestartIn, out *RestartOut) error {
 	return s.debugger.Restart()
 }
 
+type RestartWithIn struct {
+	// Args are the arguments of the new process, following the
+	// executable. The previous arguments are kept if Args is nil.
+	Args []string
+	// Env is the environment of the new process. The previous environment
+	// is kept if Env is nil.
+	Env []string
+}
+
+type RestartWithOut struct {
+}
+
+// RestartWith restarts the program with new arguments or environment,
+// preserving breakpoints like Restart.
+func (s *RPCServer) RestartWith(arg RestartWithIn, out *RestartWithOut) error {
+	if s.config.AttachPid != 0 {
+		return errors.New("cannot restart process Delve did not create")
+	}
+	return s.debugger.RestartWith(arg.Args, arg.Env)
+}
+
 type StateIn struct {
 }
 
@@ -125,6 +165,8 @@ type StacktraceIn struct {
 	Depth int
 	Full  bool
 	Cfg   *api.LoadConfig
+	// Defers requests the deferred calls of each frame.
+	Defers bool
 }
 
 type StacktraceOut struct {
@@ -135,12 +177,15 @@ type StacktraceOut struct {
 //
 // If Full is set it will also the variable of all local variables
 // and function arguments of all stack frames.
+//
+// If Defers is set the deferred calls registered by each frame are
+// returned too, their arguments are loaded with Cfg.
 func (s *RPCServer) Stacktrace(arg StacktraceIn, out *StacktraceOut) error {
 	cfg := arg.Cfg
-	if cfg == nil && arg.Full {
-		cfg = &api.LoadConfig{true, 1, 64, 64, -1}
+	if arg.Full {
+		cfg = s.defaultLoadConfig(cfg)
 	}
-	locs, err := s.debugger.Stacktrace(arg.Id, arg.Depth, api.LoadConfigToProc(cfg))
+	locs, err := s.debugger.Stacktrace(arg.Id, arg.Depth, arg.Defers, api.LoadConfigToProc(cfg))
 	if err != nil {
 		return err
 	}
@@ -148,6 +193,91 @@ func (s *RPCServer) Stacktrace(arg StacktraceIn, out *StacktraceOut) error {
 	return nil
 }
 
+type ContinueToIn struct {
+	Loc string
+}
+
+type ContinueToOut struct {
+	State api.DebuggerState
+}
+
+// ContinueTo resumes the process until it reaches arg.Loc, a location
+// specification resolved as FindLocation does, or until it stops for any
+// other reason. The breakpoints used to stop at arg.Loc are not listed by
+// ListBreakpoints and are removed when the process stops.
+func (s *RPCServer) ContinueTo(arg ContinueToIn, out *ContinueToOut) error {
+	st, err := s.debugger.ContinueTo(arg.Loc)
+	if err != nil {
+		return err
+	}
+	out.State = *st
+	return nil
+}
+
+type ContinueWithSignalIn struct {
+	Signal int
+}
+
+type ContinueWithSignalOut struct {
+	State api.DebuggerState
+}
+
+// ContinueWithSignal resumes the process like Continue, delivering
+// arg.Signal to the current thread instead of the signal it was stopped
+// by, reported in DebuggerState.Signal. A Signal of 0 discards the signal.
+// Only supported on linux.
+func (s *RPCServer) ContinueWithSignal(arg ContinueWithSignalIn, out *ContinueWithSignalOut) error {
+	st, err := s.debugger.ContinueWithSignal(arg.Signal)
+	if err != nil {
+		return err
+	}
+	out.State = *st
+	return nil
+}
+
+type SetSignalPolicyIn struct {
+	Signal int
+	// Stop stops the process when it receives Signal.
+	Stop bool
+	// Pass delivers Signal to the process, if not set it is discarded.
+	Pass bool
+}
+
+type SetSignalPolicyOut struct {
+}
+
+// SetSignalPolicy sets how arg.Signal is handled when the process receives
+// it. By default signals are passed to the process without stopping it,
+// which is what the Go runtime expects for SIGURG and SIGSEGV.
+// Only supported on linux.
+func (s *RPCServer) SetSignalPolicy(arg SetSignalPolicyIn, out *SetSignalPolicyOut) error {
+	return s.debugger.SetSignalPolicy(arg.Signal, arg.Stop, arg.Pass)
+}
+
+type SwitchFrameIn struct {
+	Frame int
+}
+
+type SwitchFrameOut struct {
+	State api.DebuggerState
+}
+
+// SwitchFrame selects arg.Frame of the selected goroutine, the frames of
+// the scopes with GoroutineID -1 are relative to it until the process is
+// resumed or another goroutine is selected.
+// An error is returned if the goroutine does not have the frame.
+func (s *RPCServer) SwitchFrame(arg SwitchFrameIn, out *SwitchFrameOut) error {
+	if err := s.debugger.SwitchFrame(arg.Frame); err != nil {
+		return err
+	}
+	st, err := s.debugger.State()
+	if err != nil {
+		return err
+	}
+	out.State = *st
+	return nil
+}
+
 type ListBreakpointsIn struct {
 }
 
@@ -179,6 +309,9 @@ type CreateBreakpointOut struct {
 // location. Note that setting a breakpoint on a function's entry point
 // (line == 0) can have surprising consequences, it is advisable to
 // use line = -1 instead which will skip the prologue.
+// If the function only exists in inlined form a breakpoint will be
+// created on each inlined call site, the returned breakpoint will list
+// all of them in its InlinedCallSites field.
 //
 // - Otherwise the value specified by arg.Breakpoint.Addr will be used.
 func (s *RPCServer) CreateBreakpoint(arg CreateBreakpointIn, out *CreateBreakpointOut) error {
@@ -190,6 +323,99 @@ func (s *RPCServer) CreateBreakpoint(arg CreateBreakpointIn, out *CreateBreakpoi
 	return nil
 }
 
+type CreateBreakpointsIn struct {
+	Breakpoints []api.Breakpoint
+	Group       string
+}
+
+type CreateBreakpointsOut struct {
+	Breakpoints []*api.Breakpoint
+}
+
+// CreateBreakpoints creates all the breakpoints in arg.Breakpoints, as
+// CreateBreakpoint would, and sets their Group to arg.Group.
+// If any of them can not be created the ones already created are cleared
+// and an error is returned.
+func (s *RPCServer) CreateBreakpoints(arg CreateBreakpointsIn, out *CreateBreakpointsOut) error {
+	requested := make([]*api.Breakpoint, len(arg.Breakpoints))
+	for i := range arg.Breakpoints {
+		requested[i] = &arg.Breakpoints[i]
+	}
+	var err error
+	out.Breakpoints, err = s.debugger.CreateBreakpoints(requested, arg.Group)
+	return err
+}
+
+type CreatePackageTracepointsIn struct {
+	// Filter is a regular expression matched against the names of the
+	// functions, as ListFunctions does.
+	Filter string
+	// Max is the maximum number of functions that can match Filter, 0
+	// means no limit.
+	Max int
+}
+
+type CreatePackageTracepointsOut struct {
+	Breakpoints []*api.Breakpoint
+	// Skipped are the functions matching Filter whose entry point could
+	// not be found, no tracepoint was created for them.
+	Skipped []string
+}
+
+// CreatePackageTracepoints creates a tracepoint at the entry of every
+// function matching arg.Filter, without stacktrace or variables, so that
+// continuing reports each function call. The tracepoints have Group set to
+// "trace " followed by arg.Filter and can be cleared with
+// ClearBreakpointGroup.
+// If more than arg.Max functions match no tracepoint is created and an
+// error is returned. Functions whose entry point can not be found are
+// skipped and listed in out.Skipped.
+func (s *RPCServer) CreatePackageTracepoints(arg CreatePackageTracepointsIn, out *CreatePackageTracepointsOut) error {
+	var err error
+	out.Breakpoints, out.Skipped, err = s.debugger.CreatePackageTracepoints(arg.Filter, arg.Max)
+	return err
+}
+
+type PendingBreakpointsAheadIn struct {
+	Id int
+}
+
+type PendingBreakpointsAheadOut struct {
+	Breakpoints []*api.Breakpoint
+}
+
+// PendingBreakpointsAhead returns the breakpoints goroutine arg.Id is
+// likely to reach next.
+//
+// This is a heuristic based on the disassembly of the functions on the
+// goroutine's stack and of the functions they call directly: branches and
+// indirect calls are not followed.
+func (s *RPCServer) PendingBreakpointsAhead(arg PendingBreakpointsAheadIn, out *PendingBreakpointsAheadOut) error {
+	bps, err := s.debugger.PendingBreakpointsAhead(arg.Id)
+	if err != nil {
+		return err
+	}
+	out.Breakpoints = bps
+	return nil
+}
+
+type DefineBreakpointTemplateIn struct {
+	Name     string
+	Template api.Breakpoint
+}
+
+type DefineBreakpointTemplateOut struct {
+}
+
+// DefineBreakpointTemplate defines a breakpoint template called arg.Name.
+// A breakpoint created with its Template field set to arg.Name will copy
+// the condition, tracepoint flag, information to retrieve, ignore count
+// and aggregate expression from arg.Template, unless they are set on the
+// breakpoint itself.
+func (s *RPCServer) DefineBreakpointTemplate(arg DefineBreakpointTemplateIn, out *DefineBreakpointTemplateOut) error {
+	return s.debugger.DefineBreakpointTemplate(arg.Name, &arg.Template)
+}
+
 type ClearBreakpointIn struct {
 	Id   int
 	Name string
@@ -222,6 +448,22 @@ func (s *RPCServer) ClearBreakpoint(arg ClearBreakpointIn, out *ClearBreakpointO
 	return nil
 }
 
+type ClearBreakpointGroupIn struct {
+	Group string
+}
+
+type ClearBreakpointGroupOut struct {
+	Breakpoints []*api.Breakpoint
+}
+
+// ClearBreakpointGroup deletes all the breakpoints created by
+// CreateBreakpoints with Group arg.Group.
+func (s *RPCServer) ClearBreakpointGroup(arg ClearBreakpointGroupIn, out *ClearBreakpointGroupOut) error {
+	var err error
+	out.Breakpoints, err = s.debugger.ClearBreakpointGroup(arg.Group)
+	return err
+}
+
 type AmendBreakpointIn struct {
 	Breakpoint api.Breakpoint
 }
@@ -303,22 +545,39 @@ func (s *RPCServer) ListPackageVars(arg ListPackageVarsIn, out *ListPackageVarsO
 		return fmt.Errorf("no current thread")
 	}
 
-	vars, err := s.debugger.PackageVariables(current.ID, arg.Filter, *api.LoadConfigToProc(&arg.Cfg))
+	cfg := s.defaultLoadConfig(&arg.Cfg)
+	vars, err := s.debugger.PackageVariables(current.ID, arg.Filter, *api.LoadConfigToProc(cfg))
 	if err != nil {
 		return err
 	}
+	if err := formatVariables(vars, cfg.Format); err != nil {
+		return err
+	}
 	out.Variables = vars
 	return nil
 }
 
+// formatVariables applies format to all vars, see api.LoadConfig.Format.
+func formatVariables(vars []api.Variable, format string) error {
+	for i := range vars {
+		if err := vars[i].ApplyFormat(format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type ListRegistersIn struct {
 }
 
 type ListRegistersOut struct {
 	Registers string
+	Regs      api.Registers
 }
 
 // ListRegisters lists registers and their values.
+// Registers that changed since the last time the process was resumed
+// have the Changed field set.
 func (s *RPCServer) ListRegisters(arg ListRegistersIn, out *ListRegistersOut) error {
 	state, err := s.debugger.State()
 	if err != nil {
@@ -329,13 +588,59 @@ func (s *RPCServer) ListRegisters(arg ListRegistersIn, out *ListRegistersOut) er
 	if err != nil {
 		return err
 	}
-	out.Registers = regs
+	out.Registers = regs.String()
+	out.Regs = regs
 	return nil
 }
 
+type ListThreadRegistersIn struct {
+}
+
+type ListThreadRegistersOut struct {
+	// Regs are the registers of each thread, by thread ID.
+	Regs map[int]api.Registers
+}
+
+// ListThreadRegisters lists the registers of all threads, like
+// ListRegisters does for the current thread.
+func (s *RPCServer) ListThreadRegisters(arg ListThreadRegistersIn, out *ListThreadRegistersOut) error {
+	regs, err := s.debugger.ThreadRegisters()
+	if err != nil {
+		return err
+	}
+	out.Regs = regs
+	return nil
+}
+
+type SetRegisterIn struct {
+	// ThreadID is the thread whose register is changed, -1 for the
+	// current thread.
+	ThreadID int
+	Name     string
+	Value    string
+}
+
+type SetRegisterOut struct {
+}
+
+// SetRegister sets the register Name of the thread ThreadID to Value.
+//
+// Value is parsed as a decimal, hexadecimal (0x prefix) or octal (0
+// prefix) number. Unknown and read-only registers, like the segment
+// registers, return an error.
+func (s *RPCServer) SetRegister(arg SetRegisterIn, out *SetRegisterOut) error {
+	return s.debugger.SetRegister(arg.ThreadID, arg.Name, arg.Value)
+}
+
 type ListLocalVarsIn struct {
 	Scope api.EvalScope
 	Cfg   api.LoadConfig
+	// AllBlocks requests the variables declared in nested lexical blocks
+	// too, including the ones they shadow.
+	AllBlocks bool
+	// Filter, if not empty, is a regular expression, only the variables
+	// whose name matches it are loaded and returned.
+	Filter string
 }
 
 type ListLocalVarsOut struct {
@@ -343,11 +648,30 @@ type ListLocalVarsOut struct {
 }
 
 // ListLocalVars lists all local variables in scope.
+//
+// If arg.Filter is set only the variables whose name matches it are
+// returned, the values of the others are not read.
+//
+// If arg.AllBlocks is set the variables of the lexical blocks containing
+// the current line are returned as well, each with its DeclLine and
+// variables hidden by an inner declaration have Shadowed set.
 func (s *RPCServer) ListLocalVars(arg ListLocalVarsIn, out *ListLocalVarsOut) error {
-	vars, err := s.debugger.LocalVariables(arg.Scope, *api.LoadConfigToProc(&arg.Cfg))
+	var (
+		vars []api.Variable
+		err  error
+	)
+	cfg := s.defaultLoadConfig(&arg.Cfg)
+	if arg.AllBlocks {
+		vars, err = s.debugger.LocalVariablesAllBlocks(arg.Scope, arg.Filter, *api.LoadConfigToProc(cfg))
+	} else {
+		vars, err = s.debugger.LocalVariables(arg.Scope, arg.Filter, *api.LoadConfigToProc(cfg))
+	}
 	if err != nil {
 		return err
 	}
+	if err := formatVariables(vars, cfg.Format); err != nil {
+		return err
+	}
 	out.Variables = vars
 	return nil
 }
@@ -355,6 +679,9 @@ func (s *RPCServer) ListLocalVars(arg ListLocalVarsIn, out *ListLocalVarsOut) er
 type ListFunctionArgsIn struct {
 	Scope api.EvalScope
 	Cfg   api.LoadConfig
+	// Filter, if not empty, is a regular expression, only the arguments
+	// whose name matches it are loaded and returned.
+	Filter string
 }
 
 type ListFunctionArgsOut struct {
@@ -363,18 +690,50 @@ type ListFunctionArgsOut struct {
 
 // ListFunctionArgs lists all arguments to the current function
 func (s *RPCServer) ListFunctionArgs(arg ListFunctionArgsIn, out *ListFunctionArgsOut) error {
-	vars, err := s.debugger.FunctionArguments(arg.Scope, *api.LoadConfigToProc(&arg.Cfg))
+	cfg := s.defaultLoadConfig(&arg.Cfg)
+	vars, err := s.debugger.FunctionArguments(arg.Scope, arg.Filter, *api.LoadConfigToProc(cfg))
 	if err != nil {
 		return err
 	}
+	if err := formatVariables(vars, cfg.Format); err != nil {
+		return err
+	}
 	out.Args = vars
 	return nil
 }
 
+type CallFrameValuesIn struct {
+	Scope api.EvalScope
+	Cfg   api.LoadConfig
+}
+
+type CallFrameValuesOut struct {
+	// Receiver is the receiver of the function, nil if it is not a method.
+	Receiver *api.Variable
+	// Args are the arguments of the function, excluding the receiver.
+	Args []api.Variable
+}
+
+// CallFrameValues returns the receiver of the current function, if it is
+// a method, separately from its other arguments.
+func (s *RPCServer) CallFrameValues(arg CallFrameValuesIn, out *CallFrameValuesOut) error {
+	cfg := s.defaultLoadConfig(&arg.Cfg)
+	recv, args, err := s.debugger.FunctionReceiver(arg.Scope, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Receiver = recv
+	out.Args = args
+	return nil
+}
+
 type EvalIn struct {
 	Scope api.EvalScope
 	Expr  string
 	Cfg   *api.LoadConfig
+	// Timeout, if not zero, is the maximum time the evaluation can take
+	// before being aborted.
+	Timeout time.Duration
 }
 
 type EvalOut struct {
@@ -385,19 +744,161 @@ type EvalOut struct {
 //
 // See https://github.com/derekparker/delve/wiki/Expressions for
 // a description of acceptable values of arg.Expr.
+//
+// If arg.Timeout is not zero and the evaluation takes longer than
+// arg.Timeout it is aborted and an error is returned.
 func (s *RPCServer) Eval(arg EvalIn, out *EvalOut) error {
-	cfg := arg.Cfg
-	if cfg == nil {
-		cfg = &api.LoadConfig{true, 1, 64, 64, -1}
-	}
-	v, err := s.debugger.EvalVariableInScope(arg.Scope, arg.Expr, *api.LoadConfigToProc(cfg))
+	cfg := s.defaultLoadConfig(arg.Cfg)
+	v, err := s.debugger.EvalVariableInScope(arg.Scope, arg.Expr, *api.LoadConfigToProc(cfg), arg.Timeout)
 	if err != nil {
 		return err
 	}
+	if err := v.ApplyFormat(cfg.Format); err != nil {
+		return err
+	}
 	out.Variable = v
 	return nil
 }
 
+type EvalAcrossGoroutinesIn struct {
+	Expr string
+	Cfg  *api.LoadConfig
+}
+
+type EvalAcrossGoroutinesOut struct {
+	// Variables are the values of Expr, by goroutine ID. The Unreadable
+	// field of a value is set if Expr could not be evaluated in its
+	// goroutine.
+	Variables map[int]*api.Variable
+}
+
+// EvalAcrossGoroutines evaluates arg.Expr in the topmost frame of every
+// goroutine. The goroutines where arg.Expr uses names that are not visible
+// in that frame, or whose stack can not be read, are omitted.
+func (s *RPCServer) EvalAcrossGoroutines(arg EvalAcrossGoroutinesIn, out *EvalAcrossGoroutinesOut) error {
+	cfg := s.defaultLoadConfig(arg.Cfg)
+	vars, errs, err := s.debugger.EvalAcrossGoroutines(arg.Expr, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	for gid, v := range vars {
+		if err := v.ApplyFormat(cfg.Format); err != nil {
+			errs[gid] = err
+		}
+	}
+	for gid, err := range errs {
+		vars[gid] = &api.Variable{Name: arg.Expr, Unreadable: err.Error()}
+	}
+	out.Variables = vars
+	return nil
+}
+
+type EvalPairIn struct {
+	ScopeA api.EvalScope
+	ExprA  string
+	ScopeB api.EvalScope
+	ExprB  string
+	Cfg    *api.LoadConfig
+}
+
+type EvalPairOut struct {
+	A, B *api.Variable
+	// ErrA and ErrB are the errors returned evaluating ExprA and ExprB.
+	ErrA, ErrB string
+}
+
+// EvalPair evaluates arg.ExprA in arg.ScopeA and arg.ExprB in arg.ScopeB
+// and returns both values, read from the same stop of the target process.
+// An error evaluating one of the expressions is returned in out.ErrA or
+// out.ErrB and does not affect the evaluation of the other one.
+func (s *RPCServer) EvalPair(arg EvalPairIn, out *EvalPairOut) error {
+	cfg := s.defaultLoadConfig(arg.Cfg)
+	a, b, errA, errB := s.debugger.EvalPair(arg.ScopeA, arg.ExprA, arg.ScopeB, arg.ExprB, *api.LoadConfigToProc(cfg))
+	if errA == nil {
+		errA = a.ApplyFormat(cfg.Format)
+	}
+	if errB == nil {
+		errB = b.ApplyFormat(cfg.Format)
+	}
+	out.A, out.B = a, b
+	if errA != nil {
+		out.ErrA = errA.Error()
+	}
+	if errB != nil {
+		out.ErrB = errB.Error()
+	}
+	return nil
+}
+
+type VariableAddressIn struct {
+	Scope api.EvalScope
+	Expr  string
+}
+
+type VariableAddressOut struct {
+	Addr uint64
+	Size int64
+}
+
+// VariableAddress returns the address and the size in bytes of the
+// memory location described by arg.Expr.
+//
+// The expression must evaluate to an addressable value, for example
+// a variable, a struct field or an element of an array or a slice.
+func (s *RPCServer) VariableAddress(arg VariableAddressIn, out *VariableAddressOut) error {
+	var err error
+	out.Addr, out.Size, err = s.debugger.VariableAddress(arg.Scope, arg.Expr)
+	return err
+}
+
+type UnwrapErrorIn struct {
+	Scope api.EvalScope
+	Expr  string
+	Cfg   *api.LoadConfig
+}
+
+type UnwrapErrorOut struct {
+	Errors []api.Variable
+}
+
+// UnwrapError evaluates arg.Expr, which must be an error, and returns the
+// chain of errors it wraps, starting with the value of arg.Expr itself.
+//
+// Wrapped errors are found by reading the err or Err field of each error,
+// if there is no such field the Unwrap method of the error is called, when
+// the target supports function calls.
+func (s *RPCServer) UnwrapError(arg UnwrapErrorIn, out *UnwrapErrorOut) error {
+	cfg := s.defaultLoadConfig(arg.Cfg)
+	errs, err := s.debugger.UnwrapError(arg.Scope, arg.Expr, *api.LoadConfigToProc(cfg))
+	if err != nil {
+		return err
+	}
+	out.Errors = errs
+	return nil
+}
+
+type SyncObjectInfoIn struct {
+	Scope api.EvalScope
+	Expr  string
+}
+
+type SyncObjectInfoOut struct {
+	Object *api.SyncObject
+}
+
+// SyncObjectInfo evaluates arg.Expr, which must be a sync.WaitGroup,
+// sync.Once or sync.Cond, or a pointer to one, and returns its decoded
+// internal state: the counter and waiters of a WaitGroup, the done flag
+// of a Once and the number of goroutines waiting on a Cond.
+func (s *RPCServer) SyncObjectInfo(arg SyncObjectInfoIn, out *SyncObjectInfoOut) error {
+	obj, err := s.debugger.SyncObjectInfo(arg.Scope, arg.Expr)
+	if err != nil {
+		return err
+	}
+	out.Object = obj
+	return nil
+}
+
 type SetIn struct {
 	Scope  api.EvalScope
 	Symbol string
@@ -431,6 +932,32 @@ func (s *RPCServer) ListSources(arg ListSourcesIn, out *ListSourcesOut) error {
 	return nil
 }
 
+type ListSourceIn struct {
+	File string
+	Line int
+	// Context is the number of lines returned before and after Line.
+	Context int
+}
+
+type ListSourceOut struct {
+	Lines []api.SourceLine
+}
+
+// ListSource returns the numbered lines of arg.File around arg.Line, read
+// from the filesystem of the machine running the debugger server. The line
+// the selected goroutine is stopped at, if it is one of them, has Current
+// set.
+// An error is returned if arg.File is not a source file of the process or
+// can not be read by the server.
+func (s *RPCServer) ListSource(arg ListSourceIn, out *ListSourceOut) error {
+	lines, err := s.debugger.ListSource(arg.File, arg.Line, arg.Context)
+	if err != nil {
+		return err
+	}
+	out.Lines = lines
+	return nil
+}
+
 type ListFunctionsIn struct {
 	Filter string
 }
@@ -467,20 +994,281 @@ func (s *RPCServer) ListTypes(arg ListTypesIn, out *ListTypesOut) error {
 	return nil
 }
 
+type ListMethodsIn struct {
+	TypeName string
+}
+
+type ListMethodsOut struct {
+	Methods []api.Method
+}
+
+// ListMethods lists the methods of the type arg.TypeName, including the
+// ones with a pointer to it as receiver, with their signatures.
+func (s *RPCServer) ListMethods(arg ListMethodsIn, out *ListMethodsOut) error {
+	methods, err := s.debugger.ListMethods(arg.TypeName)
+	if err != nil {
+		return err
+	}
+	out.Methods = methods
+	return nil
+}
+
 type ListGoroutinesIn struct {
+	Start  int
+	Count  int
+	Filter api.GoroutineFilter
 }
 
 type ListGoroutinesOut struct {
 	Goroutines []*api.Goroutine
+	// Total is the number of goroutines selected by arg.Filter.
+	Total int
 }
 
-// ListGoroutines lists all goroutines.
+// ListGoroutines lists the goroutines selected by arg.Filter, sorted by
+// ID.
+//
+// The first arg.Start goroutines are skipped and, if arg.Count is greater
+// than zero, at most arg.Count goroutines are returned. The order is
+// stable while the target is stopped, allowing clients to paginate the
+// list.
 func (s *RPCServer) ListGoroutines(arg ListGoroutinesIn, out *ListGoroutinesOut) error {
-	gs, err := s.debugger.Goroutines()
+	gs, total, err := s.debugger.FilterGoroutines(arg.Filter, arg.Start, arg.Count)
 	if err != nil {
 		return err
 	}
 	out.Goroutines = gs
+	out.Total = total
+	return nil
+}
+
+type GoroutineByAddressIn struct {
+	Addr uint64
+}
+
+type GoroutineByAddressOut struct {
+	Goroutine *api.Goroutine
+}
+
+// GoroutineByAddress returns the goroutine whose runtime.g struct is
+// stored at address Addr.
+func (s *RPCServer) GoroutineByAddress(arg GoroutineByAddressIn, out *GoroutineByAddressOut) error {
+	g, err := s.debugger.GoroutineByAddress(arg.Addr)
+	if err != nil {
+		return err
+	}
+	out.Goroutine = g
+	return nil
+}
+
+type TracepointStatsIn struct {
+	Id int
+}
+
+type TracepointStatsOut struct {
+	Stats *api.TracepointStats
+}
+
+// TracepointStats returns the statistics of the values of the Aggregate
+// expression of tracepoint arg.Id.
+func (s *RPCServer) TracepointStats(arg TracepointStatsIn, out *TracepointStatsOut) error {
+	stats, err := s.debugger.TracepointStats(arg.Id)
+	if err != nil {
+		return err
+	}
+	out.Stats = stats
+	return nil
+}
+
+type DirectRootReferencesIn struct {
+	Addr uint64
+}
+
+type DirectRootReferencesOut struct {
+	References []api.Reference
+}
+
+// DirectRootReferences returns the words of the GC roots, goroutine
+// stacks and package variables, that contain a pointer to arg.Addr.
+//
+// Only pointers to the exact address are considered and pointers stored
+// in heap objects are not followed: an address without direct root
+// references can still be reachable.
+func (s *RPCServer) DirectRootReferences(arg DirectRootReferencesIn, out *DirectRootReferencesOut) error {
+	var err error
+	out.References, err = s.debugger.DirectRootReferences(arg.Addr)
+	return err
+}
+
+type GoroutineStackInfoIn struct {
+	Id int
+}
+
+type GoroutineStackInfoOut struct {
+	Lo, Hi uint64
+	Used   uint64
+}
+
+// GoroutineStackInfo returns the bounds of the stack of goroutine arg.Id
+// and how many bytes of it are in use, measured from the top of the stack
+// to the goroutine's stack pointer.
+func (s *RPCServer) GoroutineStackInfo(arg GoroutineStackInfoIn, out *GoroutineStackInfoOut) error {
+	var err error
+	out.Lo, out.Hi, out.Used, err = s.debugger.GoroutineStackInfo(arg.Id)
+	return err
+}
+
+type BlockingChainIn struct {
+	Id int
+}
+
+type BlockingChainOut struct {
+	Chain []api.Goroutine
+}
+
+// BlockingChain returns the chain of goroutines goroutine arg.Id is
+// waiting for, starting with goroutine arg.Id itself.
+//
+// A goroutine blocked on a channel operation or on a select statement is
+// considered to be waiting for the first other goroutine that holds a
+// reference to one of the channels in its stack and is not blocked on the
+// same channels. This is a heuristic: the returned goroutine is the one
+// most likely to unblock the previous one. If the goroutines are
+// deadlocked the chain ends by repeating the first goroutine of the cycle.
+func (s *RPCServer) BlockingChain(arg BlockingChainIn, out *BlockingChainOut) error {
+	var err error
+	out.Chain, err = s.debugger.BlockingChain(arg.Id)
+	return err
+}
+
+type ContinueRecordingLinesIn struct {
+	MaxInstructions int
+}
+
+type ContinueRecordingLinesOut struct {
+	State api.DebuggerState
+	Lines []api.Location
+}
+
+// ContinueRecordingLines resumes the selected goroutine one instruction at
+// a time, until a breakpoint is reached or arg.MaxInstructions instructions
+// have been executed, and returns the distinct source lines executed.
+//
+// This is much slower than Continue and other threads are not resumed
+// while the selected goroutine executes.
+func (s *RPCServer) ContinueRecordingLines(arg ContinueRecordingLinesIn, out *ContinueRecordingLinesOut) error {
+	st, lines, err := s.debugger.ContinueRecordingLines(arg.MaxInstructions)
+	if err != nil {
+		return err
+	}
+	out.State = *st
+	out.Lines = lines
+	return nil
+}
+
+type ExamineMemoryIn struct {
+	Addr       uint64
+	Count      int
+	WordSize   int
+	AsPointers bool
+}
+
+type ExamineMemoryOut struct {
+	Words []api.MemWord
+}
+
+// ExamineMemory reads arg.Count words of arg.WordSize bytes starting at
+// arg.Addr. If arg.AsPointers is set words that point to code, package
+// variables or the heap are symbolized. At most 65536 words can be read
+// by a single call.
+func (s *RPCServer) ExamineMemory(arg ExamineMemoryIn, out *ExamineMemoryOut) error {
+	words, err := s.debugger.ExamineMemory(arg.Addr, arg.Count, arg.WordSize, arg.AsPointers)
+	if err != nil {
+		return err
+	}
+	out.Words = words
+	return nil
+}
+
+type ReadMemoryIn struct {
+	Addr   uint64
+	Length int
+}
+
+type ReadMemoryOut struct {
+	Mem []byte
+	// PartialError is set when only the first len(Mem) bytes could be
+	// read because the range crosses unmapped memory.
+	PartialError string
+}
+
+// ReadMemory reads arg.Length bytes of the target's memory starting at
+// arg.Addr. If only part of the range is readable the readable prefix is
+// returned and out.PartialError explains how many bytes were valid.
+// At most 1MB can be read by a single call.
+func (s *RPCServer) ReadMemory(arg ReadMemoryIn, out *ReadMemoryOut) error {
+	mem, err := s.debugger.ReadMemory(arg.Addr, arg.Length)
+	if err != nil {
+		if len(mem) == 0 {
+			return err
+		}
+		out.PartialError = err.Error()
+	}
+	out.Mem = mem
+	return nil
+}
+
+type WriteMemoryIn struct {
+	Addr uint64
+	Data []byte
+}
+
+type WriteMemoryOut struct {
+	Written int
+}
+
+// WriteMemory writes arg.Data to the target's memory starting at
+// arg.Addr. Breakpoints inside the range stay set, the new bytes become
+// the instructions they replace.
+func (s *RPCServer) WriteMemory(arg WriteMemoryIn, out *WriteMemoryOut) error {
+	var err error
+	out.Written, err = s.debugger.WriteMemory(arg.Addr, arg.Data)
+	return err
+}
+
+type RuntimeConfigIn struct {
+}
+
+type RuntimeConfigOut struct {
+	Config *api.RuntimeConfig
+}
+
+// RuntimeConfig returns a snapshot of the configuration of the Go runtime
+// of the target: GOMAXPROCS, the GC percent and the GODEBUG settings.
+func (s *RPCServer) RuntimeConfig(arg RuntimeConfigIn, out *RuntimeConfigOut) error {
+	cfg, err := s.debugger.RuntimeConfig()
+	if err != nil {
+		return err
+	}
+	out.Config = cfg
+	return nil
+}
+
+type ModuleInfoIn struct {
+}
+
+type ModuleInfoOut struct {
+	Info *api.ModuleInfo
+}
+
+// ModuleInfo returns the Go module information and build ID embedded in
+// the executable.
+func (s *RPCServer) ModuleInfo(arg ModuleInfoIn, out *ModuleInfoOut) error {
+	info, err := s.debugger.ModuleInfo()
+	if err != nil {
+		return err
+	}
+	out.Info = info
 	return nil
 }
 
@@ -502,6 +1290,8 @@ func (c *RPCServer) AttachedToExistingProcess(arg AttachedToExistingProcessIn, o
 type FindLocationIn struct {
 	Scope api.EvalScope
 	Loc   string
+	// IncludeSource fills in the Source field of the returned locations.
+	IncludeSource bool
 }
 
 type FindLocationOut struct {
@@ -520,17 +1310,32 @@ type FindLocationOut struct {
 //  * <line> returns a location for a line in the current file
 //  * *<address> returns the location corresponding to the specified address
 //
+// If IncludeSource is set the text of the source line of each location is
+// also returned.
+//
 // NOTE: this function does not actually set breakpoints.
 func (c *RPCServer) FindLocation(arg FindLocationIn, out *FindLocationOut) error {
 	var err error
-	out.Locations, err = c.debugger.FindLocation(arg.Scope, arg.Loc)
+	if arg.IncludeSource {
+		out.Locations, err = c.debugger.FindLocationVerbose(arg.Scope, arg.Loc)
+	} else {
+		out.Locations, err = c.debugger.FindLocation(arg.Scope, arg.Loc)
+	}
 	return err
 }
 
 type DisassembleIn struct {
 	Scope          api.EvalScope
 	StartPC, EndPC uint64
-	Flavour        api.AssemblyFlavour
+	// Flavour is one of api.IntelFlavour, api.GNUFlavour or
+	// api.ATTFlavour, other values are rejected.
+	Flavour api.AssemblyFlavour
+	// BranchesOnly restricts the output to call, jump and return
+	// instructions.
+	BranchesOnly bool
+	// FunctionName, if not empty, is the name of the function to
+	// disassemble, StartPC and EndPC are ignored.
+	FunctionName string
 }
 
 type DisassembleOut struct {
@@ -541,11 +1346,217 @@ type DisassembleOut struct {
 //
 // If both StartPC and EndPC are non-zero the specified range will be disassembled, otherwise the function containing StartPC will be disassembled.
 //
+// If FunctionName is set the function with that name is disassembled instead.
+//
 // Scope is used to mark the instruction the specified gorutine is stopped at.
 //
 // Disassemble will also try to calculate the destination address of an absolute indirect CALL if it happens to be the instruction the selected goroutine is stopped at.
+//
+// If BranchesOnly is set only control transfer instructions (calls, jumps and returns) are returned, the destination of relative jumps is returned in DestLoc.
 func (c *RPCServer) Disassemble(arg DisassembleIn, out *DisassembleOut) error {
 	var err error
-	out.Disassemble, err = c.debugger.Disassemble(arg.Scope, arg.StartPC, arg.EndPC, arg.Flavour)
+	if arg.FunctionName != "" {
+		out.Disassemble, err = c.debugger.DisassembleFunction(arg.Scope, arg.FunctionName, arg.Flavour, arg.BranchesOnly)
+		return err
+	}
+	out.Disassemble, err = c.debugger.Disassemble(arg.Scope, arg.StartPC, arg.EndPC, arg.Flavour, arg.BranchesOnly)
+	return err
+}
+
+type ExportStateIn struct {
+}
+
+type ExportStateOut struct {
+	Snapshot *api.StateSnapshot
+}
+
+// ExportState returns a snapshot of the stopped process: the registers of
+// all threads, the stacktraces of all goroutines and the arguments and
+// local variables of the selected goroutine. See api.StateSnapshot.
+func (s *RPCServer) ExportState(arg ExportStateIn, out *ExportStateOut) error {
+	var err error
+	out.Snapshot, err = s.debugger.ExportState()
 	return err
 }
+
+type DiffStacksIn struct {
+	GoroutineA, GoroutineB int
+}
+
+type DiffStacksOut struct {
+	// Index is the index, counted from the outermost frame, of the first
+	// frame where the two stacktraces call a different function.
+	Index int
+	A, B  []api.Stackframe
+}
+
+// DiffStacks compares the stacktraces of two goroutines, starting from
+// their outermost frame, and returns both stacktraces and the index of the
+// first frame where they diverge.
+func (s *RPCServer) DiffStacks(arg DiffStacksIn, out *DiffStacksOut) error {
+	idx, a, b, err := s.debugger.DiffStacks(arg.GoroutineA, arg.GoroutineB)
+	if err != nil {
+		return err
+	}
+	out.Index, out.A, out.B = idx, a, b
+	return nil
+}
+
+type GCPercentIn struct {
+}
+
+type GCPercentOut struct {
+	Percent int
+}
+
+// GCPercent returns the garbage collection target percentage of the target
+// process, as set by GOGC or runtime/debug.SetGCPercent. A negative value
+// means that the garbage collector is disabled.
+func (s *RPCServer) GCPercent(arg GCPercentIn, out *GCPercentOut) error {
+	pct, err := s.debugger.GCPercent()
+	if err != nil {
+		return err
+	}
+	out.Percent = pct
+	return nil
+}
+
+type ForceGCIn struct {
+}
+
+type ForceGCOut struct {
+}
+
+// ForceGC runs a garbage collection in the target process by calling
+// runtime.GC on the current goroutine. Like all function calls this
+// requires a target built with Go 1.11 to 1.16, running on linux/amd64.
+func (s *RPCServer) ForceGC(arg ForceGCIn, out *ForceGCOut) error {
+	return s.debugger.ForceGC()
+}
+
+type SetGCPercentIn struct {
+	Percent int
+}
+
+type SetGCPercentOut struct {
+	Previous int
+}
+
+// SetGCPercent sets the garbage collection target percentage of the target
+// process by calling runtime/debug.SetGCPercent on the current goroutine.
+// A negative value disables the garbage collector. The previous value is
+// returned. Like ForceGC this requires a target built with Go 1.11 to 1.16.
+func (s *RPCServer) SetGCPercent(arg SetGCPercentIn, out *SetGCPercentOut) error {
+	var err error
+	out.Previous, err = s.debugger.SetGCPercent(arg.Percent)
+	return err
+}
+
+type BreakOnChannelOpIn struct {
+	Scope api.EvalScope
+	Expr  string
+	// Op is api.ChanSend, api.ChanRecv or empty for both.
+	Op string
+}
+
+type BreakOnChannelOpOut struct {
+	Breakpoints []*api.Breakpoint
+}
+
+// BreakOnChannelOp creates breakpoints that stop the next time a goroutine
+// sends to or receives from the channel arg.Expr. When one of them is
+// reached the goroutine executing the operation and, for sends, the value
+// being sent are returned in the breakpoint information of the thread.
+// Calling it again for a different channel adds the channel to the
+// condition of the existing breakpoints.
+func (s *RPCServer) BreakOnChannelOp(arg BreakOnChannelOpIn, out *BreakOnChannelOpOut) error {
+	bps, err := s.debugger.BreakOnChannelOp(arg.Scope, arg.Expr, arg.Op)
+	if err != nil {
+		return err
+	}
+	out.Breakpoints = bps
+	return nil
+}
+
+type CreateWatchpointIn struct {
+	Scope api.EvalScope
+	Expr  string
+	Kind  api.WatchKind
+}
+
+type CreateWatchpointOut struct {
+	Breakpoint *api.Breakpoint
+}
+
+// CreateWatchpoint creates a hardware watchpoint on the memory of
+// arg.Expr that stops when it is accessed as specified by arg.Kind.
+// The watchpoint is listed by ListBreakpoints and can be cleared like a
+// breakpoint. Watchpoints on local variables are removed automatically
+// when they go out of scope and reported in the next state.
+func (s *RPCServer) CreateWatchpoint(arg CreateWatchpointIn, out *CreateWatchpointOut) error {
+	bp, err := s.debugger.CreateWatchpoint(arg.Scope, arg.Expr, arg.Kind)
+	if err != nil {
+		return err
+	}
+	out.Breakpoint = bp
+	return nil
+}
+
+type ActiveFunctionsIn struct {
+}
+
+type ActiveFunctionsOut struct {
+	Functions map[string]int
+}
+
+// ActiveFunctions returns a histogram of the functions currently on the
+// stack of any goroutine: for each function the number of goroutines that
+// have it somewhere on their stack.
+func (s *RPCServer) ActiveFunctions(arg ActiveFunctionsIn, out *ActiveFunctionsOut) error {
+	fns, err := s.debugger.ActiveFunctions()
+	if err != nil {
+		return err
+	}
+	out.Functions = fns
+	return nil
+}
+
+type GetLoadConfigIn struct {
+}
+
+type GetLoadConfigOut struct {
+	Cfg api.LoadConfig
+}
+
+// GetLoadConfig returns the load configuration used by the calls that
+// receive a nil or zero LoadConfig.
+func (s *RPCServer) GetLoadConfig(arg GetLoadConfigIn, out *GetLoadConfigOut) error {
+	out.Cfg = *s.defaultLoadConfig(nil)
+	return nil
+}
+
+type SetLoadConfigIn struct {
+	Cfg api.LoadConfig
+}
+
+type SetLoadConfigOut struct {
+}
+
+// SetLoadConfig changes the load configuration used by the calls that
+// receive a nil or zero LoadConfig, for all clients. Calls passing any
+// other LoadConfig are not affected.
+func (s *RPCServer) SetLoadConfig(arg SetLoadConfigIn, out *SetLoadConfigOut) error {
+	if arg.Cfg == (api.LoadConfig{}) {
+		return errors.New("the default load configuration can not be the zero value")
+	}
+	if arg.Cfg.MaxVariableRecurse < 0 {
+		return fmt.Errorf("invalid MaxVariableRecurse %d", arg.Cfg.MaxVariableRecurse)
+	}
+	if err := api.ValidFormat(arg.Cfg.Format); err != nil {
+		return err
+	}
+	s.loadConfigMu.Lock()
+	s.loadConfig = arg.Cfg
+	s.loadConfigMu.Unlock()
+	return nil
+}
@@ -109,9 +109,14 @@ func (s *ServerImpl) Run() error {
 
 	// Create and start the debugger
 	if s.debugger, err = debugger.New(&debugger.Config{
-		ProcessArgs: s.config.ProcessArgs,
-		AttachPid:   s.config.AttachPid,
-		WorkingDir:  s.config.WorkingDir,
+		ProcessArgs:    s.config.ProcessArgs,
+		AttachPid:      s.config.AttachPid,
+		CoreFile:       s.config.CoreFile,
+		WorkingDir:     s.config.WorkingDir,
+		StopAtEntry:    s.config.StopAtEntry,
+		PTY:            s.config.PTY,
+		Backend:        s.config.Backend,
+		RedirectOutput: s.config.RedirectOutput,
 	}); err != nil {
 		return err
 	}
@@ -1,11 +1,16 @@
 package servicetest
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"math/rand"
 	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
@@ -17,17 +22,22 @@ import (
 	"github.com/derekparker/delve/proc"
 	"github.com/derekparker/delve/service"
 	"github.com/derekparker/delve/service/api"
+	"github.com/derekparker/delve/service/debugger"
 	"github.com/derekparker/delve/service/rpc2"
 	"github.com/derekparker/delve/service/rpccommon"
 )
 
-var normalLoadConfig = api.LoadConfig{true, 1, 64, 64, -1}
+var normalLoadConfig = api.LoadConfig{true, 1, 64, 64, -1, ""}
 
 func TestMain(m *testing.M) {
 	os.Exit(protest.RunTestsWithFixtures(m))
 }
 
 func withTestClient2(name string, t *testing.T, fn func(c service.Client)) {
+	withTestClient2Extended(name, t, 0, fn)
+}
+
+func withTestClient2Extended(name string, t *testing.T, buildFlags protest.BuildFlags, fn func(c service.Client)) {
 	listener, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
 		t.Fatalf("couldn't start listener: %s\n", err)
@@ -35,7 +45,7 @@ func withTestClient2(name string, t *testing.T, fn func(c service.Client)) {
 	defer listener.Close()
 	server := rpccommon.NewServer(&service.Config{
 		Listener:    listener,
-		ProcessArgs: []string{protest.BuildFixture(name).Path},
+		ProcessArgs: []string{protest.BuildFixtureWithFlags(name, buildFlags).Path},
 	}, false)
 	if err := server.Run(); err != nil {
 		t.Fatal(err)
@@ -436,13 +446,21 @@ func TestClientServer_infoLocals(t *testing.T) {
 		if state.Err != nil {
 			t.Fatalf("Unexpected error: %v, state: %#v", state.Err, state)
 		}
-		locals, err := c.ListLocalVariables(api.EvalScope{-1, 0}, normalLoadConfig)
+		locals, err := c.ListLocalVariables(api.EvalScope{-1, 0}, "", normalLoadConfig)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
 		if len(locals) != 3 {
 			t.Fatalf("Expected 3 locals, got %d %#v", len(locals), locals)
 		}
+
+		locals, err = c.ListLocalVariables(api.EvalScope{-1, 0}, "^[jf]$", normalLoadConfig)
+		assertNoError(err, t, "ListLocalVariables() with filter")
+		if len(locals) != 2 || locals[0].Name == "i" || locals[1].Name == "i" {
+			t.Fatalf("Expected locals j and f, got %#v", locals)
+		}
+		_, err = c.ListLocalVariables(api.EvalScope{-1, 0}, "[", normalLoadConfig)
+		assertError(err, t, "ListLocalVariables() with invalid filter")
 	})
 }
 
@@ -461,16 +479,22 @@ func TestClientServer_infoArgs(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
-		if regs == "" {
-			t.Fatal("Expected string showing registers values, got empty string")
+		if len(regs) == 0 {
+			t.Fatal("Expected list of registers, got empty list")
 		}
-		locals, err := c.ListFunctionArgs(api.EvalScope{-1, 0}, normalLoadConfig)
+		locals, err := c.ListFunctionArgs(api.EvalScope{-1, 0}, "", normalLoadConfig)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
 		if len(locals) != 2 {
 			t.Fatalf("Expected 2 function args, got %d %#v", len(locals), locals)
 		}
+
+		locals, err = c.ListFunctionArgs(api.EvalScope{-1, 0}, "^foo$", normalLoadConfig)
+		assertNoError(err, t, "ListFunctionArgs() with filter")
+		if len(locals) != 1 || locals[0].Name != "foo" {
+			t.Fatalf("Expected argument foo, got %#v", locals)
+		}
 	})
 }
 
@@ -580,6 +604,9 @@ func TestClientServer_FindLocations(t *testing.T) {
 		someFunctionLine1 := findLocationHelper(t, c, "locationsprog.go:27", false, 1, 0)[0]
 		findLocationHelper(t, c, "anotherFunction:1", false, 1, someFunctionLine1)
 		findLocationHelper(t, c, "main.anotherFunction:1", false, 1, someFunctionLine1)
+		findLocationHelper(t, c, "main.anotherFunction:+0", false, 1, someFunctionLine1)
+		findLocationHelper(t, c, "main.main:+2", false, 1, findLocationHelper(t, c, "locationsprog.go:33", false, 1, 0)[0])
+		findLocationHelper(t, c, "locationsprog.go:+27", true, 0, 0)
 		findLocationHelper(t, c, "anotherFunction", false, 1, someFunctionCallAddr)
 		findLocationHelper(t, c, "main.anotherFunction", false, 1, someFunctionCallAddr)
 		findLocationHelper(t, c, fmt.Sprintf("*0x%x", someFunctionCallAddr), false, 1, someFunctionCallAddr)
@@ -714,6 +741,35 @@ func TestClientServer_EvalVariable(t *testing.T) {
 	})
 }
 
+func TestClientServer_VariableAddress(t *testing.T) {
+	withTestClient2("testvariables", t, func(c service.Client) {
+		state := <-c.Continue()
+
+		if state.Err != nil {
+			t.Fatalf("Continue(): %v\n", state.Err)
+		}
+
+		a2, err := c.EvalVariable(api.EvalScope{-1, 0}, "a2", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(a2)")
+		addr, size, err := c.VariableAddress(api.EvalScope{-1, 0}, "a2")
+		assertNoError(err, t, "VariableAddress(a2)")
+		if addr != uint64(a2.Addr) || size != 8 {
+			t.Fatalf("Wrong address or size for a2: %#x %d (expected %#x 8)", addr, size, a2.Addr)
+		}
+
+		a11, err := c.EvalVariable(api.EvalScope{-1, 0}, "a11", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(a11)")
+		addr, size, err = c.VariableAddress(api.EvalScope{-1, 0}, "a11[1].Bur")
+		assertNoError(err, t, "VariableAddress(a11[1].Bur)")
+		if addr != uint64(a11.Children[1].Children[1].Addr) || size != 16 {
+			t.Fatalf("Wrong address or size for a11[1].Bur: %#x %d", addr, size)
+		}
+
+		_, _, err = c.VariableAddress(api.EvalScope{-1, 0}, "a2 + 1")
+		assertError(err, t, "VariableAddress(a2 + 1)")
+	})
+}
+
 func TestClientServer_SetVariable(t *testing.T) {
 	withTestClient2("testvariables", t, func(c service.Client) {
 		state := <-c.Continue()
@@ -745,7 +801,7 @@ func TestClientServer_FullStacktrace(t *testing.T) {
 			t.Fatalf("Continue(): %v\n", state.Err)
 		}
 
-		gs, err := c.ListGoroutines()
+		gs, _, err := c.ListGoroutines(0, 0)
 		assertNoError(err, t, "GoroutinesInfo()")
 		found := make([]bool, 10)
 		for _, g := range gs {
@@ -849,13 +905,13 @@ func TestIssue355(t *testing.T) {
 		_, err = c.GetThread(tid)
 		assertError(err, t, "GetThread()")
 		assertError(c.SetVariable(api.EvalScope{gid, 0}, "a", "10"), t, "SetVariable()")
-		_, err = c.ListLocalVariables(api.EvalScope{gid, 0}, normalLoadConfig)
+		_, err = c.ListLocalVariables(api.EvalScope{gid, 0}, "", normalLoadConfig)
 		assertError(err, t, "ListLocalVariables()")
-		_, err = c.ListFunctionArgs(api.EvalScope{gid, 0}, normalLoadConfig)
+		_, err = c.ListFunctionArgs(api.EvalScope{gid, 0}, "", normalLoadConfig)
 		assertError(err, t, "ListFunctionArgs()")
 		_, err = c.ListRegisters()
 		assertError(err, t, "ListRegisters()")
-		_, err = c.ListGoroutines()
+		_, _, err = c.ListGoroutines(0, 0)
 		assertError(err, t, "ListGoroutines()")
 		_, err = c.Stacktrace(gid, 10, &normalLoadConfig)
 		assertError(err, t, "Stacktrace()")
@@ -1011,6 +1067,49 @@ func TestClientServer_CondBreakpoint(t *testing.T) {
 		if nvar.SinglelineString() != "7" {
 			t.Fatalf("Stopped on wrong goroutine %s\n", nvar.Value)
 		}
+
+		// conditions can use register pseudo-variables
+		bp.Cond = "$sp != 0 && n == 8"
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint() 3")
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint() 3")
+		if bp.Cond != "$sp != 0 && n == 8" {
+			t.Fatalf("wrong condition %q", bp.Cond)
+		}
+	})
+}
+
+func TestClientServer_IgnoreBreakpoint(t *testing.T) {
+	withTestClient2("break", t, func(c service.Client) {
+		fp := testProgPath(t, "break")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 7, Ignore: 3})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		ivar, err := c.EvalVariable(api.EvalScope{-1, 0}, "i", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable()")
+		if ivar.Value != "4" {
+			t.Fatalf("Stopped on wrong iteration: i = %s", ivar.Value)
+		}
+
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint()")
+		if bp.Ignore != 0 || bp.TotalHitCount != 4 {
+			t.Fatalf("Wrong ignore or hit count: %d %d", bp.Ignore, bp.TotalHitCount)
+		}
+
+		bp.Ignore = 2
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint()")
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		ivar, err = c.EvalVariable(api.EvalScope{-1, 0}, "i", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable()")
+		if ivar.Value != "7" {
+			t.Fatalf("Stopped on wrong iteration after amend: i = %s", ivar.Value)
+		}
 	})
 }
 
@@ -1149,3 +1248,2517 @@ func TestClientServer_Issue528(t *testing.T) {
 		findLocationHelper(t, c, "State.Close", false, 1, 0)
 	})
 }
+
+func TestClientServer_RegistersChanged(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 47})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		_, err = c.StepInstruction()
+		assertNoError(err, t, "StepInstruction()")
+		regs, err := c.ListRegisterValues()
+		assertNoError(err, t, "ListRegisterValues()")
+		found := false
+		for _, reg := range regs {
+			if reg.Name == "Rip" {
+				found = true
+				if !reg.Changed {
+					t.Fatalf("Rip not marked as changed after StepInstruction: %#v", reg)
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("Rip not found in %#v", regs)
+		}
+	})
+}
+
+func TestClientServer_GoroutineByAddress(t *testing.T) {
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		gs, _, err := c.ListGoroutines(0, 0)
+		assertNoError(err, t, "ListGoroutines()")
+		for _, g := range gs {
+			if g.Addr == 0 {
+				t.Fatalf("goroutine %d has no address", g.ID)
+			}
+			g2, err := c.GoroutineByAddress(g.Addr)
+			assertNoError(err, t, fmt.Sprintf("GoroutineByAddress(%#x)", g.Addr))
+			if g2.ID != g.ID {
+				t.Fatalf("GoroutineByAddress(%#x) returned goroutine %d, expected %d", g.Addr, g2.ID, g.ID)
+			}
+		}
+
+		_, err = c.GoroutineByAddress(0)
+		assertError(err, t, "GoroutineByAddress(0)")
+	})
+}
+
+func TestClientServer_ModuleInfo(t *testing.T) {
+	withTestClient2("continuetestprog", t, func(c service.Client) {
+		info, err := c.ModuleInfo()
+		if err != nil && err.Error() == proc.NoBuildInfoErr.Error() {
+			t.Skip("executable does not contain module information")
+		}
+		assertNoError(err, t, "ModuleInfo()")
+		if !strings.HasPrefix(info.GoVersion, "go") {
+			t.Fatalf("wrong Go version %q", info.GoVersion)
+		}
+		if info.BuildID == "" {
+			t.Fatal("no build ID")
+		}
+	})
+}
+
+func TestClientServer_UnwrapError(t *testing.T) {
+	withTestClient2("errorchain", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		chain, err := c.UnwrapError(api.EvalScope{-1, 0}, "err", normalLoadConfig)
+		assertNoError(err, t, "UnwrapError(err)")
+		tgt := []string{"*main.wrapError", "*main.wrapError", "*errors.errorString"}
+		if len(chain) != len(tgt) {
+			t.Fatalf("wrong chain length %d: %#v", len(chain), chain)
+		}
+		for i := range chain {
+			if len(chain[i].Children) != 1 || chain[i].Children[0].Type != tgt[i] {
+				t.Fatalf("wrong type for error %d: %#v", i, chain[i])
+			}
+		}
+
+		chain, err = c.UnwrapError(api.EvalScope{-1, 0}, "errnil", normalLoadConfig)
+		assertNoError(err, t, "UnwrapError(errnil)")
+		if len(chain) != 0 {
+			t.Fatalf("expected empty chain for nil error: %#v", chain)
+		}
+
+		_, err = c.UnwrapError(api.EvalScope{-1, 0}, "base.(*errors.errorString).s", normalLoadConfig)
+		assertError(err, t, "UnwrapError(non-interface)")
+
+		// errhidden can only be unwrapped calling its Unwrap method
+		protest.MustSupportFunctionCalls(t)
+		chain, err = c.UnwrapError(api.EvalScope{-1, 0}, "errhidden", normalLoadConfig)
+		assertNoError(err, t, "UnwrapError(errhidden)")
+		tgt = []string{"*main.hiddenError", "*main.wrapError", "*errors.errorString"}
+		if len(chain) != len(tgt) {
+			t.Fatalf("wrong chain length %d: %#v", len(chain), chain)
+		}
+		for i := range chain {
+			if len(chain[i].Children) != 1 || chain[i].Children[0].Type != tgt[i] {
+				t.Fatalf("wrong type for error %d: %#v", i, chain[i])
+			}
+		}
+	})
+}
+
+func TestClientServer_BreakpointPartialFile(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: "testnextprog.go", Line: 47})
+		assertNoError(err, t, "CreateBreakpoint()")
+		if bp.File != fp || bp.Line != 47 {
+			t.Fatalf("breakpoint set at %s:%d, expected %s:47", bp.File, bp.Line, fp)
+		}
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.File != fp || state.CurrentThread.Line != 47 {
+			t.Fatalf("stopped at %s:%d", state.CurrentThread.File, state.CurrentThread.Line)
+		}
+	})
+}
+
+func TestClientServer_ExamineMemory(t *testing.T) {
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		addr, _, err := c.VariableAddress(api.EvalScope{-1, 0}, "i1")
+		assertNoError(err, t, "VariableAddress(i1)")
+		words, err := c.ExamineMemory(addr, 2, 8, true)
+		assertNoError(err, t, "ExamineMemory(i1)")
+		if len(words) != 2 || words[0].Addr != addr || words[0].Value != 1 || words[1].Addr != addr+8 {
+			t.Fatalf("wrong words %#v", words)
+		}
+		if words[0].PointerKind != "" {
+			t.Fatalf("integer classified as pointer: %#v", words[0])
+		}
+
+		// fn1 points to a funcval whose first word is the entry point of main.afunc
+		addr, _, err = c.VariableAddress(api.EvalScope{-1, 0}, "fn1")
+		assertNoError(err, t, "VariableAddress(fn1)")
+		words, err = c.ExamineMemory(addr, 1, 8, false)
+		assertNoError(err, t, "ExamineMemory(fn1)")
+		words, err = c.ExamineMemory(words[0].Value, 1, 8, true)
+		assertNoError(err, t, "ExamineMemory(funcval)")
+		if words[0].PointerKind != "code" || words[0].Symbol != "main.afunc+0x0" {
+			t.Fatalf("wrong classification of function entry point: %#v", words[0])
+		}
+
+		_, err = c.ExamineMemory(addr, 1, 3, false)
+		assertError(err, t, "ExamineMemory(wordSize=3)")
+		_, err = c.ExamineMemory(addr, math.MaxInt32, 8, false)
+		assertError(err, t, "ExamineMemory(count=MaxInt32)")
+	})
+}
+
+func TestClientServer_ReadWriteMemory(t *testing.T) {
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		addr, _, err := c.VariableAddress(api.EvalScope{-1, 0}, "i1")
+		assertNoError(err, t, "VariableAddress(i1)")
+		mem, err := c.ReadMemory(addr, 8)
+		assertNoError(err, t, "ReadMemory(i1)")
+		if !bytes.Equal(mem, []byte{1, 0, 0, 0, 0, 0, 0, 0}) {
+			t.Fatalf("wrong memory %v", mem)
+		}
+		n, err := c.WriteMemory(addr, []byte{42})
+		assertNoError(err, t, "WriteMemory(i1)")
+		if n != 1 {
+			t.Fatalf("wrong number of bytes written %d", n)
+		}
+		i1, err := c.EvalVariable(api.EvalScope{-1, 0}, "i1", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(i1)")
+		if i1.Value != "42" {
+			t.Fatalf("wrong value of i1 after WriteMemory: %s", i1.Value)
+		}
+
+		// breakpoints are hidden from the returned memory
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.afunc", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		insts, err := c.DisassemblePC(api.EvalScope{-1, 0}, bp.Addr, api.IntelFlavour)
+		assertNoError(err, t, "DisassemblePC()")
+		for _, inst := range insts {
+			if inst.Loc.PC != bp.Addr {
+				continue
+			}
+			mem, err := c.ReadMemory(bp.Addr, len(inst.Bytes))
+			assertNoError(err, t, "ReadMemory(bp)")
+			if !bytes.Equal(mem, inst.Bytes) {
+				t.Fatalf("breakpoint visible in memory: %v, expected %v", mem, inst.Bytes)
+			}
+		}
+
+		_, err = c.ReadMemory(0, 8)
+		assertError(err, t, "ReadMemory(0)")
+		_, err = c.ReadMemory(addr, math.MaxInt32)
+		assertError(err, t, "ReadMemory(length=MaxInt32)")
+	})
+}
+
+func TestClientServer_StopAtEntry(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("couldn't start listener: %s\n", err)
+	}
+	defer listener.Close()
+	server := rpccommon.NewServer(&service.Config{
+		Listener:    listener,
+		ProcessArgs: []string{protest.BuildFixture("testnextprog").Path},
+		APIVersion:  2,
+		StopAtEntry: true,
+	}, false)
+	if err := server.Run(); err != nil {
+		t.Fatal(err)
+	}
+	c := rpc2.NewClient(listener.Addr().String())
+	defer c.Detach(true)
+
+	state, err := c.GetState()
+	assertNoError(err, t, "GetState()")
+	if state.CurrentThread == nil || state.CurrentThread.Function == nil {
+		t.Fatalf("no current function: %#v", state)
+	}
+	if fn := state.CurrentThread.Function.Name; fn != "main.init" && fn != "main.main" {
+		t.Fatalf("stopped in %s, expected main.init or main.main", fn)
+	}
+}
+
+func TestClientServer_ContinueRecordingLines(t *testing.T) {
+	withTestClient2("break", t, func(c service.Client) {
+		fp := testProgPath(t, "break")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 4})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		_, err = c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 8})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		state, lines, err := c.ContinueRecordingLines(100000)
+		assertNoError(err, t, "ContinueRecordingLines()")
+		if state.CurrentThread.Line != 8 {
+			t.Fatalf("stopped at line %d, expected 8", state.CurrentThread.Line)
+		}
+		hit := map[int]bool{}
+		for _, l := range lines {
+			if l.File == fp {
+				hit[l.Line] = true
+			}
+		}
+		for _, line := range []int{4, 6, 7} {
+			if !hit[line] {
+				t.Fatalf("line %d not recorded: %v", line, lines)
+			}
+		}
+		if hit[8] {
+			t.Fatalf("line 8 recorded before being executed: %v", lines)
+		}
+	})
+}
+
+func TestClientServer_LocalVariablesAllBlocks(t *testing.T) {
+	withTestClient2("shadowtest", t, func(c service.Client) {
+		fp := testProgPath(t, "shadowtest")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 11})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		vars, err := c.ListLocalVariablesAllBlocks(api.EvalScope{-1, 0}, normalLoadConfig)
+		assertNoError(err, t, "ListLocalVariablesAllBlocks()")
+		type tgt struct {
+			value    string
+			shadowed bool
+		}
+		found := map[int64]tgt{}
+		for _, v := range vars {
+			t.Logf("%s = %s declared at %d shadowed %v", v.Name, v.Value, v.DeclLine, v.Shadowed)
+			if v.Name == "x" {
+				found[v.DeclLine] = tgt{v.Value, v.Shadowed}
+			}
+		}
+		if found[6] != (tgt{"1", true}) {
+			t.Fatalf("wrong outer x: %#v", found[6])
+		}
+		if found[9] != (tgt{"3", false}) {
+			t.Fatalf("wrong inner x: %#v", found[9])
+		}
+	})
+}
+
+func TestClientServer_RuntimeConfig(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.main", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		cfg, err := c.RuntimeConfig()
+		assertNoError(err, t, "RuntimeConfig()")
+		if cfg.GOMAXPROCS != int64(runtime.GOMAXPROCS(0)) {
+			t.Fatalf("wrong GOMAXPROCS %d, expected %d", cfg.GOMAXPROCS, runtime.GOMAXPROCS(0))
+		}
+		if os.Getenv("GOGC") == "" && cfg.GCPercent != 100 {
+			t.Fatalf("wrong GC percent %d", cfg.GCPercent)
+		}
+	})
+}
+
+func TestClientServer_DisasmResolvedTarget(t *testing.T) {
+	withTestClient2("locationsprog2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		locs, err := c.FindLocation(api.EvalScope{-1, 0}, "main.main")
+		assertNoError(err, t, "FindLocation()")
+		d, err := c.DisassemblePC(api.EvalScope{-1, 0}, locs[0].PC, api.IntelFlavour)
+		assertNoError(err, t, "DisassemblePC()")
+
+		found := false
+		for i := range d {
+			if d[i].Loc.Line == 29 && strings.HasPrefix(d[i].Text, "call") && d[i].DestLoc != nil {
+				if d[i].ResolvedTarget != d[i].DestLoc.PC {
+					t.Fatalf("wrong resolved target for %q: %#x (expected %#x)", d[i].Text, d[i].ResolvedTarget, d[i].DestLoc.PC)
+				}
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("Could not find call to main.afunction on line 29")
+		}
+	})
+}
+
+func TestClientServer_GoroutineStackInfo(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		lo, hi, used, err := c.GoroutineStackInfo(state.SelectedGoroutine.ID)
+		assertNoError(err, t, "GoroutineStackInfo()")
+		t.Logf("lo: %#x hi: %#x used: %d", lo, hi, used)
+		if lo == 0 || hi <= lo {
+			t.Fatalf("wrong stack bounds %#x-%#x", lo, hi)
+		}
+		if used == 0 || used > hi-lo {
+			t.Fatalf("wrong stack usage %d for stack %#x-%#x", used, lo, hi)
+		}
+
+		_, _, _, err = c.GoroutineStackInfo(1000000)
+		assertError(err, t, "GoroutineStackInfo() of unknown goroutine")
+	})
+}
+
+func TestClientServer_TracepointStats(t *testing.T) {
+	withTestClient2("integrationprog", t, func(c service.Client) {
+		fp := testProgPath(t, "integrationprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, Aggregate: "i"})
+		assertError(err, t, "CreateBreakpoint() with aggregate expression on a breakpoint")
+
+		// pseudo-variables are accepted by aggregate expressions and log messages
+		pbp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, Tracepoint: true, Aggregate: "$ndefers", LogMessage: "panicking {$panicking}"})
+		assertNoError(err, t, "CreateBreakpoint() with pseudo-variables")
+		_, err = c.ClearBreakpoint(pbp.ID)
+		assertNoError(err, t, "ClearBreakpoint()")
+
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, Tracepoint: true, Aggregate: "i"})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		count := 0
+		for state := range c.Continue() {
+			if state.CurrentThread != nil && state.CurrentThread.Breakpoint != nil && state.CurrentThread.Breakpoint.ID == bp.ID {
+				count++
+			}
+			if !state.Exited && state.Err != nil {
+				t.Fatalf("Unexpected error during continue: %v\n", state.Err)
+			}
+		}
+		if count != 0 {
+			t.Fatalf("aggregating tracepoint returned %d states", count)
+		}
+
+		stats, err := c.TracepointStats(bp.ID)
+		assertNoError(err, t, "TracepointStats()")
+		t.Logf("stats: %#v", stats)
+		if stats.Count != 3 || stats.Min != 0 || stats.Max != 2 || stats.Sum != 3 {
+			t.Fatalf("wrong statistics %#v", stats)
+		}
+		if stats.Histogram[0] != 1 || stats.Histogram[1] != 1 || stats.Histogram[2] != 1 {
+			t.Fatalf("wrong histogram %v", stats.Histogram)
+		}
+	})
+}
+
+func TestClientServer_BreakpointTemplate(t *testing.T) {
+	withTestClient2("integrationprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.sayhi", Line: -1, Template: "trace"})
+		assertError(err, t, "CreateBreakpoint() with undefined template")
+
+		err = c.DefineBreakpointTemplate("trace", api.Breakpoint{Cond: "i =="})
+		assertError(err, t, "DefineBreakpointTemplate() with invalid condition")
+
+		err = c.DefineBreakpointTemplate("trace", api.Breakpoint{Tracepoint: true, Goroutine: true, Stacktrace: 5, Variables: []string{"i"}})
+		assertNoError(err, t, "DefineBreakpointTemplate()")
+
+		fp := testProgPath(t, "integrationprog")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, Stacktrace: 2, Template: "trace"})
+		assertNoError(err, t, "CreateBreakpoint()")
+		if !bp.Tracepoint || !bp.Goroutine || len(bp.Variables) != 1 || bp.Variables[0] != "i" {
+			t.Fatalf("template not applied: %#v", bp)
+		}
+		if bp.Stacktrace != 2 {
+			t.Fatalf("template overrode the stacktrace depth: %d", bp.Stacktrace)
+		}
+	})
+}
+
+func TestClientServer_DirectRootReferences(t *testing.T) {
+	withTestClient2("reachable", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		for _, tc := range []struct {
+			expr   string
+			global bool
+		}{{"main.global", true}, {"local", false}} {
+			v, err := c.EvalVariable(api.EvalScope{-1, 0}, tc.expr, normalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			if len(v.Children) != 1 || v.Children[0].Addr == 0 {
+				t.Fatalf("%s: could not read pointer: %#v", tc.expr, v)
+			}
+			refs, err := c.DirectRootReferences(uint64(v.Children[0].Addr))
+			assertNoError(err, t, fmt.Sprintf("DirectRootReferences(%s)", tc.expr))
+			t.Logf("%s: %#v", tc.expr, refs)
+			found := false
+			for _, ref := range refs {
+				if tc.global && ref.Symbol == "main.global+0x0" {
+					found = true
+				}
+				if !tc.global && ref.GoroutineID == state.SelectedGoroutine.ID {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("%s: reference not found", tc.expr)
+			}
+		}
+
+		refs, err := c.DirectRootReferences(0xdeadbeef0)
+		assertNoError(err, t, "DirectRootReferences(0xdeadbeef0)")
+		if len(refs) != 0 {
+			t.Fatalf("unexpected references to 0xdeadbeef0: %#v", refs)
+		}
+	})
+}
+
+func TestClientServer_PCSymbol(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		th := state.CurrentThread
+		if th.Function == nil || th.Function.Name != "main.helloworld" {
+			t.Fatalf("stopped in wrong function %#v", th.Function)
+		}
+		expected := fmt.Sprintf("main.helloworld+%#x", th.PC-th.Function.Value)
+		if th.PCSymbol != expected {
+			t.Fatalf("wrong PC symbol %q, expected %q", th.PCSymbol, expected)
+		}
+	})
+}
+
+func TestClientServer_FindLocationsFrame(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		// the call to helloworld on line 34 is the last statement of
+		// testnext, its return address belongs to line 35.
+		for _, tc := range []struct {
+			locspec string
+			line    int
+		}{{"+0", 34}, {"-3", 31}, {"34", 34}} {
+			locs, err := c.FindLocation(api.EvalScope{-1, 1}, tc.locspec)
+			assertNoError(err, t, fmt.Sprintf("FindLocation(%q)", tc.locspec))
+			if len(locs) != 1 || locs[0].Line != tc.line || locs[0].Function == nil || locs[0].Function.Name != "main.testnext" {
+				t.Fatalf("wrong location for %q in frame 1: %#v", tc.locspec, locs)
+			}
+		}
+	})
+}
+
+func TestClientServer_RegisterFlags(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		regs, err := c.ListRegisterValues()
+		assertNoError(err, t, "ListRegisterValues()")
+		for _, reg := range regs {
+			if reg.Name != "Eflags" && reg.Name != "Rflags" {
+				continue
+			}
+			if reg.Flags == nil {
+				t.Fatalf("flags not decoded: %#v", reg)
+			}
+			zf, err := c.EvalVariable(api.EvalScope{-1, 0}, "$zf", normalLoadConfig)
+			assertNoError(err, t, "EvalVariable($zf)")
+			if zf.Value != strconv.FormatBool(reg.Flags["ZF"]) || reg.Flags["ZF"] != (reg.Value&(1<<6) != 0) {
+				t.Fatalf("wrong ZF: %s %#v", zf.Value, reg)
+			}
+			return
+		}
+		t.Fatalf("flags register not found in %#v", regs)
+	})
+}
+
+func TestClientServer_PendingBreakpointsAhead(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.testnext", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		// reached through a direct call from testnext
+		bpHello, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		// reached after testnext returns to main
+		bpMain, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 40})
+		assertNoError(err, t, "CreateBreakpoint()")
+		// never reached from the current position
+		bpInit, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 51})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		bps, err := c.PendingBreakpointsAhead(-1)
+		assertNoError(err, t, "PendingBreakpointsAhead()")
+		found := map[int]bool{}
+		for _, bp := range bps {
+			found[bp.ID] = true
+		}
+		if !found[bpHello.ID] || !found[bpMain.ID] || found[bpInit.ID] || found[state.CurrentThread.Breakpoint.ID] {
+			t.Fatalf("wrong pending breakpoints %v", found)
+		}
+	})
+}
+
+func TestClientServer_BreakpointTiming(t *testing.T) {
+	withTestClient2("integrationprog", t, func(c service.Client) {
+		fp := testProgPath(t, "integrationprog")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, Cond: "i == 2", Variables: []string{"i"}})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint()")
+		t.Logf("cond: %v capture: %v", bp.CondEvalTime, bp.CaptureTime)
+		if bp.CondEvalTime <= 0 {
+			t.Fatalf("condition evaluation time not recorded")
+		}
+		if bp.CaptureTime <= 0 {
+			t.Fatalf("capture time not recorded")
+		}
+	})
+}
+
+func TestClientServer_ThreadName(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("thread names are only supported on linux")
+	}
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.main", Line: 1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		threads, err := c.ListThreads()
+		assertNoError(err, t, "ListThreads()")
+		for _, th := range threads {
+			t.Logf("thread %d %q", th.ID, th.Name)
+			if th.Name == "" {
+				t.Fatalf("thread %d has no name", th.ID)
+			}
+		}
+	})
+}
+
+func TestClientServer_EvalVariableTimeout(t *testing.T) {
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		_, err := c.EvalVariableTimeout(api.EvalScope{-1, 0}, "m1", normalLoadConfig, time.Nanosecond)
+		assertError(err, t, "EvalVariableTimeout(m1) with expired timeout")
+		t.Logf("error: %v", err)
+
+		m1, err := c.EvalVariableTimeout(api.EvalScope{-1, 0}, "m1", normalLoadConfig, time.Minute)
+		assertNoError(err, t, "EvalVariableTimeout(m1)")
+		if len(m1.Children) == 0 {
+			t.Fatalf("m1 not loaded")
+		}
+
+		_, err = c.EvalVariable(api.EvalScope{-1, 0}, "m1", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(m1) after a timed out evaluation")
+	})
+}
+
+func TestClientServer_SyncObjectInfo(t *testing.T) {
+	withTestClient2("syncobjects", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		wg, err := c.SyncObjectInfo(api.EvalScope{-1, 0}, "wg")
+		assertNoError(err, t, "SyncObjectInfo(wg)")
+		if wg.Type != "sync.WaitGroup" || wg.Counter != 3 || wg.Waiters != 0 {
+			t.Fatalf("wrong WaitGroup state %#v", wg)
+		}
+
+		once, err := c.SyncObjectInfo(api.EvalScope{-1, 0}, "once")
+		assertNoError(err, t, "SyncObjectInfo(once)")
+		if once.Type != "sync.Once" || !once.Done {
+			t.Fatalf("wrong Once state %#v", once)
+		}
+		once2, err := c.SyncObjectInfo(api.EvalScope{-1, 0}, "once2")
+		assertNoError(err, t, "SyncObjectInfo(once2)")
+		if once2.Done {
+			t.Fatalf("wrong Once state %#v", once2)
+		}
+
+		cond, err := c.SyncObjectInfo(api.EvalScope{-1, 0}, "cond")
+		assertNoError(err, t, "SyncObjectInfo(cond)")
+		if cond.Type != "sync.Cond" || cond.Waiters != 0 {
+			t.Fatalf("wrong Cond state %#v", cond)
+		}
+
+		_, err = c.SyncObjectInfo(api.EvalScope{-1, 0}, "mu")
+		assertError(err, t, "SyncObjectInfo(mu)")
+	})
+}
+
+func TestClientServer_ContinueUntilGoroutineChange(t *testing.T) {
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.SelectedGoroutine == nil {
+			t.Fatal("no selected goroutine")
+		}
+		startID := state.SelectedGoroutine.ID
+
+		state = <-c.ContinueUntilGoroutineChange()
+		assertNoError(state.Err, t, "ContinueUntilGoroutineChange()")
+		if state.SelectedGoroutine == nil || state.SelectedGoroutine.ID == startID {
+			t.Fatalf("goroutine did not change: %#v", state.SelectedGoroutine)
+		}
+		t.Logf("switched from goroutine %d to %d at %s:%d", startID, state.SelectedGoroutine.ID, state.SelectedGoroutine.CurrentLoc.File, state.SelectedGoroutine.CurrentLoc.Line)
+	})
+}
+
+func TestClientServer_BreakpointSkipPrologue(t *testing.T) {
+	withTestClient2("locationsprog2", t, func(c service.Client) {
+		afunction := findLocationHelper(t, c, "main.afunction", false, 1, 0)[0]
+		afunction0 := findLocationHelper(t, c, "main.afunction:0", false, 1, 0)[0]
+
+		skip, noskip := true, false
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.afunction", Line: -1, SkipPrologue: &noskip})
+		assertNoError(err, t, "CreateBreakpoint(SkipPrologue: false)")
+		if bp.Addr != afunction0 {
+			t.Fatalf("breakpoint at %#x, expected function entry %#x", bp.Addr, afunction0)
+		}
+
+		bp, err = c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.afunction", Line: 0, SkipPrologue: &skip})
+		assertNoError(err, t, "CreateBreakpoint(SkipPrologue: true)")
+		if bp.Addr != afunction {
+			t.Fatalf("breakpoint at %#x, expected %#x", bp.Addr, afunction)
+		}
+
+		_, err = c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.afunction", Line: 2, SkipPrologue: &skip})
+		assertError(err, t, "CreateBreakpoint(SkipPrologue with line offset)")
+	})
+}
+
+func TestClientServer_AnchoredBreakpoint(t *testing.T) {
+	withTestClient2("locationsprog2", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: testProgPath(t, "locationsprog2"), Line: 28, Anchored: true})
+		assertNoError(err, t, "CreateBreakpoint()")
+		if !bp.Anchored || bp.FunctionName != "main.main" || bp.AnchorStatement <= 0 {
+			t.Fatalf("wrong anchor: %#v", bp)
+		}
+
+		assertNoError(c.Restart(), t, "Restart()")
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		var found *api.Breakpoint
+		for _, b := range bps {
+			if b.ID == bp.ID {
+				found = b
+			}
+		}
+		if found == nil {
+			t.Fatal("anchored breakpoint not preserved")
+		}
+		if found.Line != 28 || !found.Anchored || found.AnchorStatement != bp.AnchorStatement {
+			t.Fatalf("wrong breakpoint after restart: %#v", found)
+		}
+
+		// the first stop is the call to runtime.Breakpoint on line 27
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Line != 28 {
+			t.Fatalf("stopped at line %d, expected 28", state.CurrentThread.Line)
+		}
+	})
+}
+
+func TestClientServer_ExportState(t *testing.T) {
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		f, err := ioutil.TempFile("", "dlvstate")
+		assertNoError(err, t, "TempFile()")
+		path := f.Name()
+		f.Close()
+		defer os.Remove(path)
+
+		assertNoError(c.ExportState(path), t, "ExportState()")
+
+		snapshot, err := api.ImportState(path)
+		assertNoError(err, t, "ImportState()")
+
+		if snapshot.State == nil || snapshot.State.SelectedGoroutine == nil {
+			t.Fatalf("no selected goroutine in snapshot")
+		}
+		if len(snapshot.Registers) != len(snapshot.State.Threads) {
+			t.Fatalf("registers of %d threads exported, %d threads", len(snapshot.Registers), len(snapshot.State.Threads))
+		}
+		found := false
+		for _, gsnap := range snapshot.Goroutines {
+			if gsnap.Goroutine.ID != snapshot.State.SelectedGoroutine.ID {
+				continue
+			}
+			found = true
+			if len(gsnap.Stacktrace) == 0 {
+				t.Fatalf("empty stacktrace for the selected goroutine")
+			}
+			haslocal := false
+			for _, v := range gsnap.Stacktrace[0].Locals {
+				if v.Name == "i1" {
+					haslocal = true
+				}
+			}
+			if !haslocal {
+				t.Fatalf("local variable i1 not exported: %#v", gsnap.Stacktrace[0].Locals)
+			}
+		}
+		if !found {
+			t.Fatalf("selected goroutine not exported")
+		}
+
+		// the imported snapshot answers the same queries as the process
+		locals, err := snapshot.ListLocalVariables(api.EvalScope{-1, 0})
+		assertNoError(err, t, "ListLocalVariables()")
+		if len(locals) == 0 {
+			t.Fatalf("no local variables in imported snapshot")
+		}
+		stack, err := snapshot.Stacktrace(-1, 0)
+		assertNoError(err, t, "Stacktrace()")
+		if len(stack) != 1 || stack[0].Function == nil || stack[0].Function.Name != "main.main" {
+			t.Fatalf("wrong stacktrace in imported snapshot %#v", stack)
+		}
+		regs, err := snapshot.ListRegisters(-1)
+		assertNoError(err, t, "ListRegisters()")
+		if len(regs) == 0 {
+			t.Fatalf("no registers for the current thread")
+		}
+	})
+}
+
+func TestClientServer_BlockingChain(t *testing.T) {
+	withTestClient2("blockingchain", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.SelectedGoroutine == nil {
+			t.Fatal("no selected goroutine")
+		}
+		bID := state.SelectedGoroutine.ID
+
+		chain, err := c.BlockingChain(1)
+		assertNoError(err, t, "BlockingChain(1)")
+		for _, g := range chain {
+			t.Logf("goroutine %d started at %s:%d", g.ID, g.GoStatementLoc.File, g.GoStatementLoc.Line)
+		}
+		if len(chain) != 3 || chain[0].ID != 1 || chain[2].ID != bID {
+			t.Fatalf("wrong chain, expected main -> a -> b (%d)", bID)
+		}
+		if chain[1].GoStatementLoc.Line != 23 {
+			t.Fatalf("second goroutine of the chain not started by main")
+		}
+
+		chain, err = c.BlockingChain(bID)
+		assertNoError(err, t, "BlockingChain(b)")
+		if len(chain) != 1 {
+			t.Fatalf("running goroutine should not be blocked, got chain of %d goroutines", len(chain))
+		}
+	})
+}
+
+func TestClientServer_EvalPair(t *testing.T) {
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		// find a goroutine blocked in main.agoroutine and the frame of agoroutine
+		gs, _, err := c.ListGoroutines(0, 0)
+		assertNoError(err, t, "ListGoroutines()")
+		var scopeA *api.EvalScope
+		for _, g := range gs {
+			frames, err := c.Stacktrace(g.ID, 10, nil)
+			assertNoError(err, t, "Stacktrace()")
+			for i := range frames {
+				if frames[i].Function != nil && frames[i].Function.Name == "main.agoroutine" {
+					scopeA = &api.EvalScope{g.ID, i}
+					break
+				}
+			}
+			if scopeA != nil {
+				break
+			}
+		}
+		if scopeA == nil {
+			t.Fatal("could not find a goroutine in main.agoroutine")
+		}
+
+		a, b, errA, errB := c.EvalPair(*scopeA, "i", api.EvalScope{-1, 1}, "done", normalLoadConfig)
+		assertNoError(errA, t, "EvalPair() first expression")
+		assertNoError(errB, t, "EvalPair() second expression")
+		if a.Type != "int" || b.Type != "chan struct {}" {
+			t.Fatalf("wrong values %#v %#v", a, b)
+		}
+
+		a, b, errA, errB = c.EvalPair(*scopeA, "i", api.EvalScope{-1, 1}, "nonexistent", normalLoadConfig)
+		assertNoError(errA, t, "EvalPair() first expression")
+		assertError(errB, t, "EvalPair() second expression")
+		if a == nil || b != nil {
+			t.Fatalf("wrong values %#v %#v", a, b)
+		}
+	})
+}
+
+func TestClientServer_ActiveFunctions(t *testing.T) {
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		fns, err := c.ActiveFunctions()
+		assertNoError(err, t, "ActiveFunctions()")
+		t.Logf("%v", fns)
+		if n := fns["main.agoroutine"]; n != 10 {
+			t.Fatalf("main.agoroutine found on %d goroutines, expected 10", n)
+		}
+		if n := fns["main.main"]; n != 1 {
+			t.Fatalf("main.main found on %d goroutines, expected 1", n)
+		}
+	})
+}
+
+func TestClientServer_DiffStacks(t *testing.T) {
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		gs, _, err := c.ListGoroutines(0, 0)
+		assertNoError(err, t, "ListGoroutines()")
+		workers := []int{}
+		for _, g := range gs {
+			if g.UserCurrentLoc.Function != nil && g.UserCurrentLoc.Function.Name == "main.agoroutine" {
+				workers = append(workers, g.ID)
+			}
+		}
+		if len(workers) < 2 {
+			t.Fatalf("not enough goroutines in main.agoroutine: %v", workers)
+		}
+
+		idx, a, b, err := c.DiffStacks(workers[0], workers[1])
+		assertNoError(err, t, "DiffStacks()")
+		if len(a) != len(b) || idx != len(a) {
+			t.Fatalf("stacks of goroutines %d and %d diverge at %d (lengths %d %d)", workers[0], workers[1], idx, len(a), len(b))
+		}
+
+		idx, a, b, err = c.DiffStacks(state.SelectedGoroutine.ID, workers[0])
+		assertNoError(err, t, "DiffStacks()")
+		if idx >= len(a) || idx >= len(b) {
+			t.Fatalf("stacks do not diverge (index %d)", idx)
+		}
+		if fa, fb := a[len(a)-1-idx].Function, b[len(b)-1-idx].Function; fa != nil && fb != nil && fa.Name == fb.Name {
+			t.Fatalf("frames at divergence point call the same function %s", fa.Name)
+		}
+		if api.DiffStacks(a, b) != idx {
+			t.Fatal("api.DiffStacks mismatch")
+		}
+	})
+}
+
+func TestClientServer_BreakpointActiveAfter(t *testing.T) {
+	withTestClient2("timedloop", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.tick", Line: -1, ActiveUntil: time.Second, ActiveAfter: 2 * time.Second})
+		assertError(err, t, "CreateBreakpoint(ActiveUntil before ActiveAfter)")
+
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.tick", Line: -1, ActiveAfter: 400 * time.Millisecond})
+		assertNoError(err, t, "CreateBreakpoint()")
+		if bp.ActiveAfter != 400*time.Millisecond {
+			t.Fatalf("wrong ActiveAfter %v", bp.ActiveAfter)
+		}
+
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		i, err := c.EvalVariable(api.EvalScope{-1, 0}, "i", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(i)")
+		n, _ := strconv.Atoi(i.Value)
+		if n < 4 {
+			t.Fatalf("breakpoint active too early, at iteration %d", n)
+		}
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		for _, b := range bps {
+			if b.ID == bp.ID && b.TotalHitCount != 1 {
+				t.Fatalf("hits before activation were counted: %d", b.TotalHitCount)
+			}
+		}
+	})
+}
+
+func TestClientServer_GCPercent(t *testing.T) {
+	if os.Getenv("GOGC") != "" {
+		t.Skip("GOGC is set")
+	}
+	withTestClient2("continuetestprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.sayhi", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		pct, err := c.GCPercent()
+		assertNoError(err, t, "GCPercent()")
+		if pct != 100 {
+			t.Fatalf("wrong GC percentage %d", pct)
+		}
+	})
+}
+
+func TestClientServer_SetGCPercent(t *testing.T) {
+	if os.Getenv("GOGC") != "" {
+		t.Skip("GOGC is set")
+	}
+	protest.MustSupportFunctionCalls(t)
+	withTestClient2("fncall", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		prev, err := c.SetGCPercent(-1)
+		assertNoError(err, t, "SetGCPercent(-1)")
+		if prev != 100 {
+			t.Fatalf("wrong previous GC percentage %d", prev)
+		}
+		pct, err := c.GCPercent()
+		assertNoError(err, t, "GCPercent()")
+		if pct != -1 {
+			t.Fatalf("wrong GC percentage %d", pct)
+		}
+
+		assertNoError(c.ForceGC(), t, "ForceGC()")
+
+		prev, err = c.SetGCPercent(100)
+		assertNoError(err, t, "SetGCPercent(100)")
+		if prev != -1 {
+			t.Fatalf("wrong previous GC percentage %d", prev)
+		}
+	})
+}
+
+func TestClientServer_DisassembleBranches(t *testing.T) {
+	withTestClient2("locationsprog2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		all, err := c.DisassemblePC(api.EvalScope{-1, 0}, state.CurrentThread.PC, api.IntelFlavour)
+		assertNoError(err, t, "DisassemblePC()")
+		branches, err := c.DisassembleBranches(api.EvalScope{-1, 0}, state.CurrentThread.PC, 0, api.IntelFlavour)
+		assertNoError(err, t, "DisassembleBranches()")
+		if len(branches) == 0 || len(branches) >= len(all) {
+			t.Fatalf("wrong number of branch instructions %d (of %d)", len(branches), len(all))
+		}
+
+		foundCall, foundJump := false, false
+		for _, inst := range branches {
+			switch {
+			case strings.HasPrefix(inst.Text, "call"):
+				if inst.Loc.Line == 29 && inst.DestLoc != nil && inst.DestLoc.Function != nil && inst.DestLoc.Function.Name == "main.afunction" {
+					foundCall = true
+				}
+			case strings.HasPrefix(inst.Text, "j"):
+				if inst.ResolvedTarget != 0 && (inst.DestLoc == nil || inst.DestLoc.PC != inst.ResolvedTarget) {
+					t.Fatalf("jump without destination: %#v", inst)
+				}
+				foundJump = true
+			case strings.HasPrefix(inst.Text, "ret"):
+			default:
+				t.Fatalf("not a branch instruction: %q", inst.Text)
+			}
+		}
+		if !foundCall || !foundJump {
+			t.Fatalf("missing call (%v) or jump (%v)", foundCall, foundJump)
+		}
+	})
+}
+
+func TestClientServer_CallFrameValues(t *testing.T) {
+	withTestClient2("locationsprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.(*SomeType).SomeFunction", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		recv, args, err := c.CallFrameValues(api.EvalScope{-1, 0}, normalLoadConfig)
+		assertNoError(err, t, "CallFrameValues()")
+		if recv == nil || recv.Name != "a" || recv.Type != "*main.SomeType" {
+			t.Fatalf("wrong receiver %#v", recv)
+		}
+		if len(args) != 0 {
+			t.Fatalf("receiver included in arguments %#v", args)
+		}
+	})
+
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: testProgPath(t, "testnextprog"), Line: 47})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		recv, args, err := c.CallFrameValues(api.EvalScope{-1, 0}, normalLoadConfig)
+		assertNoError(err, t, "CallFrameValues()")
+		if recv != nil {
+			t.Fatalf("receiver returned for a function %#v", recv)
+		}
+		if len(args) != 2 {
+			t.Fatalf("expected 2 function args, got %d %#v", len(args), args)
+		}
+	})
+}
+
+func TestClientServer_BreakOnChannelOp(t *testing.T) {
+	withTestClient2("chanops", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		mainID := state.SelectedGoroutine.ID
+
+		_, err := c.BreakOnChannelOp(api.EvalScope{-1, 0}, "a", "close")
+		assertError(err, t, "BreakOnChannelOp(close)")
+		_, err = c.BreakOnChannelOp(api.EvalScope{-1, 0}, "mainID", api.ChanSend)
+		assertError(err, t, "BreakOnChannelOp(not a channel)")
+
+		bps, err := c.BreakOnChannelOp(api.EvalScope{-1, 0}, "a", api.ChanSend)
+		assertNoError(err, t, "BreakOnChannelOp()")
+		if len(bps) != 1 || bps[0].FunctionName != "runtime.chansend" {
+			t.Fatalf("wrong breakpoints %#v", bps)
+		}
+
+		// a second channel shares the breakpoint on runtime.chansend
+		bps2, err := c.BreakOnChannelOp(api.EvalScope{-1, 0}, "b", api.ChanSend)
+		assertNoError(err, t, "BreakOnChannelOp(b)")
+		if len(bps2) != 1 || bps2[0].ID != bps[0].ID || !strings.Contains(bps2[0].Cond, "||") {
+			t.Fatalf("wrong shared breakpoint %#v", bps2)
+		}
+
+		for _, tc := range []struct {
+			value string
+			main  bool
+		}{{"1", true}, {"42", false}} {
+			state = <-c.Continue()
+			assertNoError(state.Err, t, "Continue()")
+			th := state.CurrentThread
+			if th.Breakpoint == nil || th.Breakpoint.ID != bps[0].ID {
+				t.Fatalf("stopped at %s:%d instead of channel breakpoint", th.File, th.Line)
+			}
+			if th.BreakpointInfo == nil || th.BreakpointInfo.Goroutine == nil || (th.BreakpointInfo.Goroutine.ID == mainID) != tc.main {
+				t.Fatalf("wrong goroutine information %#v", th.BreakpointInfo)
+			}
+			if len(th.BreakpointInfo.Variables) != 1 || th.BreakpointInfo.Variables[0].Value != tc.value {
+				t.Fatalf("wrong value sent %#v, expected %s", th.BreakpointInfo.Variables, tc.value)
+			}
+		}
+
+		// the channels do not exist in the restarted process
+		assertNoError(c.Restart(), t, "Restart()")
+		all, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		for _, bp := range all {
+			if bp.ID == bps[0].ID || bp.FunctionName == "runtime.chansend" {
+				t.Fatalf("channel breakpoint kept after restart: %#v", bp)
+			}
+		}
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue() after restart")
+		if state.CurrentThread.Breakpoint != nil && state.CurrentThread.Breakpoint.FunctionName == "runtime.chansend" {
+			t.Fatal("stopped at a channel breakpoint after restart")
+		}
+		bps, err = c.BreakOnChannelOp(api.EvalScope{-1, 0}, "a", api.ChanSend)
+		assertNoError(err, t, "BreakOnChannelOp() after restart")
+		if len(bps) != 1 || strings.Contains(bps[0].Cond, "||") {
+			t.Fatalf("wrong breakpoints after restart %#v", bps)
+		}
+	})
+}
+
+func TestClientServer_InspectionKeepsSelectedGoroutine(t *testing.T) {
+	// Read-only calls with an explicit scope must not change the selected
+	// goroutine or the current thread.
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		gs, _, err := c.ListGoroutines(0, 0)
+		assertNoError(err, t, "ListGoroutines()")
+		var worker *api.Goroutine
+		for _, g := range gs {
+			if g.ThreadID == 0 && g.UserCurrentLoc.Function != nil && g.UserCurrentLoc.Function.Name == "main.agoroutine" {
+				worker = g
+				break
+			}
+		}
+		if worker == nil {
+			t.Fatal("could not find a parked goroutine in main.agoroutine")
+		}
+		state, err = c.SwitchGoroutine(worker.ID)
+		assertNoError(err, t, "SwitchGoroutine()")
+		threadID := state.CurrentThread.ID
+
+		for _, g := range gs {
+			frames, err := c.Stacktrace(g.ID, 20, &normalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("Stacktrace(%d)", g.ID))
+			for i := range frames {
+				scope := api.EvalScope{g.ID, i}
+				c.ListLocalVariables(scope, "", normalLoadConfig)
+				c.ListFunctionArgs(scope, "", normalLoadConfig)
+				c.CallFrameValues(scope, normalLoadConfig)
+				c.EvalVariable(scope, "i", normalLoadConfig)
+			}
+			c.DisassemblePC(api.EvalScope{g.ID, 0}, g.CurrentLoc.PC, api.IntelFlavour)
+		}
+		c.EvalPair(api.EvalScope{state.SelectedGoroutine.ID, 0}, "i", api.EvalScope{1, 0}, "done", normalLoadConfig)
+
+		state, err = c.GetState()
+		assertNoError(err, t, "GetState()")
+		if state.SelectedGoroutine == nil || state.SelectedGoroutine.ID != worker.ID {
+			t.Fatalf("selected goroutine changed from %d to %#v", worker.ID, state.SelectedGoroutine)
+		}
+		if state.CurrentThread == nil || state.CurrentThread.ID != threadID {
+			t.Fatalf("current thread changed from %d to %#v", threadID, state.CurrentThread)
+		}
+	})
+}
+
+func TestClientServer_ListMethods(t *testing.T) {
+	withTestClient2("locationsprog", t, func(c service.Client) {
+		methods, err := c.ListMethods("main.SomeType")
+		assertNoError(err, t, "ListMethods()")
+		t.Logf("%#v", methods)
+		if len(methods) != 2 || methods[0].Name != "SomeFunction" || methods[1].Name != "String" {
+			t.Fatalf("wrong methods %#v", methods)
+		}
+		for _, m := range methods {
+			if m.Receiver != "*main.SomeType" || m.Entry == 0 {
+				t.Fatalf("wrong method %#v", m)
+			}
+		}
+		if methods[1].Signature != "func() string" {
+			t.Fatalf("wrong signature for String: %q", methods[1].Signature)
+		}
+
+		_, err = c.ListMethods("main.NonexistentType")
+		assertError(err, t, "ListMethods(nonexistent type)")
+	})
+}
+
+func TestClientServer_CreateWatchpoint(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("hardware watchpoints are only supported on linux")
+	}
+	withTestClient2("watchprog", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		_, err := c.CreateWatchpoint(api.EvalScope{-1, 0}, "global+1", api.WatchWrite)
+		assertError(err, t, "CreateWatchpoint(not addressable)")
+
+		wp, err := c.CreateWatchpoint(api.EvalScope{-1, 0}, "global", api.WatchWrite)
+		assertNoError(err, t, "CreateWatchpoint()")
+		if wp.WatchExpr != "global" || wp.WatchKind != api.WatchWrite {
+			t.Fatalf("wrong watchpoint %#v", wp)
+		}
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		found := false
+		for _, bp := range bps {
+			if bp.ID == wp.ID {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("watchpoint %d not listed", wp.ID)
+		}
+
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != wp.ID {
+			t.Fatalf("stopped at %s:%d instead of watchpoint", state.CurrentThread.File, state.CurrentThread.Line)
+		}
+		if state.CurrentThread.Line != 21 && state.CurrentThread.Line != 22 {
+			t.Fatalf("watchpoint reported at line %d", state.CurrentThread.Line)
+		}
+		_, err = c.ClearBreakpoint(wp.ID)
+		assertNoError(err, t, "ClearBreakpoint()")
+
+		// stop inside main.local and watch its local variable
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		lwp, err := c.CreateWatchpoint(api.EvalScope{-1, 0}, "x", api.WatchWrite)
+		assertNoError(err, t, "CreateWatchpoint(x)")
+
+		for i := 0; i < 10; i++ {
+			state = <-c.Continue()
+			assertNoError(state.Err, t, "Continue()")
+			if len(state.WatchOutOfScope) > 0 {
+				break
+			}
+		}
+		if len(state.WatchOutOfScope) != 1 || state.WatchOutOfScope[0].ID != lwp.ID {
+			t.Fatalf("watchpoint on x not reported out of scope %#v", state.WatchOutOfScope)
+		}
+		bps, err = c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		for _, bp := range bps {
+			if bp.ID == lwp.ID {
+				t.Fatalf("watchpoint %d not removed", lwp.ID)
+			}
+		}
+	})
+}
+
+func TestClientServer_TracepointLogToFile(t *testing.T) {
+	logpath := filepath.Join(os.TempDir(), fmt.Sprintf("dlvlog%d", rand.Int()))
+	defer os.Remove(logpath)
+	withTestClient2("integrationprog", t, func(c service.Client) {
+		fp := testProgPath(t, "integrationprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, Tracepoint: true, LogToFile: logpath})
+		assertError(err, t, "CreateBreakpoint() with LogToFile and no message")
+		_, err = c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, Tracepoint: true, LogMessage: "i = {i +}", LogToFile: logpath})
+		assertError(err, t, "CreateBreakpoint() with invalid log message")
+
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, Tracepoint: true, LogMessage: "i = {i}", LogToFile: logpath})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		for state := range c.Continue() {
+			if state.CurrentThread != nil && state.CurrentThread.Breakpoint != nil && state.CurrentThread.Breakpoint.ID == bp.ID {
+				t.Fatalf("logging tracepoint stopped the process")
+			}
+			if !state.Exited && state.Err != nil {
+				t.Fatalf("Unexpected error during continue: %v\n", state.Err)
+			}
+		}
+
+		buf, err := ioutil.ReadFile(logpath)
+		assertNoError(err, t, "ReadFile()")
+		if string(buf) != "i = 0\ni = 1\ni = 2\n" {
+			t.Fatalf("wrong log file contents %q", string(buf))
+		}
+
+		err = c.Restart()
+		assertNoError(err, t, "Restart()")
+		buf, err = ioutil.ReadFile(logpath)
+		assertNoError(err, t, "ReadFile()")
+		if len(buf) != 0 {
+			t.Fatalf("log file not truncated by Restart: %q", string(buf))
+		}
+	})
+}
+
+func TestClientServer_HitCond(t *testing.T) {
+	withTestClient2("integrationprog", t, func(c service.Client) {
+		fp := testProgPath(t, "integrationprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, HitCond: ">= x"})
+		assertError(err, t, "CreateBreakpoint() with invalid hit condition")
+
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, HitCond: "== 2"})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+			t.Fatalf("did not stop at breakpoint")
+		}
+		if state.CurrentThread.Breakpoint.TotalHitCount != 2 {
+			t.Fatalf("wrong hit count %d", state.CurrentThread.Breakpoint.TotalHitCount)
+		}
+		v, err := c.EvalVariable(api.EvalScope{-1, 0}, "i", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable()")
+		if v.Value != "1" {
+			t.Fatalf("stopped at iteration %s", v.Value)
+		}
+
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint()")
+		bp.HitCond = "% 2 == 1 &&"
+		assertError(c.AmendBreakpoint(bp), t, "AmendBreakpoint() with invalid hit condition")
+		bp.HitCond = "% 3 == 0"
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint()")
+
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.TotalHitCount != 3 {
+			t.Fatalf("did not stop at third hit %#v", state.CurrentThread.Breakpoint)
+		}
+		if state.CurrentThread.Breakpoint.HitCond != "% 3 == 0" {
+			t.Fatalf("hit condition not amended %q", state.CurrentThread.Breakpoint.HitCond)
+		}
+		if n := state.CurrentThread.Breakpoint.HitCount[strconv.Itoa(state.CurrentThread.GoroutineID)]; n != 3 {
+			t.Fatalf("wrong goroutine hit count %d", n)
+		}
+
+		assertNoError(c.Restart(), t, "Restart()")
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		for _, b := range bps {
+			if b.ID == bp.ID && (b.TotalHitCount != 0 || len(b.HitCount) != 0 || b.HitCond != "% 3 == 0") {
+				t.Fatalf("breakpoint not reset by Restart %#v", b)
+			}
+		}
+	})
+}
+
+func TestClientServer_ListSources(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		sources, err := c.ListSources("")
+		assertNoError(err, t, "ListSources()")
+		fp := testProgPath(t, "testnextprog")
+		foundFixture, foundRuntime := false, false
+		for i, s := range sources {
+			if i > 0 && sources[i-1] >= s {
+				t.Fatalf("sources not sorted or duplicated: %q %q", sources[i-1], s)
+			}
+			if s == fp {
+				foundFixture = true
+			}
+			if strings.HasSuffix(s, "/runtime/proc.go") {
+				foundRuntime = true
+			}
+		}
+		if !foundFixture || !foundRuntime {
+			t.Fatalf("missing source files (fixture: %v, runtime: %v)", foundFixture, foundRuntime)
+		}
+
+		sources, err = c.ListSources("/runtime/.*\\.go$")
+		assertNoError(err, t, "ListSources(filter)")
+		if len(sources) == 0 {
+			t.Fatalf("no runtime sources")
+		}
+		for _, s := range sources {
+			if !strings.Contains(s, "/runtime/") {
+				t.Fatalf("source %q does not match filter", s)
+			}
+		}
+	})
+}
+
+func TestClientServer_ListFunctionsFindLocation(t *testing.T) {
+	// every function returned by ListFunctions must be accepted by
+	// FindLocation
+	withTestClient2("locationsprog2", t, func(c service.Client) {
+		funcs, err := c.ListFunctions("^main\\.")
+		assertNoError(err, t, "ListFunctions()")
+		foundClosure, foundMethod := false, false
+		for _, fn := range funcs {
+			switch fn {
+			case "main.main.func1":
+				foundClosure = true
+			case "main.(*someStruct).structfunc":
+				foundMethod = true
+			}
+			_, err := c.FindLocation(api.EvalScope{-1, 0}, fn)
+			assertNoError(err, t, fmt.Sprintf("FindLocation(%q)", fn))
+		}
+		if !foundClosure || !foundMethod {
+			t.Fatalf("missing functions (closure: %v, method: %v) in %v", foundClosure, foundMethod, funcs)
+		}
+	})
+}
+
+func TestClientServer_ReverseNotSupported(t *testing.T) {
+	withTestClient2("continuetestprog", t, func(c service.Client) {
+		_, err := c.StepBack()
+		assertError(err, t, "StepBack()")
+		_, err = c.ReverseContinue()
+		assertError(err, t, "ReverseContinue()")
+		if err.Error() != debugger.ErrReverseNotSupported.Error() {
+			t.Fatalf("wrong error %v", err)
+		}
+		state := <-c.Continue()
+		if !state.Exited && state.Err != nil {
+			t.Fatalf("Continue() after ReverseContinue(): %v", state.Err)
+		}
+	})
+}
+
+func TestClientServer_EvalFormat(t *testing.T) {
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		for _, tc := range []struct{ expr, format, value string }{
+			{"i6", "", "-500"},
+			{"i6", api.FormatHex, "-0x1f4"},
+			{"i1", api.FormatBinary, "0b1"},
+			{"as1", api.FormatHex, "main.astruct {A: 0x1, B: 0x1}"},
+			{"piBytes[0]", api.FormatChar, "'@'"},
+			{"piBytes[1]", api.FormatHex, "0x9"},
+			{"f1", api.FormatScientific, "3e+00"},
+			{"f1", api.FormatHex, "3"},
+		} {
+			cfg := normalLoadConfig
+			cfg.Format = tc.format
+			v, err := c.EvalVariable(api.EvalScope{-1, 0}, tc.expr, cfg)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s, %q)", tc.expr, tc.format))
+			if s := v.SinglelineString(); s != tc.value {
+				t.Fatalf("Wrong value of %s with format %q: %q, expected %q", tc.expr, tc.format, s, tc.value)
+			}
+		}
+
+		cfg := normalLoadConfig
+		cfg.Format = "octal"
+		_, err := c.EvalVariable(api.EvalScope{-1, 0}, "i1", cfg)
+		assertError(err, t, "EvalVariable with unknown format")
+	})
+}
+
+func TestClientServer_TracepointLogMessage(t *testing.T) {
+	withTestClient2("integrationprog", t, func(c service.Client) {
+		fp := testProgPath(t, "integrationprog")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, Tracepoint: true, LogMessage: "i = {i}"})
+		assertNoError(err, t, "CreateBreakpoint()")
+		_, err = c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.sayhi"})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		var msgs []string
+		for i := 0; i < 3; i++ {
+			state := <-c.Continue()
+			assertNoError(state.Err, t, "Continue()")
+			if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID == bp.ID {
+				t.Fatalf("stopped at wrong breakpoint %#v", state.CurrentThread.Breakpoint)
+			}
+			for _, r := range state.TracepointResults {
+				if r.BreakpointID != bp.ID || r.Line != 15 {
+					t.Fatalf("wrong tracepoint result %#v", r)
+				}
+				msgs = append(msgs, r.Message)
+			}
+		}
+		if strings.Join(msgs, ";") != "i = 0;i = 1;i = 2" {
+			t.Fatalf("wrong tracepoint messages %q", msgs)
+		}
+	})
+}
+
+func TestClientServer_ListGoroutinesFilter(t *testing.T) {
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		all, total, err := c.ListGoroutines(0, 0)
+		assertNoError(err, t, "ListGoroutines()")
+		if total != len(all) {
+			t.Fatalf("wrong total %d, expected %d", total, len(all))
+		}
+
+		// paginate and check that the pages match the full list
+		var paged []*api.Goroutine
+		for start := 0; start < total; start += 3 {
+			gs, pagetotal, err := c.ListGoroutines(start, 3)
+			assertNoError(err, t, "ListGoroutines()")
+			if pagetotal != total || len(gs) > 3 {
+				t.Fatalf("wrong page at %d: %d goroutines, total %d", start, len(gs), pagetotal)
+			}
+			paged = append(paged, gs...)
+		}
+		if len(paged) != len(all) {
+			t.Fatalf("wrong number of paged goroutines %d, expected %d", len(paged), len(all))
+		}
+		for i := range all {
+			if paged[i].ID != all[i].ID || (i > 0 && all[i].ID <= all[i-1].ID) {
+				t.Fatalf("goroutines not sorted consistently at %d: %d %d", i, paged[i].ID, all[i].ID)
+			}
+		}
+
+		running, _, err := c.ListGoroutinesWithFilter(api.GoroutineFilter{Status: api.GoroutineRunning}, 0, 0)
+		assertNoError(err, t, "ListGoroutinesWithFilter(running)")
+		found := false
+		for _, g := range running {
+			if g.Status != api.GoroutineRunning {
+				t.Fatalf("goroutine %d has status %s", g.ID, g.Status)
+			}
+			found = found || g.ID == state.SelectedGoroutine.ID
+		}
+		if !found {
+			t.Fatalf("selected goroutine not listed as running")
+		}
+
+		parked, _, err := c.ListGoroutinesWithFilter(api.GoroutineFilter{Status: api.GoroutineWaiting, Function: `^runtime\.gopark$`}, 0, 0)
+		assertNoError(err, t, "ListGoroutinesWithFilter(waiting)")
+		if len(parked) < 10 {
+			t.Fatalf("expected at least 10 parked goroutines, got %d", len(parked))
+		}
+
+		_, _, err = c.ListGoroutinesWithFilter(api.GoroutineFilter{Function: "("}, 0, 0)
+		assertError(err, t, "ListGoroutinesWithFilter with invalid regex")
+	})
+}
+
+func TestClientServer_GoroutineLabels(t *testing.T) {
+	withTestClient2("goroutinelabels", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if len(state.SelectedGoroutine.Labels) != 0 {
+			t.Fatalf("unexpected labels %v", state.SelectedGoroutine.Labels)
+		}
+
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		labels := state.SelectedGoroutine.Labels
+		if len(labels) != 2 || labels["k1"] != "v1" || labels["k2"] != "v2" {
+			t.Fatalf("wrong labels %v", labels)
+		}
+
+		for _, tc := range []struct {
+			label string
+			found bool
+		}{
+			{"k1", true},
+			{"k2=v2", true},
+			{"k2=v1", false},
+			{"k3", false},
+		} {
+			gs, _, err := c.ListGoroutinesWithFilter(api.GoroutineFilter{Label: tc.label}, 0, 0)
+			assertNoError(err, t, fmt.Sprintf("ListGoroutinesWithFilter(%s)", tc.label))
+			found := false
+			for _, g := range gs {
+				found = found || g.ID == state.SelectedGoroutine.ID
+			}
+			if found != tc.found {
+				t.Fatalf("label filter %q: found %v, expected %v", tc.label, found, tc.found)
+			}
+		}
+	})
+}
+
+func TestClientServer_DisassembleFlavours(t *testing.T) {
+	withTestClient2("locationsprog2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		intel, err := c.DisassemblePC(api.EvalScope{-1, 0}, state.CurrentThread.PC, api.IntelFlavour)
+		assertNoError(err, t, "DisassemblePC(IntelFlavour)")
+		att, err := c.DisassemblePC(api.EvalScope{-1, 0}, state.CurrentThread.PC, api.ATTFlavour)
+		assertNoError(err, t, "DisassemblePC(ATTFlavour)")
+		if len(intel) != len(att) {
+			t.Fatalf("different number of instructions %d (intel) %d (att)", len(intel), len(att))
+		}
+		different := false
+		for i := range intel {
+			a, b := intel[i], att[i]
+			if a.Loc.PC != b.Loc.PC || a.AtPC != b.AtPC {
+				t.Fatalf("mismatched instruction %d: %#v %#v", i, a, b)
+			}
+			if (a.DestLoc == nil) != (b.DestLoc == nil) || (a.DestLoc != nil && a.DestLoc.PC != b.DestLoc.PC) {
+				t.Fatalf("mismatched destination of instruction %d: %#v %#v", i, a.DestLoc, b.DestLoc)
+			}
+			if a.Text != b.Text {
+				different = true
+			}
+		}
+		if !different {
+			t.Fatalf("intel and att disassembly are identical")
+		}
+
+		_, err = c.DisassemblePC(api.EvalScope{-1, 0}, state.CurrentThread.PC, api.AssemblyFlavour(42))
+		assertError(err, t, "DisassemblePC(42)")
+	})
+}
+
+func TestClientServer_SetRegister(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		assertNoError(c.SetRegister(-1, "rbx", "0x1234"), t, "SetRegister(rbx)")
+		assertNoError(c.SetRegister(state.CurrentThread.ID, "R12", "42"), t, "SetRegister(R12)")
+		regs, err := c.ListRegisterValues()
+		assertNoError(err, t, "ListRegisterValues()")
+		values := map[string]uint64{}
+		for _, reg := range regs {
+			values[reg.Name] = reg.Value
+		}
+		if values["Rbx"] != 0x1234 || values["R12"] != 42 {
+			t.Fatalf("registers not written: Rbx=%#x R12=%#x", values["Rbx"], values["R12"])
+		}
+
+		assertError(c.SetRegister(-1, "nosuchregister", "0"), t, "SetRegister(nosuchregister)")
+		assertError(c.SetRegister(-1, "Cs", "0"), t, "SetRegister(Cs)")
+		assertError(c.SetRegister(-1, "rax", "notanumber"), t, "SetRegister(rax, notanumber)")
+		assertError(c.SetRegister(-2, "rax", "0"), t, "SetRegister(-2)")
+	})
+}
+
+func TestClientServer_CreateBreakpoints(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		bps, err := c.CreateBreakpoints([]*api.Breakpoint{{File: fp, Line: 19}, {File: fp, Line: 20}, {File: fp, Line: 23, Tracepoint: true}}, "lines")
+		assertNoError(err, t, "CreateBreakpoints()")
+		if len(bps) != 3 {
+			t.Fatalf("wrong number of breakpoints created %d", len(bps))
+		}
+		for _, bp := range bps {
+			if bp.Group != "lines" || bp.ID <= 0 {
+				t.Fatalf("wrong breakpoint %#v", bp)
+			}
+		}
+
+		_, err = c.CreateBreakpoints([]*api.Breakpoint{{File: fp, Line: 24}, {FunctionName: "main.nosuchfunction", Line: -1}}, "broken")
+		assertError(err, t, "CreateBreakpoints(nosuchfunction)")
+		_, err = c.CreateBreakpoints([]*api.Breakpoint{{File: fp, Line: 24}}, "lines")
+		assertError(err, t, "CreateBreakpoints(existing group)")
+		all, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		if len(all) != 3 {
+			t.Fatalf("breakpoints not rolled back: %d breakpoints", len(all))
+		}
+
+		cleared, err := c.ClearBreakpointGroup("lines")
+		assertNoError(err, t, "ClearBreakpointGroup()")
+		if len(cleared) != 3 {
+			t.Fatalf("wrong number of breakpoints cleared %d", len(cleared))
+		}
+		all, err = c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		if len(all) != 0 {
+			t.Fatalf("breakpoints left after ClearBreakpointGroup: %d", len(all))
+		}
+		_, err = c.ClearBreakpointGroup("lines")
+		assertError(err, t, "ClearBreakpointGroup(cleared)")
+	})
+}
+
+func TestClientServer_ClearBreakpointByName(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.helloworld", Line: -1, Name: "firstbreakpoint"})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		cleared, err := c.ClearBreakpointByName("firstbreakpoint")
+		assertNoError(err, t, "ClearBreakpointByName()")
+		if cleared.ID != bp.ID || cleared.Name != "firstbreakpoint" {
+			t.Fatalf("wrong breakpoint cleared %#v", cleared)
+		}
+		if _, err := c.GetBreakpoint(bp.ID); err == nil {
+			t.Fatalf("breakpoint %d still exists", bp.ID)
+		}
+
+		_, err = c.ClearBreakpointByName("firstbreakpoint")
+		if err == nil || !strings.Contains(err.Error(), "no breakpoint with name firstbreakpoint") {
+			t.Fatalf("wrong error clearing missing breakpoint: %v", err)
+		}
+	})
+}
+
+func TestClientServer_StacktraceDefers(t *testing.T) {
+	withTestClient2("deferstack", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		frames, err := c.StacktraceDefers(-1, 10, &normalLoadConfig)
+		assertNoError(err, t, "StacktraceDefers()")
+		ndefers := map[string]int{}
+		for _, frame := range frames {
+			if frame.Function == nil {
+				continue
+			}
+			for _, d := range frame.Defers {
+				if d.Unreadable != "" {
+					t.Fatalf("unreadable defer in %s: %s", frame.Function.Name, d.Unreadable)
+				}
+				if d.DeferredLoc.Function == nil || d.DeferredLoc.Function.Name != "main.cleanup" {
+					t.Fatalf("wrong deferred function in %s: %#v", frame.Function.Name, d.DeferredLoc)
+				}
+				if d.DeferLoc.Function == nil || d.DeferLoc.Function.Name != frame.Function.Name {
+					t.Fatalf("wrong defer location in %s: %#v", frame.Function.Name, d.DeferLoc)
+				}
+				if len(d.Arguments) > 0 && d.Arguments[0].Value != strings.TrimPrefix(frame.Function.Name, "main.") {
+					t.Fatalf("wrong arguments in %s: %#v", frame.Function.Name, d.Arguments)
+				}
+			}
+			ndefers[frame.Function.Name] = len(frame.Defers)
+		}
+		if ndefers["main.inner"] != 1 || ndefers["main.outer"] != 2 || ndefers["main.main"] != 0 {
+			t.Fatalf("wrong number of defers %v", ndefers)
+		}
+
+		frames, err = c.Stacktrace(-1, 10, &normalLoadConfig)
+		assertNoError(err, t, "Stacktrace()")
+		for _, frame := range frames {
+			if len(frame.Defers) != 0 {
+				t.Fatalf("defers returned without being requested: %#v", frame)
+			}
+		}
+	})
+}
+
+func TestClientServer_StepInto(t *testing.T) {
+	withTestClient2("stepinto", t, func(c service.Client) {
+		fp := testProgPath(t, "stepinto")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 14})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		_, err = c.StepInto(api.EvalScope{-1, 0}, "main.nosuchfunction")
+		assertError(err, t, "StepInto(main.nosuchfunction)")
+
+		// first is called before second but only second is entered
+		state, err = c.StepInto(api.EvalScope{-1, 0}, "main.second")
+		assertNoError(err, t, "StepInto(main.second)")
+		if state.CurrentThread.Function == nil || state.CurrentThread.Function.Name != "main.second" || state.CurrentThread.Line != 10 {
+			t.Fatalf("wrong location after StepInto: %s:%d", state.CurrentThread.File, state.CurrentThread.Line)
+		}
+		if state.NextInProgress {
+			t.Fatalf("step still in progress")
+		}
+
+		// main.first is not called by this line, StepInto behaves like Next
+		state, err = c.StepInto(api.EvalScope{-1, 0}, "main.first")
+		assertNoError(err, t, "StepInto(main.first)")
+		if state.CurrentThread.Function == nil || state.CurrentThread.Function.Name != "main.second" || state.CurrentThread.Line != 11 {
+			t.Fatalf("wrong location after StepInto: %s:%d", state.CurrentThread.File, state.CurrentThread.Line)
+		}
+	})
+}
+
+func TestClientServer_ContinueTo(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		state, err := c.ContinueTo(fmt.Sprintf("%s:%d", fp, 20))
+		assertNoError(err, t, "ContinueTo()")
+		if state.CurrentThread.Line != 20 || state.CurrentThread.Breakpoint != nil || state.NextInProgress {
+			t.Fatalf("wrong state after ContinueTo: %s:%d %#v next in progress %v", state.CurrentThread.File, state.CurrentThread.Line, state.CurrentThread.Breakpoint, state.NextInProgress)
+		}
+		if state.StopReason != api.StopNext {
+			t.Fatalf("wrong stop reason after ContinueTo %q", state.StopReason)
+		}
+
+		// a user breakpoint is reached first, the temporary breakpoint is removed anyway
+		_, err = c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 23})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state, err = c.ContinueTo("main.helloworld")
+		assertNoError(err, t, "ContinueTo(main.helloworld)")
+		if state.CurrentThread.Line != 23 || state.CurrentThread.Breakpoint == nil || state.NextInProgress {
+			t.Fatalf("wrong state after ContinueTo: %s:%d next in progress %v", state.CurrentThread.File, state.CurrentThread.Line, state.NextInProgress)
+		}
+		if state.StopReason != api.StopBreakpoint {
+			t.Fatalf("wrong stop reason after ContinueTo %q", state.StopReason)
+		}
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		if len(bps) != 1 {
+			t.Fatalf("wrong number of breakpoints %d", len(bps))
+		}
+
+		_, err = c.ContinueTo("main.nosuchfunction")
+		assertError(err, t, "ContinueTo(main.nosuchfunction)")
+	})
+}
+
+func TestClientServer_Core(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("core files are only supported on linux")
+	}
+	fixture := protest.BuildFixture("panic")
+	tempDir, err := ioutil.TempDir("", "delve-core")
+	assertNoError(err, t, "TempDir()")
+	defer os.RemoveAll(tempDir)
+	cmd := exec.Command("sh", "-c", "ulimit -c unlimited && exec "+fixture.Path)
+	cmd.Dir = tempDir
+	cmd.Env = append(os.Environ(), "GOTRACEBACK=crash")
+	cmd.Run()
+	cores, _ := filepath.Glob(filepath.Join(tempDir, "core*"))
+	if len(cores) != 1 {
+		t.Skip("core file not produced, check /proc/sys/kernel/core_pattern")
+	}
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	assertNoError(err, t, "Listen()")
+	defer listener.Close()
+	server := rpccommon.NewServer(&service.Config{
+		Listener:    listener,
+		ProcessArgs: []string{fixture.Path},
+		CoreFile:    cores[0],
+		APIVersion:  2,
+	}, false)
+	assertNoError(server.Run(), t, "Run()")
+	c := rpc2.NewClient(listener.Addr().String())
+	defer c.Detach(false)
+
+	gs, _, err := c.ListGoroutines(0, 0)
+	assertNoError(err, t, "ListGoroutines()")
+	if len(gs) == 0 {
+		t.Fatal("no goroutines in core file")
+	}
+	state, err := c.GetState()
+	assertNoError(err, t, "GetState()")
+	if state.SelectedGoroutine == nil {
+		t.Fatal("no selected goroutine")
+	}
+	frames, err := c.Stacktrace(state.SelectedGoroutine.ID, 20, nil)
+	assertNoError(err, t, "Stacktrace()")
+	found, panicFrame := false, -1
+	for i, frame := range frames {
+		if frame.Function != nil && frame.Function.Name == "main.main" {
+			found = true
+		}
+		if frame.Function != nil && frame.Function.Name == "runtime.gopanic" {
+			panicFrame = i
+		}
+	}
+	if !found {
+		t.Fatalf("main.main not found in stacktrace %v", frames)
+	}
+	if panicFrame < 0 {
+		t.Fatalf("runtime.gopanic not found in stacktrace %v", frames)
+	}
+	_, err = c.ListRegisters()
+	assertNoError(err, t, "ListRegisters()")
+
+	v, err := c.EvalVariable(api.EvalScope{state.SelectedGoroutine.ID, panicFrame}, "e", normalLoadConfig)
+	assertNoError(err, t, "EvalVariable(e)")
+	if len(v.Children) != 1 || v.Children[0].Value != "BOOM!" {
+		t.Fatalf("unexpected panic argument %#v", v)
+	}
+
+	state = <-c.Continue()
+	if state.Err == nil {
+		t.Fatal("Continue() on a core file did not fail")
+	}
+	_, err = c.Next()
+	assertError(err, t, "Next()")
+}
+
+func TestClientServer_RestartWith(t *testing.T) {
+	withTestClient2("restartwith", t, func(c service.Client) {
+		check := func(nargs int, env string) {
+			state := <-c.Continue()
+			assertNoError(state.Err, t, "Continue()")
+			v, err := c.EvalVariable(api.EvalScope{-1, 0}, "len(args)", normalLoadConfig)
+			assertNoError(err, t, "EvalVariable(len(args))")
+			if v.Value != strconv.Itoa(nargs) {
+				t.Fatalf("wrong number of arguments %s, expected %d", v.Value, nargs)
+			}
+			v, err = c.EvalVariable(api.EvalScope{-1, 0}, "env", normalLoadConfig)
+			assertNoError(err, t, "EvalVariable(env)")
+			if v.Value != env {
+				t.Fatalf("wrong environment variable %q, expected %q", v.Value, env)
+			}
+		}
+
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.main", Line: 1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		check(0, "")
+
+		assertNoError(c.RestartWith([]string{"a", "b"}, []string{"DLV_RESTART_ENV=x"}), t, "RestartWith()")
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Breakpoint == nil {
+			t.Fatal("breakpoint not preserved by RestartWith")
+		}
+		check(2, "x")
+
+		assertNoError(c.RestartWith(nil, nil), t, "RestartWith(nil, nil)")
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		check(2, "x")
+	})
+}
+
+func TestClientServer_RedirectOutput(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("output redirection is only supported on linux")
+	}
+	listener, err := net.Listen("tcp", "localhost:0")
+	assertNoError(err, t, "Listen()")
+	defer listener.Close()
+	server := rpccommon.NewServer(&service.Config{
+		Listener:       listener,
+		ProcessArgs:    []string{protest.BuildFixture("outputprog").Path},
+		RedirectOutput: true,
+		APIVersion:     2,
+	}, false)
+	assertNoError(server.Run(), t, "Run()")
+	c := rpc2.NewClient(listener.Addr().String())
+	defer c.Detach(true)
+
+	state := <-c.Continue()
+	assertNoError(state.Err, t, "Continue()")
+	// the order of output written to different streams is not preserved
+	streams := map[string]string{}
+	for _, ev := range state.Output {
+		streams[ev.Stream] += ev.Data
+	}
+	expected := map[string]string{
+		api.OutputStdout: "first stdout\n",
+		api.OutputStderr: "first stderr\n",
+	}
+	if !reflect.DeepEqual(streams, expected) {
+		t.Fatalf("wrong output at breakpoint %#v, expected %#v", state.Output, expected)
+	}
+
+	state = <-c.Continue()
+	if !state.Exited {
+		t.Fatalf("process did not exit: %v", state.Err)
+	}
+	if want := []api.OutputEvent{{api.OutputStdout, "second stdout\n"}}; !reflect.DeepEqual(state.Output, want) {
+		t.Fatalf("wrong output at exit %#v, expected %#v", state.Output, want)
+	}
+}
+
+func TestClientServer_SwitchFrame(t *testing.T) {
+	withTestClient2("switchframe", t, func(c service.Client) {
+		eval := func(expr, value string) {
+			v, err := c.EvalVariable(api.EvalScope{-1, 0}, expr, normalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", expr))
+			if v.Value != value {
+				t.Fatalf("wrong value of %s: %s, expected %s", expr, v.Value, value)
+			}
+		}
+
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.callee", Line: 1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		eval("b", "2")
+
+		state, err = c.SwitchFrame(1)
+		assertNoError(err, t, "SwitchFrame(1)")
+		if state.SelectedFrame != 1 {
+			t.Fatalf("wrong selected frame %d", state.SelectedFrame)
+		}
+		eval("a", "1")
+		// scopes specifying the goroutine explicitly ignore the selected frame
+		v, err := c.EvalVariable(api.EvalScope{state.SelectedGoroutine.ID, 0}, "b", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(b) in the selected goroutine")
+		if v.Value != "2" {
+			t.Fatalf("wrong value of b in frame 0 of the selected goroutine: %s", v.Value)
+		}
+
+		_, err = c.SwitchFrame(100)
+		assertError(err, t, "SwitchFrame(100)")
+		_, err = c.SwitchFrame(-1)
+		assertError(err, t, "SwitchFrame(-1)")
+		eval("a", "1")
+
+		state, err = c.SwitchGoroutine(state.SelectedGoroutine.ID)
+		assertNoError(err, t, "SwitchGoroutine()")
+		if state.SelectedFrame != 0 {
+			t.Fatalf("frame selection not reset by SwitchGoroutine: %d", state.SelectedFrame)
+		}
+		eval("b", "2")
+	})
+}
+
+func TestClientServer_ListThreadRegisters(t *testing.T) {
+	withTestClient2("continuetestprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.sayhi", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		all, err := c.ListThreadRegisters()
+		assertNoError(err, t, "ListThreadRegisters()")
+		if len(all) != len(state.Threads) {
+			t.Fatalf("registers of %d threads returned, expected %d", len(all), len(state.Threads))
+		}
+		for _, th := range state.Threads {
+			_, err := c.SwitchThread(th.ID)
+			assertNoError(err, t, "SwitchThread()")
+			regs, err := c.ListRegisterValues()
+			assertNoError(err, t, "ListRegisterValues()")
+			if !reflect.DeepEqual(all[th.ID], regs) {
+				t.Fatalf("registers of thread %d differ:\n%v\nListRegisters:\n%v", th.ID, all[th.ID], regs)
+			}
+		}
+	})
+}
+
+func TestClientServer_EvalAcrossGoroutines(t *testing.T) {
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		gs, _, err := c.ListGoroutines(0, 0)
+		assertNoError(err, t, "ListGoroutines()")
+
+		// i1 is a local variable of main.main, only visible in the selected
+		// goroutine
+		vars, err := c.EvalAcrossGoroutines("i1", normalLoadConfig)
+		assertNoError(err, t, "EvalAcrossGoroutines(i1)")
+		if len(vars) != 1 {
+			t.Fatalf("wrong number of values %d, expected 1: %v", len(vars), vars)
+		}
+		if v := vars[state.SelectedGoroutine.ID]; v == nil || v.Value != "1" {
+			t.Fatalf("wrong value in goroutine %d: %v", state.SelectedGoroutine.ID, v)
+		}
+
+		// package variables are visible in all goroutines
+		vars, err = c.EvalAcrossGoroutines("runtime.gomaxprocs", normalLoadConfig)
+		assertNoError(err, t, "EvalAcrossGoroutines(runtime.gomaxprocs)")
+		if len(vars) != len(gs) {
+			t.Fatalf("values for %d goroutines, expected %d", len(vars), len(gs))
+		}
+
+		// errors other than unknown names are reported per goroutine
+		vars, err = c.EvalAcrossGoroutines("*(*int)(0)", normalLoadConfig)
+		assertNoError(err, t, "EvalAcrossGoroutines(*(*int)(0))")
+		if len(vars) != len(gs) {
+			t.Fatalf("values for %d goroutines, expected %d", len(vars), len(gs))
+		}
+		for gid, v := range vars {
+			if v.Unreadable == "" {
+				t.Fatalf("no error reading address 0 in goroutine %d: %v", gid, v)
+			}
+		}
+
+		// pseudo-variables are evaluated in every goroutine
+		vars, err = c.EvalAcrossGoroutines("$ndefers", normalLoadConfig)
+		assertNoError(err, t, "EvalAcrossGoroutines($ndefers)")
+		if len(vars) != len(gs) {
+			t.Fatalf("values for %d goroutines, expected %d", len(vars), len(gs))
+		}
+
+		_, err = c.EvalAcrossGoroutines("i1 +", normalLoadConfig)
+		assertError(err, t, "EvalAcrossGoroutines(i1 +)")
+	})
+}
+
+func TestClientServer_UnboundedLoadConfig(t *testing.T) {
+	withTestClient2("longstrings", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		cfg := api.LoadConfig{false, 0, -1, -1, -1, ""}
+		long, err := c.EvalVariable(api.EvalScope{-1, 0}, "long", cfg)
+		assertNoError(err, t, "EvalVariable(long)")
+		if long.Len != 1<<20 || len(long.Value) != 1<<20 {
+			t.Fatalf("wrong length of long: %d (value %d bytes)", long.Len, len(long.Value))
+		}
+		if long.Value != strings.Repeat("0123456789abcdef", 1<<16) {
+			t.Fatalf("wrong value of long")
+		}
+
+		ints, err := c.EvalVariable(api.EvalScope{-1, 0}, "ints", cfg)
+		assertNoError(err, t, "EvalVariable(ints)")
+		if len(ints.Children) != 1000 || ints.Children[999].Value != "999" {
+			t.Fatalf("wrong children of ints: %d", len(ints.Children))
+		}
+
+		m, err := c.EvalVariable(api.EvalScope{-1, 0}, "m", cfg)
+		assertNoError(err, t, "EvalVariable(m)")
+		if len(m.Children) != 200 {
+			t.Fatalf("wrong number of children of m: %d", len(m.Children))
+		}
+
+		long, err = c.EvalVariable(api.EvalScope{-1, 0}, "long", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable(long)")
+		if len(long.Value) != normalLoadConfig.MaxStringLen {
+			t.Fatalf("long not truncated: %d bytes", len(long.Value))
+		}
+	})
+}
+
+func TestClientServer_DisassembleFunction(t *testing.T) {
+	withTestClient2("locationsprog2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		d1, err := c.DisassembleFunction(api.EvalScope{-1, 0}, "main.main", api.IntelFlavour)
+		assertNoError(err, t, "DisassembleFunction()")
+		d2, err := c.DisassemblePC(api.EvalScope{-1, 0}, state.CurrentThread.PC, api.IntelFlavour)
+		assertNoError(err, t, "DisassemblePC()")
+		if !reflect.DeepEqual(d1, d2) {
+			t.Logf("d1: %v", d1)
+			t.Logf("d2: %v", d2)
+			t.Fatal("mismatched disassembly of main.main")
+		}
+		haspc := false
+		for i := range d1 {
+			if d1[i].AtPC {
+				haspc = true
+			}
+		}
+		if !haspc {
+			t.Fatal("current PC not marked in the disassembly of main.main")
+		}
+
+		_, err = c.DisassembleFunction(api.EvalScope{-1, 0}, "main.nonexistent", api.IntelFlavour)
+		assertError(err, t, "DisassembleFunction(main.nonexistent)")
+	})
+}
+
+func TestClientServer_HaltStopReason(t *testing.T) {
+	// Halt is called while the process is running, stopped at a breakpoint
+	// or exited, each time the state returned by Halt must agree with the
+	// state returned by Continue or, if Halt was handled before Continue,
+	// with the previous one.
+	withTestClient2("timedloop", t, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.tick", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		rand.Seed(time.Now().Unix())
+		prev := ""
+		for i := 0; i < 1000; i++ {
+			statech := c.Continue()
+			time.Sleep(time.Duration(rand.Intn(60)) * time.Millisecond)
+			hstate, err := c.Halt()
+			assertNoError(err, t, "Halt()")
+			state := <-statech
+
+			switch state.StopReason {
+			case api.StopExited:
+				if !state.Exited {
+					t.Fatalf("exit reported but process not exited %#v", state)
+				}
+			case api.StopBreakpoint:
+				if state.CurrentThread == nil || state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+					t.Fatalf("breakpoint reported but not stopped at breakpoint %#v", state.CurrentThread)
+				}
+			case api.StopManual:
+				if state.CurrentThread != nil && state.CurrentThread.Breakpoint != nil {
+					t.Fatalf("manual stop reported but stopped at breakpoint %#v", state.CurrentThread.Breakpoint)
+				}
+			default:
+				t.Fatalf("wrong stop reason %q at iteration %d", state.StopReason, i)
+			}
+			if !state.Exited {
+				assertNoError(state.Err, t, "Continue()")
+			}
+			if hstate.StopReason != state.StopReason && hstate.StopReason != prev {
+				t.Fatalf("Halt returned stop reason %q, Continue %q, previous %q", hstate.StopReason, state.StopReason, prev)
+			}
+			if hstate.StopReason == api.StopExited && !hstate.Exited {
+				t.Fatalf("exit reported by Halt but process not exited %#v", hstate)
+			}
+			if state.Exited {
+				return
+			}
+			prev = state.StopReason
+		}
+		t.Fatal("process did not exit")
+	})
+}
+
+func TestClientServer_SignalPolicy(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("signal policies are only supported on linux")
+	}
+	const sigusr1 = 10
+	withTestClient2("signalprog", t, func(c service.Client) {
+		assertError(c.SetSignalPolicy(5, true, false), t, "SetSignalPolicy(SIGTRAP)")
+		assertNoError(c.SetSignalPolicy(sigusr1, true, true), t, "SetSignalPolicy(SIGUSR1)")
+
+		received := func(value string) {
+			v, err := c.EvalVariable(api.EvalScope{-1, 0}, "received", normalLoadConfig)
+			assertNoError(err, t, "EvalVariable(received)")
+			if v.Value != value {
+				t.Fatalf("wrong value of received %s, expected %s", v.Value, value)
+			}
+		}
+
+		// passed to the process
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.Signal != sigusr1 || state.StopReason != api.StopSignal {
+			t.Fatalf("not stopped by SIGUSR1: signal %d reason %q", state.Signal, state.StopReason)
+		}
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.Signal != 0 {
+			t.Fatalf("signal still reported %d", state.Signal)
+		}
+		received("true")
+
+		// discarded
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.Signal != sigusr1 {
+			t.Fatalf("not stopped by SIGUSR1: signal %d", state.Signal)
+		}
+		_, err := c.ContinueWithSignal(0)
+		assertNoError(err, t, "ContinueWithSignal(0)")
+		received("false")
+	})
+}
+
+func TestClientServer_ListSource(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 23})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		src, err := ioutil.ReadFile(fp)
+		assertNoError(err, t, "ReadFile()")
+		text := strings.Split(string(src), "\n")
+
+		lines, err := c.ListSource(fp, 23, 2)
+		assertNoError(err, t, "ListSource()")
+		if len(lines) != 5 {
+			t.Fatalf("wrong number of lines %d", len(lines))
+		}
+		for i, l := range lines {
+			if l.Line != 21+i || l.Text != text[l.Line-1] {
+				t.Fatalf("wrong line %d: %#v", 21+i, l)
+			}
+			if l.Current != (l.Line == 23) {
+				t.Fatalf("wrong current flag on line %d", l.Line)
+			}
+		}
+
+		lines, err = c.ListSource(fp, 1, 3)
+		assertNoError(err, t, "ListSource() at the start of the file")
+		if len(lines) != 4 || lines[0].Line != 1 {
+			t.Fatalf("wrong lines at the start of the file %#v", lines)
+		}
+
+		_, err = c.ListSource(fp, len(text)+10, 3)
+		assertError(err, t, "ListSource() past the end of the file")
+		_, err = c.ListSource(fp+".nonexistent", 1, 3)
+		assertError(err, t, "ListSource() of nonexistent file")
+	})
+}
+
+func TestClientServer_CreatePackageTracepoints(t *testing.T) {
+	withTestClient2("integrationprog", t, func(c service.Client) {
+		_, _, err := c.CreatePackageTracepoints(`^main\.`, 1)
+		assertError(err, t, "CreatePackageTracepoints() over the maximum")
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		for _, bp := range bps {
+			if bp.ID > 0 {
+				t.Fatalf("tracepoint created over the maximum: %#v", bp)
+			}
+		}
+
+		bps, skipped, err := c.CreatePackageTracepoints(`^main\.(main|sayhi)$`, 0)
+		assertNoError(err, t, "CreatePackageTracepoints()")
+		if len(bps) != 2 || len(skipped) != 0 {
+			t.Fatalf("wrong number of tracepoints %d, skipped %v", len(bps), skipped)
+		}
+		for _, bp := range bps {
+			if !bp.Tracepoint || bp.Group != `trace ^main\.(main|sayhi)$` || bp.Stacktrace != 0 || len(bp.Variables) != 0 {
+				t.Fatalf("wrong tracepoint %#v", bp)
+			}
+		}
+
+		hits := map[string]int{}
+		for state := range c.Continue() {
+			if state.Err != nil {
+				if !state.Exited {
+					t.Fatalf("Continue(): %v", state.Err)
+				}
+				break
+			}
+			for _, th := range state.Threads {
+				if th.Breakpoint != nil {
+					hits[th.Breakpoint.FunctionName]++
+				}
+			}
+		}
+		if hits["main.main"] != 1 || hits["main.sayhi"] != 3 {
+			t.Fatalf("wrong tracepoint hits %v", hits)
+		}
+	})
+}
+
+func TestClientServer_FindLocationVerbose(t *testing.T) {
+	withTestClient2("testnextprog", t, func(c service.Client) {
+		fp := testProgPath(t, "testnextprog")
+		src, err := ioutil.ReadFile(fp)
+		assertNoError(err, t, "ReadFile()")
+		text := strings.Split(string(src), "\n")
+
+		locs, err := c.FindLocation(api.EvalScope{-1, 0}, "testnextprog.go:23")
+		assertNoError(err, t, "FindLocation()")
+		if len(locs) != 1 || locs[0].Source != "" {
+			t.Fatalf("wrong locations %#v", locs)
+		}
+
+		locs, err = c.FindLocationVerbose(api.EvalScope{-1, 0}, "testnextprog.go:23")
+		assertNoError(err, t, "FindLocationVerbose()")
+		if len(locs) != 1 {
+			t.Fatalf("wrong number of locations %d", len(locs))
+		}
+		if locs[0].Source != text[22] || locs[0].Function == nil || locs[0].Function.Name != "main.testnext" {
+			t.Fatalf("wrong location %#v", locs[0])
+		}
+
+		locs, err = c.FindLocationVerbose(api.EvalScope{-1, 0}, "main.helloworld")
+		assertNoError(err, t, "FindLocationVerbose(function)")
+		if len(locs) != 1 || locs[0].Source != text[locs[0].Line-1] {
+			t.Fatalf("wrong location %#v", locs)
+		}
+	})
+}
+
+func TestClientServer_BreakpointGoroutineID(t *testing.T) {
+	withTestClient2("goroutinestackprog", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.stacktraceme", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint(main.stacktraceme)")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		gs, _, err := c.ListGoroutines(0, 0)
+		assertNoError(err, t, "ListGoroutines()")
+		gid := 0
+		for _, g := range gs {
+			if g.UserCurrentLoc.Function != nil && g.UserCurrentLoc.Function.Name == "main.agoroutine" {
+				gid = g.ID
+			}
+		}
+		if gid == 0 {
+			t.Fatalf("could not find a goroutine running main.agoroutine")
+		}
+
+		fp := testProgPath(t, "goroutinestackprog")
+		_, err = c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 10, GoroutineID: -1})
+		assertError(err, t, "CreateBreakpoint() with negative goroutine ID")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 10, GoroutineID: gid + 1000})
+		assertNoError(err, t, "CreateBreakpoint()")
+		bp.GoroutineID = gid
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint()")
+
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Breakpoint == nil || state.CurrentThread.Breakpoint.ID != bp.ID {
+			t.Fatalf("did not stop at breakpoint %#v", state.CurrentThread.Breakpoint)
+		}
+		if state.SelectedGoroutine.ID != gid || state.CurrentThread.Breakpoint.TotalHitCount != 1 {
+			t.Fatalf("stopped on goroutine %d after %d hits, expected %d", state.SelectedGoroutine.ID, state.CurrentThread.Breakpoint.TotalHitCount, gid)
+		}
+		if state.CurrentThread.Breakpoint.GoroutineID != gid {
+			t.Fatalf("goroutine ID not amended %d", state.CurrentThread.Breakpoint.GoroutineID)
+		}
+
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread.Breakpoint != nil && state.CurrentThread.Breakpoint.ID == bp.ID {
+			t.Fatalf("stopped again on breakpoint on goroutine %d", state.SelectedGoroutine.ID)
+		}
+	})
+}
+
+func TestClientServer_BreakpointThreadID(t *testing.T) {
+	withTestClient2("integrationprog", t, func(c service.Client) {
+		fp := testProgPath(t, "integrationprog")
+		_, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15, ThreadID: -1})
+		assertError(err, t, "CreateBreakpoint() with negative thread ID")
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{File: fp, Line: 15})
+		assertNoError(err, t, "CreateBreakpoint()")
+
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		tid := state.CurrentThread.ID
+
+		// no thread of the process has the ID of init
+		bp.ThreadID = 1
+		bp.Cond = "i >= 1"
+		assertNoError(c.AmendBreakpoint(bp), t, "AmendBreakpoint()")
+		bp, err = c.GetBreakpoint(bp.ID)
+		assertNoError(err, t, "GetBreakpoint()")
+		if bp.ThreadID != 1 {
+			t.Fatalf("thread ID not amended %d", bp.ThreadID)
+		}
+
+		state = <-c.Continue()
+		if !state.Exited {
+			t.Fatalf("stopped on thread %d instead of exiting (first stop on %d)", state.CurrentThread.ID, tid)
+		}
+	})
+}
+
+func TestClientServer_DefaultLoadConfig(t *testing.T) {
+	withTestClient2("testvariables2", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		cfg, err := c.GetLoadConfig()
+		assertNoError(err, t, "GetLoadConfig()")
+		if cfg != normalLoadConfig {
+			t.Fatalf("wrong initial default load configuration %#v", cfg)
+		}
+
+		assertError(c.SetLoadConfig(api.LoadConfig{}), t, "SetLoadConfig() with zero configuration")
+		assertError(c.SetLoadConfig(api.LoadConfig{true, 1, 64, 2, -1, "octal"}), t, "SetLoadConfig() with unknown format")
+		assertNoError(c.SetLoadConfig(api.LoadConfig{true, 1, 64, 2, -1, api.FormatHex}), t, "SetLoadConfig()")
+		cfg, err = c.GetLoadConfig()
+		assertNoError(err, t, "GetLoadConfig()")
+		if cfg.MaxArrayValues != 2 || cfg.Format != api.FormatHex {
+			t.Fatalf("default load configuration not changed %#v", cfg)
+		}
+
+		v, err := c.EvalVariable(api.EvalScope{-1, 0}, "s1", api.LoadConfig{})
+		assertNoError(err, t, "EvalVariable() with zero configuration")
+		if len(v.Children) != 2 {
+			t.Fatalf("default load configuration not used, %d elements loaded", len(v.Children))
+		}
+		v, err = c.EvalVariable(api.EvalScope{-1, 0}, "i1", api.LoadConfig{})
+		assertNoError(err, t, "EvalVariable(i1)")
+		if v.Value != "0x1" {
+			t.Fatalf("default format not used %q", v.Value)
+		}
+
+		v, err = c.EvalVariable(api.EvalScope{-1, 0}, "s1", normalLoadConfig)
+		assertNoError(err, t, "EvalVariable() with explicit configuration")
+		if len(v.Children) != 5 {
+			t.Fatalf("explicit load configuration not used, %d elements loaded", len(v.Children))
+		}
+	})
+}
+
+func TestClientServer_InlinedBreakpoints(t *testing.T) {
+	withTestClient2Extended("testinline", t, protest.EnableInlining, func(c service.Client) {
+		bp, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.inlineThis", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		if len(bp.InlinedCallSites) != 2 {
+			t.Fatalf("expected 2 inlined call sites, got %#v", bp.InlinedCallSites)
+		}
+
+		bps, err := c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		ids := map[int]bool{}
+		for _, lbp := range bps {
+			if lbp.FunctionName == "main.main" {
+				ids[lbp.ID] = true
+			}
+			if lbp.ID == bp.ID && len(lbp.InlinedCallSites) != 2 {
+				t.Fatalf("inlined call sites not listed: %#v", lbp)
+			}
+		}
+		if len(ids) != 2 {
+			t.Fatalf("expected 2 breakpoints in main.main, got %d", len(ids))
+		}
+
+		for i := 0; i < 2; i++ {
+			state := <-c.Continue()
+			assertNoError(state.Err, t, "Continue()")
+			if state.CurrentThread.Breakpoint == nil || !ids[state.CurrentThread.Breakpoint.ID] {
+				t.Fatalf("not stopped at an inlined call site: %#v", state.CurrentThread)
+			}
+		}
+
+		_, err = c.ClearBreakpoint(bp.ID)
+		assertNoError(err, t, "ClearBreakpoint()")
+		bps, err = c.ListBreakpoints()
+		assertNoError(err, t, "ListBreakpoints()")
+		for _, lbp := range bps {
+			if ids[lbp.ID] {
+				t.Fatalf("inlined breakpoint %d not cleared", lbp.ID)
+			}
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/derekparker/delve/proc"
 	"github.com/derekparker/delve/service/api"
@@ -447,6 +448,10 @@ func TestEvalExpression(t *testing.T) {
 		{"str1[3:]", false, "\"34567890\"", "\"34567890\"", "string", nil},
 		{"str1[0:12]", false, "", "", "string", fmt.Errorf("index out of bounds")},
 		{"str1[5:3]", false, "", "", "string", fmt.Errorf("index out of bounds")},
+		{"str1[11:]", false, "\"\"", "\"\"", "string", nil},
+		{"s1[5:]", false, "[]string len: 0, cap: 0, []", "[]string len: 0, cap: 0, []", "[]string", nil},
+		{"s1[6:]", false, "", "", "[]string", fmt.Errorf("index out of bounds")},
+		{"s1[-1:2]", false, "", "", "[]string", fmt.Errorf("index out of bounds")},
 
 		// pointers
 		{"*p2", false, "5", "5", "int", nil},
@@ -484,6 +489,8 @@ func TestEvalExpression(t *testing.T) {
 		{"err1.(*main.astruct)", false, "*main.astruct {A: 1, B: 2}", "(*main.astruct)(0x…", "*main.astruct", nil},
 		{"err1.(*main.bstruct)", false, "", "", "", fmt.Errorf("interface conversion: error is *main.astruct, not *main.bstruct")},
 		{"errnil.(*main.astruct)", false, "", "", "", fmt.Errorf("interface conversion: error is nil, not *main.astruct")},
+		{"iface2.(string)", false, "\"test\"", "\"test\"", "string", nil},
+		{"iface2.(int)", false, "", "", "", fmt.Errorf("interface conversion: interface {} is string, not int")},
 		{"const1", true, "go/constant.Value(*go/constant.int64Val) *3", "go/constant.Value(*go/constant.int64Val) 0x…", "go/constant.Value", nil},
 
 		// combined expressions
@@ -557,6 +564,19 @@ func TestEvalExpression(t *testing.T) {
 		{"imag(3i)", false, "3", "3", "", nil},
 		{"real(4)", false, "4", "4", "", nil},
 
+		// byte reinterpretation
+		{"binary.BigEndian.Uint64(piBytes)", false, "4614256656552045848", "4614256656552045848", "uint64", nil},
+		{"binary.LittleEndian.Uint16(piBytes)", false, "2368", "2368", "uint16", nil},
+		{"binary.BigEndian.Uint32(piBytes[4:])", false, "1413754136", "1413754136", "uint32", nil},
+		{"math.Float64frombits(binary.BigEndian.Uint64(piBytes))", false, "3.141592653589793", "3.141592653589793", "float64", nil},
+		{"binary.BigEndian.Uint64(piBytes[1:])", false, "", "", "", fmt.Errorf("piBytes[1:] is too short for binary.BigEndian.Uint64: 7 bytes")},
+		{"binary.BigEndian.Uint16(i1)", false, "", "", "", fmt.Errorf("invalid argument i1 (type int) to binary.BigEndian.Uint16")},
+
+		// constant names
+		{"st1", false, "StatusActive (1)", "StatusActive (1)", "main.Status", nil},
+		{"st2", false, "StatusRunning/StatusStarted (2)", "StatusRunning/StatusStarted (2)", "main.Status", nil},
+		{"st3", false, "7", "7", "main.Status", nil},
+
 		// nil
 		{"nil", false, "nil", "nil", "", nil},
 		{"nil+1", false, "", "", "", fmt.Errorf("operator + can not be applied to \"nil\"")},
@@ -617,6 +637,11 @@ func TestEvalExpression(t *testing.T) {
 		{"uint8(i5)", false, "253", "253", "uint8", nil},
 		{"int8(i5)", false, "-3", "-3", "int8", nil},
 		{"int8(i6)", false, "12", "12", "int8", nil},
+		{"([]byte)(str1)", false, "[]uint8 len: 11, cap: 11, [48,49,50,51,52,53,54,55,56,57,48]", "[]uint8 len: 11, cap: 11, [...]", "[]uint8", nil},
+		{"([]int)(parr)", false, "[]int len: 4, cap: 4, [0,1,2,3]", "[]int len: 4, cap: 4, [...]", "[]int", nil},
+		{"([]string)(parr)", false, "", "", "", fmt.Errorf("can not convert \"parr\" to []string")},
+		{"(*int)(ni8)", false, "", "", "", fmt.Errorf("can not convert \"ni8\" to *int: int8 is smaller than a pointer")},
+		{"(*main.nonexistent)(i2)", false, "", "", "", fmt.Errorf("no type entry found, use 'types' for a list of valid types")},
 
 		// misc
 		{"i1", true, "1", "1", "int", nil},
@@ -828,3 +853,148 @@ func TestPackageRenames(t *testing.T) {
 		}
 	})
 }
+
+func TestSetVariableComposite(t *testing.T) {
+	withTestProcess("testvariables2", t, func(p *proc.Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		h := func(lhs, rhs, expr, value string) {
+			assertNoError(setVariable(p, lhs, rhs), t, fmt.Sprintf("SetVariable(%s, %s)", lhs, rhs))
+			variable, err := evalVariable(p, expr, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", expr))
+			if s := api.ConvertVar(variable).SinglelineString(); s != value {
+				t.Fatalf("Wrong value of %s: %q, expected %q after setting %s to %s", expr, s, value, lhs, rhs)
+			}
+		}
+
+		h("s2[2].A", "10", "s2[2].A", "10")
+		h("*p1", "3", "i1", "3")
+		h("a1[1]", "a1[0]", "a1[1]", "\"one\"")
+		h("as1", "s2[2]", "as1", "main.astruct {A: 10, B: 6}")
+		h("p3", "p1", "*p3", "3")
+		h("p3", "nil", "p3", "*int nil")
+
+		for _, tc := range []struct{ lhs, rhs string }{
+			{"i1 + 1", "2"},
+			{"m1[\"Malone\"]", "as1"},
+			{"m1[\"Malone\"].A", "1"},
+			{"as1", "i1"},
+			{"str1", "\"abc\""},
+		} {
+			if err := setVariable(p, tc.lhs, tc.rhs); err == nil {
+				t.Fatalf("SetVariable(%s, %s) did not return an error", tc.lhs, tc.rhs)
+			}
+		}
+	})
+}
+
+func TestCallFunction(t *testing.T) {
+	withTestProcess("fncall", t, func(p *proc.Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		if err := p.FunctionCallsSupported(); err != nil {
+			t.Skip(err)
+		}
+
+		for _, tc := range []struct{ expr, value string }{
+			{"main.fib(10)", "55"},
+			{"fib(n)", "13"},
+			{"fib(3) + fib(4)", "5"},
+			{"square(2.5)", "6.25"},
+			{"deref(pn)", "7"},
+			{"deref(&n)", "7"},
+			{"a.Double()", "6"},
+			{"pa.Double()", "6"},
+			{"a.Inc(2)", "5"},
+			{"a.X", "5"},
+			{"greet(\"x\")", ""},
+			{"mustBePositive(-1)", ""},
+			{"fib(1, 2)", ""},
+			{"fib(\"a\")", ""},
+		} {
+			variable, err := evalVariable(p, tc.expr, pnormalLoadConfig)
+			if tc.value == "" {
+				if err == nil {
+					t.Fatalf("EvalVariable(%s) did not return an error", tc.expr)
+				}
+				continue
+			}
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.expr))
+			if s := api.ConvertVar(variable).SinglelineString(); s != tc.value {
+				t.Fatalf("Wrong value of %s: %q, expected %q", tc.expr, s, tc.value)
+			}
+		}
+	})
+}
+
+func TestCallFunctionInterrupted(t *testing.T) {
+	withTestProcess("fncall", t, func(p *proc.Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+
+		if err := p.FunctionCallsSupported(); err != nil {
+			t.Skip(err)
+		}
+
+		evalWithDeadline := func(expr string, timeout time.Duration) error {
+			scope, err := p.CurrentThread.Scope()
+			assertNoError(err, t, "Scope()")
+			scope.Deadline = time.Now().Add(timeout)
+			_, err = scope.EvalVariable(expr, pnormalLoadConfig)
+			return err
+		}
+
+		// the deadline interrupts a call that never returns
+		start := time.Now()
+		err := evalWithDeadline("spin()", 200*time.Millisecond)
+		if err == nil || !strings.Contains(err.Error(), "interrupted") {
+			t.Fatalf("spin() was not interrupted: %v", err)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("interrupting spin() took %v", time.Since(start))
+		}
+		_, err = evalVariable(p, "fib(1)", pnormalLoadConfig)
+		if err == nil || !strings.Contains(err.Error(), "has not completed") {
+			t.Fatalf("call allowed while spin() is still running: %v", err)
+		}
+	})
+
+	withTestProcess("fncall", t, func(p *proc.Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		if err := p.FunctionCallsSupported(); err != nil {
+			t.Skip(err)
+		}
+
+		scope, err := p.CurrentThread.Scope()
+		assertNoError(err, t, "Scope()")
+		scope.Deadline = time.Now().Add(50 * time.Millisecond)
+		_, err = scope.EvalVariable("nap(napTime)", pnormalLoadConfig)
+		if err == nil {
+			t.Skip("could not interrupt nap")
+		}
+
+		// the interrupted call completes when the process is resumed, the
+		// process must not stop inside the debug call protocol
+		err = p.Continue()
+		if !p.Exited() {
+			loc, _ := p.CurrentLocation()
+			t.Fatalf("process did not exit after the interrupted call completed: %v %#v", err, loc)
+		}
+	})
+}
+
+func TestConstantNames(t *testing.T) {
+	withTestProcess("testvariables2", t, func(p *proc.Process, fixture protest.Fixture) {
+		assertNoError(p.Continue(), t, "Continue()")
+		v, err := evalVariable(p, "st2", pnormalLoadConfig)
+		assertNoError(err, t, "EvalVariable(st2)")
+		cv := api.ConvertVar(v)
+		// the value must stay parseable, the names are only shown by the pretty printer
+		if cv.Value != "2" || cv.ConstName != "StatusRunning/StatusStarted" {
+			t.Fatalf("wrong value %q or constant name %q", cv.Value, cv.ConstName)
+		}
+		assertNoError(cv.ApplyFormat(api.FormatHex), t, "ApplyFormat()")
+		if s := cv.SinglelineString(); s != "StatusRunning/StatusStarted (0x2)" {
+			t.Fatalf("wrong formatted value %q", s)
+		}
+	})
+}
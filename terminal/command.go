@@ -63,8 +63,8 @@ type Commands struct {
 }
 
 var (
-	LongLoadConfig  = api.LoadConfig{true, 1, 64, 64, -1}
-	ShortLoadConfig = api.LoadConfig{false, 0, 64, 0, 3}
+	LongLoadConfig  = api.LoadConfig{true, 1, 64, 64, -1, ""}
+	ShortLoadConfig = api.LoadConfig{false, 0, 64, 0, 3, ""}
 )
 
 type ByFirstAlias []command
@@ -83,23 +83,29 @@ func DebugCommands(client service.Client) *Commands {
 	help [command]
 	
 Type "help" followed by the name of a command for more information about it.`},
-		{aliases: []string{"break", "b"}, cmdFn: breakpoint, helpMsg: `Sets a breakpoint.
+		{aliases: []string{"break", "b"}, allowedPrefixes: scopePrefix, cmdFn: breakpoint, helpMsg: `Sets a breakpoint.
 
-	break [name] <linespec>
+	[goroutine <n>] [frame <m>] break [-anchor] [name] <linespec>
 
-See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/locspec.md for the syntax of linespec.
+See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/locspec.md for the syntax of linespec. Relative line numbers are interpreted relative to the specified frame.
+
+With -anchor the breakpoint is remembered as a statement of its function rather than as a line: when the process is restarted after being rebuilt the breakpoint is moved to the same statement even if its line number changed.
 
 See also: "help on", "help cond" and "help clear"`},
-		{aliases: []string{"trace", "t"}, cmdFn: tracepoint, helpMsg: `Set tracepoint.
+		{aliases: []string{"trace", "t"}, allowedPrefixes: scopePrefix, cmdFn: tracepoint, helpMsg: `Set tracepoint.
 
-	trace [name] <linespec>
+	[goroutine <n>] [frame <m>] trace [-anchor] [name] <linespec>
 	
-A tracepoint is a breakpoint that does not stop the execution of the program, instead when the tracepoint is hit a notification is displayed. See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/locspec.md for the syntax of linespec.
+A tracepoint is a breakpoint that does not stop the execution of the program, instead when the tracepoint is hit a notification is displayed. See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/locspec.md for the syntax of linespec. See "help break" for the meaning of -anchor.
 
 See also: "help on", "help cond" and "help clear"`},
 		{aliases: []string{"restart", "r"}, cmdFn: restart, helpMsg: "Restart process."},
 		{aliases: []string{"continue", "c"}, cmdFn: cont, helpMsg: "Run until breakpoint or program termination."},
-		{aliases: []string{"step", "s"}, allowedPrefixes: scopePrefix, cmdFn: step, helpMsg: "Single step through program."},
+		{aliases: []string{"step", "s"}, allowedPrefixes: scopePrefix, cmdFn: step, helpMsg: `Single step through program.
+
+	step [function]
+
+If a function is specified only calls to it made by the current line are entered, other calls are stepped over.`},
 		{aliases: []string{"step-instruction", "si"}, allowedPrefixes: scopePrefix, cmdFn: stepInstruction, helpMsg: "Single step a single cpu instruction."},
 		{aliases: []string{"next", "n"}, allowedPrefixes: scopePrefix, cmdFn: next, helpMsg: "Step over to next source line."},
 		{aliases: []string{"stepout"}, allowedPrefixes: scopePrefix, cmdFn: stepout, helpMsg: "Step out of the current function."},
@@ -117,7 +123,7 @@ See also: "help on", "help cond" and "help clear"`},
 If called with the linespec argument it will delete all the breakpoints matching the linespec. If linespec is omitted all breakpoints are deleted.`},
 		{aliases: []string{"goroutines"}, cmdFn: goroutines, helpMsg: `List program goroutines.
 
-	goroutines [-u (default: user location)|-r (runtime location)|-g (go statement location)]
+	goroutines [-u (default: user location)|-r (runtime location)|-g (go statement location)] [-s <status>] [-f <regex>] [-l <key>[=<value>]]
 
 Print out info for every goroutine. The flag controls what information is shown along with each goroutine:
 
@@ -125,7 +131,13 @@ Print out info for every goroutine. The flag controls what information is shown
 	-r	displays location of topmost stackframe (including frames inside private runtime functions)
 	-g	displays location of go instruction that created the goroutine
 	
-If no flag is specified the default is -u.`},
+If no flag is specified the default is -u.
+
+The list can be filtered with:
+
+	-s <status>	only goroutines with the given status (running, runnable, waiting, syscall, ...)
+	-f <regex>	only goroutines whose topmost stackframe is in a function matching regex
+	-l <key>[=<value>]	only goroutines with the given pprof label`},
 		{aliases: []string{"goroutine"}, allowedPrefixes: onPrefix | scopePrefix, cmdFn: c.goroutine, helpMsg: `Shows or changes current goroutine
 
 	goroutine
@@ -138,9 +150,11 @@ Called with more arguments it will execute a command on the specified goroutine.
 		{aliases: []string{"breakpoints", "bp"}, cmdFn: breakpoints, helpMsg: "Print out info for active breakpoints."},
 		{aliases: []string{"print", "p"}, allowedPrefixes: onPrefix | scopePrefix, cmdFn: printVar, helpMsg: `Evaluate an expression.
 
-	[goroutine <n>] [frame <m>] print <expression>
+	[goroutine <n>] [frame <m>] print [%x|%b|%c|%e] <expression>
+
+See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/expr.md for a description of supported expressions.
 
-See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/expr.md for a description of supported expressions.`},
+The optional format prints integers in hexadecimal (%x), in binary (%b) or as characters (%c), or floating point numbers in scientific notation (%e).`},
 		{aliases: []string{"set"}, allowedPrefixes: scopePrefix, cmdFn: setVar, helpMsg: `Changes the value of a variable.
 
 	[goroutine <n>] [frame <m>] set <variable> = <value>
@@ -176,7 +190,9 @@ If regex is specified only local variables with a name matching it will be retur
 	vars [-v] [<regex>]
 
 If regex is specified only package variables with a name matching it will be returned. If -v is specified more information about each package variable will be shown.`},
-		{aliases: []string{"regs"}, cmdFn: regs, helpMsg: "Print contents of CPU registers."},
+		{aliases: []string{"regs"}, cmdFn: regs, helpMsg: `Print contents of CPU registers.
+
+Registers that changed since the program was last resumed are marked with an asterisk.`},
 		{aliases: []string{"exit", "quit", "q"}, cmdFn: exitCommand, helpMsg: "Exit the debugger."},
 		{aliases: []string{"list", "ls"}, allowedPrefixes: scopePrefix, cmdFn: listCommand, helpMsg: `Show source code.
 
@@ -210,8 +226,23 @@ Supported commands: print, stack and goroutine)`},
 		{aliases: []string{"condition", "cond"}, cmdFn: conditionCmd, helpMsg: `Set breakpoint condition.
 
 	condition <breakpoint name or id> <boolean expression>.
+	condition -hitcount <breakpoint name or id> <operator> <argument>
 	
-Specifies that the breakpoint or tracepoint should break only if the boolean expression is true.`},
+Specifies that the breakpoint or tracepoint should break only if the boolean expression is true.
+
+With -hitcount the breakpoint will only break when its hit count satisfies the condition, for example "condition -hitcount 1 >= 5" or "condition -hitcount 1 % 2 == 0". An empty condition removes it.`},
+		{aliases: []string{"ignore"}, cmdFn: ignoreCmd, helpMsg: `Ignore a breakpoint a number of times.
+
+	ignore <breakpoint name or id> <count>
+
+The next <count> times the breakpoint or tracepoint is reached execution will continue without stopping. The breakpoint hit counts are still updated.`},
+		{aliases: []string{"examinemem", "x"}, cmdFn: examineMemCmd, helpMsg: `Examine memory.
+
+	examinemem [-p] [-size <n>] <address> [<count>]
+
+Prints <count> words (8 by default) of <n> bytes (8 by default) starting at <address>.
+
+	-p		also shows the function, package variable or heap memory each word points to`},
 	}
 
 	sort.Sort(ByFirstAlias(c.cmds))
@@ -339,6 +370,9 @@ func threads(t *Term, ctx callContext, args string) error {
 		if state.CurrentThread != nil && state.CurrentThread.ID == th.ID {
 			prefix = "* "
 		}
+		if th.Name != "" {
+			prefix += fmt.Sprintf("[%s] ", th.Name)
+		}
 		if th.Function != nil {
 			fmt.Printf("%sThread %d at %#v %s:%d %s\n",
 				prefix, th.ID, th.PC, ShortenFilePath(th.File),
@@ -386,33 +420,40 @@ func (a byGoroutineID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a byGoroutineID) Less(i, j int) bool { return a[i].ID < a[j].ID }
 
 func goroutines(t *Term, ctx callContext, argstr string) error {
-	args := strings.Split(argstr, " ")
+	args := strings.Fields(argstr)
 	var fgl = fglUserCurrent
+	var filter api.GoroutineFilter
 
-	switch len(args) {
-	case 0:
-		// nothing to do
-	case 1:
-		switch args[0] {
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
 		case "-u":
 			fgl = fglUserCurrent
 		case "-r":
 			fgl = fglRuntimeCurrent
 		case "-g":
 			fgl = fglGo
-		case "":
-			// nothing to do
+		case "-s", "-f", "-l":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires an argument", args[i])
+			}
+			switch args[i] {
+			case "-s":
+				filter.Status = args[i+1]
+			case "-f":
+				filter.Function = args[i+1]
+			case "-l":
+				filter.Label = args[i+1]
+			}
+			i++
 		default:
-			return fmt.Errorf("wrong argument: '%s'", args[0])
+			return fmt.Errorf("wrong argument: '%s'", args[i])
 		}
-	default:
-		return fmt.Errorf("too many arguments")
 	}
 	state, err := t.client.GetState()
 	if err != nil {
 		return err
 	}
-	gs, err := t.client.ListGoroutines()
+	gs, _, err := t.client.ListGoroutinesWithFilter(filter, 0, 0)
 	if err != nil {
 		return err
 	}
@@ -561,6 +602,18 @@ func writeGoroutineLong(w io.Writer, g *api.Goroutine, prefix string) {
 		prefix, formatLocation(g.CurrentLoc),
 		prefix, formatLocation(g.UserCurrentLoc),
 		prefix, formatLocation(g.GoStatementLoc))
+	if len(g.Labels) > 0 {
+		keys := make([]string, 0, len(g.Labels))
+		for k := range g.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		labels := make([]string, len(keys))
+		for i, k := range keys {
+			labels[i] = fmt.Sprintf("%s=%q", k, g.Labels[k])
+		}
+		fmt.Fprintf(w, "%s\tLabels: %s\n", prefix, strings.Join(labels, ", "))
+	}
 }
 
 func restart(t *Term, ctx callContext, args string) error {
@@ -575,6 +628,7 @@ func cont(t *Term, ctx callContext, args string) error {
 	stateChan := t.client.Continue()
 	var state *api.DebuggerState
 	for state = range stateChan {
+		printTracepointResults(state)
 		if state.Err != nil {
 			return state.Err
 		}
@@ -584,6 +638,12 @@ func cont(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func printTracepointResults(state *api.DebuggerState) {
+	for _, r := range state.TracepointResults {
+		fmt.Printf("> [%d] %s:%d (goroutine %d): %s\n", r.BreakpointID, ShortenFilePath(r.File), r.Line, r.GoroutineID, r.Message)
+	}
+}
+
 func continueUntilCompleteNext(t *Term, state *api.DebuggerState, op string) error {
 	if !state.NextInProgress {
 		printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
@@ -626,7 +686,13 @@ func step(t *Term, ctx callContext, args string) error {
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
 	}
-	state, err := t.client.Step()
+	var state *api.DebuggerState
+	var err error
+	if args = strings.TrimSpace(args); args != "" {
+		state, err = t.client.StepInto(api.EvalScope{-1, 0}, args)
+	} else {
+		state, err = t.client.Step()
+	}
 	if err != nil {
 		return err
 	}
@@ -747,6 +813,12 @@ func breakpoints(t *Term, ctx callContext, args string) error {
 		if bp.Cond != "" {
 			attrs = append(attrs, fmt.Sprintf("\tcond %s", bp.Cond))
 		}
+		if bp.Ignore > 0 {
+			attrs = append(attrs, fmt.Sprintf("\tignore %d", bp.Ignore))
+		}
+		if bp.HitCond != "" {
+			attrs = append(attrs, fmt.Sprintf("\tcondition -hitcount %s", bp.HitCond))
+		}
 		if bp.Stacktrace > 0 {
 			attrs = append(attrs, fmt.Sprintf("\tstack %d", bp.Stacktrace))
 		}
@@ -777,10 +849,14 @@ func breakpoints(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
-func setBreakpoint(t *Term, tracepoint bool, argstr string) error {
+func setBreakpoint(t *Term, ctx callContext, tracepoint bool, argstr string) error {
+	requestedBp := &api.Breakpoint{}
+	if v := strings.SplitN(argstr, " ", 2); len(v) == 2 && v[0] == "-anchor" {
+		requestedBp.Anchored = true
+		argstr = strings.TrimSpace(v[1])
+	}
 	args := strings.SplitN(argstr, " ", 2)
 
-	requestedBp := &api.Breakpoint{}
 	locspec := ""
 	switch len(args) {
 	case 1:
@@ -797,7 +873,7 @@ func setBreakpoint(t *Term, tracepoint bool, argstr string) error {
 	}
 
 	requestedBp.Tracepoint = tracepoint
-	locs, err := t.client.FindLocation(api.EvalScope{GoroutineID: -1, Frame: 0}, locspec)
+	locs, err := t.client.FindLocation(ctx.Scope, locspec)
 	if err != nil {
 		if requestedBp.Name == "" {
 			return err
@@ -805,7 +881,7 @@ func setBreakpoint(t *Term, tracepoint bool, argstr string) error {
 		requestedBp.Name = ""
 		locspec = argstr
 		var err2 error
-		locs, err2 = t.client.FindLocation(api.EvalScope{GoroutineID: -1, Frame: 0}, locspec)
+		locs, err2 = t.client.FindLocation(ctx.Scope, locspec)
 		if err2 != nil {
 			return err
 		}
@@ -824,22 +900,47 @@ func setBreakpoint(t *Term, tracepoint bool, argstr string) error {
 }
 
 func breakpoint(t *Term, ctx callContext, args string) error {
-	return setBreakpoint(t, false, args)
+	return setBreakpoint(t, ctx, false, args)
 }
 
 func tracepoint(t *Term, ctx callContext, args string) error {
-	return setBreakpoint(t, true, args)
+	return setBreakpoint(t, ctx, true, args)
+}
+
+// printFormats maps the format arguments of the print command to
+// api.LoadConfig formats.
+var printFormats = map[string]string{
+	"%x": api.FormatHex,
+	"%b": api.FormatBinary,
+	"%c": api.FormatChar,
+	"%e": api.FormatScientific,
 }
 
 func printVar(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
 	}
+	cfg := LongLoadConfig
+	if args[0] == '%' {
+		v := strings.SplitN(args, " ", 2)
+		format, ok := printFormats[v[0]]
+		if !ok {
+			return fmt.Errorf("unknown format %s", v[0])
+		}
+		if len(v) < 2 || strings.TrimSpace(v[1]) == "" {
+			return fmt.Errorf("not enough arguments")
+		}
+		if ctx.Prefix == onPrefix {
+			return fmt.Errorf("formats are not supported by the on command")
+		}
+		cfg.Format = format
+		args = strings.TrimSpace(v[1])
+	}
 	if ctx.Prefix == onPrefix {
 		ctx.Breakpoint.Variables = append(ctx.Breakpoint.Variables, args)
 		return nil
 	}
-	val, err := t.client.EvalVariable(ctx.Scope, args, LongLoadConfig)
+	val, err := t.client.EvalVariable(ctx.Scope, args, cfg)
 	if err != nil {
 		return err
 	}
@@ -930,7 +1031,7 @@ func args(t *Term, ctx callContext, args string) error {
 		ctx.Breakpoint.LoadArgs = &cfg
 		return nil
 	}
-	vars, err := t.client.ListFunctionArgs(ctx.Scope, cfg)
+	vars, err := t.client.ListFunctionArgs(ctx.Scope, filter, cfg)
 	if err != nil {
 		return err
 	}
@@ -946,7 +1047,7 @@ func locals(t *Term, ctx callContext, args string) error {
 		ctx.Breakpoint.LoadLocals = &cfg
 		return nil
 	}
-	locals, err := t.client.ListLocalVariables(ctx.Scope, cfg)
+	locals, err := t.client.ListLocalVariables(ctx.Scope, filter, cfg)
 	if err != nil {
 		return err
 	}
@@ -963,7 +1064,7 @@ func vars(t *Term, ctx callContext, args string) error {
 }
 
 func regs(t *Term, ctx callContext, args string) error {
-	regs, err := t.client.ListRegisters()
+	regs, err := t.client.ListRegisterValues()
 	if err != nil {
 		return err
 	}
@@ -1226,6 +1327,10 @@ func printcontextThread(t *Term, th *api.Thread) {
 			writeGoroutineLong(os.Stdout, bpi.Goroutine, "\t")
 		}
 
+		if bpi.LogMessage != "" {
+			fmt.Printf("\t%s\n", bpi.LogMessage)
+		}
+
 		for _, v := range bpi.Variables {
 			fmt.Printf("\t%s: %s\n", v.Name, v.MultilineString("\t"))
 		}
@@ -1337,6 +1442,22 @@ func (c *Commands) onCmd(t *Term, ctx callContext, argstr string) error {
 func conditionCmd(t *Term, ctx callContext, argstr string) error {
 	args := strings.SplitN(argstr, " ", 2)
 
+	if len(args) >= 1 && args[0] == "-hitcount" {
+		if len(args) < 2 {
+			return fmt.Errorf("not enough arguments")
+		}
+		args = strings.SplitN(args[1], " ", 2)
+		bp, err := getBreakpointByIDOrName(t, args[0])
+		if err != nil {
+			return err
+		}
+		bp.HitCond = ""
+		if len(args) > 1 {
+			bp.HitCond = strings.TrimSpace(args[1])
+		}
+		return t.client.AmendBreakpoint(bp)
+	}
+
 	if len(args) < 2 {
 		return fmt.Errorf("not enough arguments")
 	}
@@ -1350,6 +1471,80 @@ func conditionCmd(t *Term, ctx callContext, argstr string) error {
 	return t.client.AmendBreakpoint(bp)
 }
 
+func ignoreCmd(t *Term, ctx callContext, argstr string) error {
+	args := strings.SplitN(argstr, " ", 2)
+
+	if len(args) < 2 {
+		return fmt.Errorf("not enough arguments")
+	}
+
+	bp, err := getBreakpointByIDOrName(t, args[0])
+	if err != nil {
+		return err
+	}
+	bp.Ignore, err = strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("count must be a number")
+	}
+
+	return t.client.AmendBreakpoint(bp)
+}
+
+func examineMemCmd(t *Term, ctx callContext, argstr string) error {
+	var (
+		asPointers bool
+		wordSize   = 8
+		count      = 8
+		err        error
+	)
+	args := strings.Fields(argstr)
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-p":
+			asPointers = true
+			args = args[1:]
+		case "-size":
+			if len(args) < 2 {
+				return fmt.Errorf("not enough arguments")
+			}
+			if wordSize, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("wrong argument: %s is not a number", args[1])
+			}
+			args = args[2:]
+		default:
+			return fmt.Errorf("unknown option %s", args[0])
+		}
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("wrong number of arguments: examinemem [-p] [-size <n>] <address> [<count>]")
+	}
+	addr, err := strconv.ParseUint(args[0], 0, 64)
+	if err != nil {
+		return fmt.Errorf("wrong argument: %s is not a number", args[0])
+	}
+	if len(args) == 2 {
+		if count, err = strconv.Atoi(args[1]); err != nil {
+			return fmt.Errorf("wrong argument: %s is not a number", args[1])
+		}
+	}
+
+	words, err := t.client.ExamineMemory(addr, count, wordSize, asPointers)
+	if err != nil {
+		return err
+	}
+	for _, w := range words {
+		fmt.Printf("%#016x:  %#0*x", w.Addr, wordSize*2+2, w.Value)
+		switch {
+		case w.Symbol != "":
+			fmt.Printf("\t<%s %s>", w.PointerKind, w.Symbol)
+		case w.PointerKind != "":
+			fmt.Printf("\t<%s>", w.PointerKind)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
 // ShortenFilePath take a full file path and attempts to shorten
 // it by replacing the current directory to './'.
 func ShortenFilePath(fullPath string) string {
@@ -1400,6 +1595,9 @@ func formatBreakpointName(bp *api.Breakpoint, upcase bool) string {
 }
 
 func formatBreakpointLocation(bp *api.Breakpoint) string {
+	if bp.WatchExpr != "" {
+		return fmt.Sprintf("%#v for watchpoint on %s", bp.Addr, bp.WatchExpr)
+	}
 	p := ShortenFilePath(bp.File)
 	if bp.FunctionName != "" {
 		return fmt.Sprintf("%#v for %s() %s:%d", bp.Addr, bp.FunctionName, p, bp.Line)
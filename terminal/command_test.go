@@ -188,7 +188,7 @@ func TestExecuteFile(t *testing.T) {
 
 func TestIssue354(t *testing.T) {
 	printStack([]api.Stackframe{}, "")
-	printStack([]api.Stackframe{{api.Location{PC: 0, File: "irrelevant.go", Line: 10, Function: nil}, nil, nil}}, "")
+	printStack([]api.Stackframe{{api.Location{PC: 0, File: "irrelevant.go", Line: 10, Function: nil}, nil, nil, nil}}, "")
 }
 
 func TestIssue411(t *testing.T) {
@@ -450,3 +450,20 @@ func TestIssue387(t *testing.T) {
 		}
 	})
 }
+
+func TestIgnore(t *testing.T) {
+	withTestTerminal("callme", t, func(term *FakeTerminal) {
+		term.MustExec("break callmebp main.callme")
+		term.AssertExecError("ignore callmebp", "not enough arguments")
+		term.AssertExecError("ignore callmebp two", "count must be a number")
+		term.MustExec("ignore callmebp 2")
+		if out := term.MustExec("breakpoints"); !strings.Contains(out, "\tignore 2") {
+			t.Fatalf("ignore count not listed:\n%s", out)
+		}
+		term.MustExec("continue")
+		term.AssertExec("print i", "2\n")
+		if out := term.MustExec("breakpoints"); strings.Contains(out, "\tignore") {
+			t.Fatalf("ignore count not consumed:\n%s", out)
+		}
+	})
+}